@@ -1,108 +1,62 @@
 package javaTokenizer
 
 import (
-	"strconv"
+	_ "embed"
+	"fmt"
+	"sync"
 	tz "tp/src/tokenizer"
-	"tp/src/util"
-	"unicode"
 )
 
-func GetJavaTokenizer() *tz.Tokenizer {
-	tkzr := tz.GenerateDefaultTokenizerObject()
-	tkzr.ConfigureGeneral("java", symbols, keywords,
-		// Function To Determine if a letter can be part of a keyword in this language
-		func(c rune) bool {
-			return unicode.IsLetter(c) || unicode.IsDigit(c) || c == '_'
-		},
-	)
-	tkzr.ConfigureComment(
-		// Comment Start
-		func(tkzr *tz.Tokenizer) bool {
-			if tkzr.CurrentChar() == '/' {
-				if tkzr.TextSize() > tkzr.Index()+1 {
-					nextChar := tkzr.GetChar(tkzr.Index() + 1)
-					if nextChar == '/' {
-						tkzr.IncrementIndex()
+//go:embed java.json
+var javaDefinition []byte
 
-						tkzr.StartInfo = "//"
-						tkzr.EndInfo = "\n"
-						tkzr.FunctionSharedInfo = strconv.Itoa(tkzr.GetCurrentLineNumber())
-						return true
-					}
-					if nextChar == '*' {
-						tkzr.IncrementIndex()
+var (
+	sharedJavaLanguage     *tz.Language
+	sharedJavaLanguageOnce sync.Once
+)
 
-						tkzr.StartInfo = "/*"
-						tkzr.EndInfo = "*/"
-						return true
-					}
-				}
-			}
-			return false
-		},
-		// Comment End
-		func(tkzr *tz.Tokenizer) bool {
-			if rune(tkzr.EndInfo[0]) == '\n' {
-				num, err := strconv.Atoi(tkzr.FunctionSharedInfo)
-				if err != nil {
-					util.Error("FunctionSharedInfo in CommentEndFunction was not a line number", err)
-					panic(err)
-				}
-				return tkzr.GetCurrentLineNumber() != num
-			}
-			currentIndex := tkzr.Index()
-			if tkzr.TextSize() > currentIndex+1 {
-				nextTwoCharacter, err := tkzr.TextRange(currentIndex, currentIndex+2)
-				if err != nil {
-					return false
-				}
-				if nextTwoCharacter == tkzr.EndInfo {
-					return true
-				}
-			}
-			return false
-		},
-	)
-	tkzr.ConfigureScope(
-		// Scope Start
-		func(tkzr *tz.Tokenizer) bool {
-			if tkzr.CurrentChar() == '{' {
-				tkzr.StartInfo = "{"
-				return true
-			}
+// GetJavaTokenizer
+// Creates a tokenizer for Java with its own copy of the Java language (see tz.NewTokenizer),
+// so configuring it does not affect any other tokenizer.
+func GetJavaTokenizer() *tz.Tokenizer {
+	return tz.NewTokenizer(GetJavaLanguage())
+}
 
-			return false
-		},
-		// Scope End
-		func(tkzr *tz.Tokenizer) bool {
-			if tkzr.CurrentChar() == '}' {
-				tkzr.EndInfo = "}"
-				return true
-			}
+// GetJavaLanguage
+// Returns the Java language, which is only built once and is shared by every caller.
+// It is safe to tokenize with it from many goroutines at once (with a tokenizer per goroutine,
+// created with tz.NewTokenizer), but it must not be configured; use Copy to configure it.
+func GetJavaLanguage() *tz.Language {
+	sharedJavaLanguageOnce.Do(func() {
+		sharedJavaLanguage = newJavaLanguage()
+	})
+	return sharedJavaLanguage
+}
+
+// GetJavaDefinition
+// Returns the definition of Java bundled with this package (java.json), which the Java language is built from.
+// Only how the scopes of Java are classified is not part of the definition, since it is written in Go (see classifyScope).
+func GetJavaDefinition() (*tz.LanguageDefinition, error) {
+	return tz.ParseLanguageDefinition(javaDefinition, tz.DEFINITION_FORMAT_JSON)
+}
 
-			return false
-		},
-	)
-	tkzr.ConfigureString(
-		// String Start
-		func(tkzr *tz.Tokenizer) bool {
-			if tkzr.CurrentChar() == '"' {
-				tkzr.StartInfo = "\""
-				tkzr.EndInfo = "\""
-				return true
-			}
-			if tkzr.CurrentChar() == '\'' {
-				tkzr.StartInfo = "'"
-				tkzr.EndInfo = "'"
-				return true
-			}
-			return false
-		},
-		// String End
-		func(tkzr *tz.Tokenizer) bool {
-			return tkzr.GetChar(tkzr.Index()-1) != '\\' && tkzr.CurrentChar() == rune(tkzr.EndInfo[0])
-		},
-	)
+// newJavaLanguage
+// Builds the Java language from its bundled definition, along with the rules for the headers of its scopes.
+// The definition is part of this package, so it can only fail to build because of a bug in it, which panics.
+func newJavaLanguage() *tz.Language {
+	definition, err := GetJavaDefinition()
+	if err != nil {
+		panic(fmt.Sprintf("the bundled Java definition is invalid: %v", err))
+	}
+	lang, err := definition.BuildLanguage()
+	if err != nil {
+		panic(fmt.Sprintf("the bundled Java definition is invalid: %v", err))
+	}
 
-	return &tkzr
+	lang.ConfigureScopeHeaders(tz.ScopeHeaderRules{
+		StatementEnds: []string{";"},
+		Brackets:      [][2]string{{"(", ")"}, {"[", "]"}},
+		Classifiers:   []tz.ScopeClassifier{classifyScope},
+	})
+	return lang
 }
@@ -1,70 +0,0 @@
-package javaTokenizer
-
-var keywords = []string{
-	"abstract",
-	"assert",
-	"boolean",
-	"break",
-	"byte",
-	"case",
-	"catch",
-	"char",
-	"class",
-	"continue",
-	"default",
-	"do",
-	"double",
-	"else",
-	"enum",
-	"extends",
-	"final",
-	"finally",
-	"float",
-	"for",
-	"if",
-	"implements",
-	"import",
-	"instance",
-	"int",
-	"interface",
-	"long",
-	"native",
-	"new",
-	"package",
-	"private",
-	"protected",
-	"public",
-	"return",
-	"short",
-	"static",
-	"super",
-	"switch",
-	"synchronized",
-	"this",
-	"throw",
-	"throws",
-	"transient",
-	"try",
-	"void",
-	"volatile",
-	"while",
-	"exports",
-	"module",
-	"non-sealed",
-	"open",
-	"opens",
-	"permits",
-	"provides",
-	"record",
-	"requires",
-	"sealed",
-	"to",
-	"transitive",
-	"uses",
-	"var",
-	"when",
-	"yield",
-	"true",
-	"false",
-	"null",
-}
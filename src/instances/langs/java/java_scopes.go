@@ -0,0 +1,132 @@
+package javaTokenizer
+
+import (
+	tz "tp/src/tokenizer"
+	tk "tp/src/tokenizer/tokens"
+)
+
+// scopeTypes
+// The keywords which mark the type of a scope, ordered so "if" is found before "else" in "else if"
+var scopeTypes = []tz.ScopeTypeRule{
+	{Keyword: "class", Type: tz.SCOPE_TYPE_CLASS, Named: true},
+	{Keyword: "interface", Type: tz.SCOPE_TYPE_INTERFACE, Named: true},
+	{Keyword: "enum", Type: tz.SCOPE_TYPE_ENUM, Named: true},
+	{Keyword: "record", Type: tz.SCOPE_TYPE_CLASS, Named: true},
+	{Keyword: "if", Type: tz.SCOPE_TYPE_IF},
+	{Keyword: "else", Type: tz.SCOPE_TYPE_ELSE},
+	{Keyword: "for", Type: tz.SCOPE_TYPE_LOOP},
+	{Keyword: "while", Type: tz.SCOPE_TYPE_LOOP},
+	{Keyword: "do", Type: tz.SCOPE_TYPE_LOOP},
+	{Keyword: "try", Type: tz.SCOPE_TYPE_TRY},
+	{Keyword: "catch", Type: tz.SCOPE_TYPE_CATCH},
+	{Keyword: "finally", Type: tz.SCOPE_TYPE_FINALLY},
+	{Keyword: "switch", Type: tz.SCOPE_TYPE_SWITCH},
+	{Keyword: "synchronized", Type: tz.SCOPE_TYPE_BLOCK},
+}
+
+// controlKeywords
+// The keywords of the statements whose parenthesized part may be followed by a statement without braces (e.g. "if (a) try {")
+var controlKeywords = []string{"if", "for", "while", "switch", "synchronized"}
+
+// classifyKeywordScope
+// Labels a scope by the keywords of its header
+var classifyKeywordScope = tz.KeywordScopeClassifier(scopeTypes)
+
+// classifyScope
+// Labels a scope by its header. Lambdas, switch cases and anonymous classes are found first, since their headers are
+// the end of an expression which may be within any other statement (e.g. the "x ->" of "if (list.stream().anyMatch(x -> {")
+func classifyScope(header []*tk.Token, scope *tk.ScopeObj) (string, string, bool) {
+	if len(header) == 0 || (len(header) == 1 && header[0].Text == "static") {
+		return tz.SCOPE_TYPE_BLOCK, "", true
+	}
+	if header[len(header)-1].Text == "->" {
+		if header[0].Text == "case" || header[0].Text == "default" {
+			return tz.SCOPE_TYPE_CASE, "", true
+		}
+		return tz.SCOPE_TYPE_LAMBDA, "", true
+	}
+	if isAnonymousClass(header) {
+		return tz.SCOPE_TYPE_CLASS, "", true
+	}
+	if scopeType, name, found := classifyKeywordScope(withoutControlStatements(header), scope); found {
+		return scopeType, name, true
+	}
+	// The exceptions a method throws come after its parameters
+	for i, token := range header {
+		if token.Text == "throws" {
+			header = header[:i]
+			break
+		}
+	}
+	if name, found := tz.CallableName(header); found {
+		return tz.SCOPE_TYPE_METHOD, name, true
+	}
+	return "", "", false
+}
+
+// withoutControlStatements
+// Returns the header without the start of the statements without braces which the scope is within
+// (e.g. "try" for the header "if ( a ) try")
+func withoutControlStatements(header []*tk.Token) []*tk.Token {
+	start := 0
+	depth := 0
+	opening := 0
+	for i, token := range header {
+		if token.Text == "(" {
+			if depth == 0 {
+				opening = i
+			}
+			depth++
+		} else if token.Text == ")" && depth > 0 {
+			depth--
+			if depth == 0 && i < len(header)-1 && opening > 0 && isControlKeyword(header[opening-1].Text) {
+				start = i + 1
+			}
+		}
+	}
+	return header[start:]
+}
+
+// isControlKeyword
+// Returns true if the text is one of the controlKeywords
+func isControlKeyword(text string) bool {
+	for _, keyword := range controlKeywords {
+		if keyword == text {
+			return true
+		}
+	}
+	return false
+}
+
+// isAnonymousClass
+// Returns true if the header ends with the creation of an object (e.g. "new Comparator < String > ( )"),
+// in which case the scope is the body of an anonymous class
+func isAnonymousClass(header []*tk.Token) bool {
+	if header[len(header)-1].Text != ")" {
+		return false
+	}
+	depth := 0
+	i := len(header) - 1
+	for ; i >= 0; i-- {
+		if header[i].Text == ")" {
+			depth++
+		} else if header[i].Text == "(" {
+			depth--
+		}
+		if depth == 0 {
+			break
+		}
+	}
+	// The name of the class (which may be qualified and generic) is between "new" and the arguments
+	for i--; i >= 0; i-- {
+		switch {
+		case header[i].Text == "new":
+			return true
+		case header[i].Kind.IsIdentifier(), header[i].Text == ".", header[i].Text == "<", header[i].Text == ">",
+			header[i].Text == ",", header[i].Text == "?":
+			continue
+		}
+		return false
+	}
+	return false
+}
@@ -1,184 +1,63 @@
 package pythonTokenizer
 
 import (
-	"errors"
+	_ "embed"
 	"fmt"
-	"strconv"
-	"strings"
+	"sync"
 	tz "tp/src/tokenizer"
-	"tp/src/util"
-	"unicode"
 )
 
-func GetPythonTokenizer() *tz.Tokenizer {
-	tkzr := tz.GenerateDefaultTokenizerObject()
-
-	setCommentLineNumber := func(tkzr *tz.Tokenizer) {
-		stopIndex := strings.Index(tkzr.FunctionSharedInfo, "|")
-		if stopIndex == -1 { // Just add |
-			tkzr.FunctionSharedInfo = "|" + tkzr.FunctionSharedInfo
-		} else { // Remove previous line number
-			tkzr.FunctionSharedInfo = tkzr.FunctionSharedInfo[stopIndex:]
-		}
-		tkzr.FunctionSharedInfo = strconv.Itoa(tkzr.GetCurrentLineNumber()) + tkzr.FunctionSharedInfo
-	}
-
-	getCommentLineNumber := func(tkzr *tz.Tokenizer) (int, error) {
-		stopIndex := strings.Index(tkzr.FunctionSharedInfo, "|")
-		if stopIndex == -1 { // No line number was ever assigned
-			return -1, errors.New("attempted to get line number when none was assigned")
-		}
-		lineNumber, err := strconv.Atoi(tkzr.FunctionSharedInfo[0:stopIndex])
-		if err != nil {
-			return -1, errors.New("failed to convert line number string to int. attempted string: " + tkzr.FunctionSharedInfo[0:stopIndex])
-		}
-		return lineNumber, nil
-	}
+//go:embed python.yaml
+var pythonDefinition []byte
 
-	pushScopeInfo := func(tkzr *tz.Tokenizer) {
-		tkzr.FunctionSharedInfo += fmt.Sprintf("&%d,%d", tkzr.GetCurrentLineNumber(), tkzr.GetCurrentTabLevel())
-	}
-
-	const NoScopeInfoFoundErrorString = "no scope info pair found"
+var (
+	sharedPythonLanguage     *tz.Language
+	sharedPythonLanguageOnce sync.Once
+)
 
-	frontScopeInfo := func(tkzr *tz.Tokenizer) (int, int, error) {
-		stopIndex := strings.LastIndex(tkzr.FunctionSharedInfo, "&")
-		if stopIndex == -1 {
-			return -1, -1, errors.New(NoScopeInfoFoundErrorString)
-		}
-		pairString := tkzr.FunctionSharedInfo[stopIndex+1:]
-		pairs := strings.Split(pairString, ",")
-		if len(pairs) != 2 {
-			return -1, -1, errors.New("scope info pair malformed (no comma): " + pairString)
-		}
+// GetPythonTokenizer
+// Creates a tokenizer for Python with its own copy of the Python language (see tz.NewTokenizer),
+// so configuring it does not affect any other tokenizer.
+func GetPythonTokenizer() *tz.Tokenizer {
+	return tz.NewTokenizer(GetPythonLanguage())
+}
 
-		lineNum, err := strconv.Atoi(pairs[0])
-		if err != nil {
-			return -1, -1, errors.New("scope info pair malformed (could not convert line number): " + pairString)
-		}
+// GetPythonLanguage
+// Returns the Python language, which is only built once and is shared by every caller.
+// It is safe to tokenize with it from many goroutines at once (with a tokenizer per goroutine,
+// created with tz.NewTokenizer), but it must not be configured; use Copy to configure it.
+func GetPythonLanguage() *tz.Language {
+	sharedPythonLanguageOnce.Do(func() {
+		sharedPythonLanguage = newPythonLanguage()
+	})
+	return sharedPythonLanguage
+}
 
-		tabLevel, err := strconv.Atoi(pairs[1])
-		if err != nil {
-			return -1, -1, errors.New("scope info pair malformed (could not convert tab level): " + pairString)
-		}
+// GetPythonDefinition
+// Returns the definition of Python bundled with this package (python.yaml), which the Python language is built from.
+// Only how the scopes of Python are classified is not part of the definition, since it is written in Go (see classifyScope).
+func GetPythonDefinition() (*tz.LanguageDefinition, error) {
+	return tz.ParseLanguageDefinition(pythonDefinition, tz.DEFINITION_FORMAT_YAML)
+}
 
-		return lineNum, tabLevel, nil
+// newPythonLanguage
+// Builds the Python language from its bundled definition, along with the rules for the headers of its scopes.
+// The definition is part of this package, so it can only fail to build because of a bug in it, which panics.
+func newPythonLanguage() *tz.Language {
+	definition, err := GetPythonDefinition()
+	if err != nil {
+		panic(fmt.Sprintf("the bundled Python definition is invalid: %v", err))
 	}
-
-	popScopeInfo := func(tkzr *tz.Tokenizer) error {
-		stopIndex := strings.LastIndex(tkzr.FunctionSharedInfo, "&")
-		if stopIndex == -1 {
-			return errors.New(NoScopeInfoFoundErrorString)
-		}
-		tkzr.FunctionSharedInfo = tkzr.FunctionSharedInfo[:stopIndex]
-		return nil
+	lang, err := definition.BuildLanguage()
+	if err != nil {
+		panic(fmt.Sprintf("the bundled Python definition is invalid: %v", err))
 	}
 
-	tkzr.ConfigureGeneral("python", symbols, keywords,
-		// Function To Determine if a letter can be part of a keyword in this language
-		func(c rune) bool {
-			return unicode.IsLetter(c) || unicode.IsDigit(c) || c == '_'
-		},
-	)
-	tkzr.ConfigureComment(
-		// Comment Start
-		func(tkzr *tz.Tokenizer) bool {
-			if tkzr.CurrentChar() == '#' {
-				tkzr.StartInfo = "#"
-				tkzr.EndInfo = "\n"
-				setCommentLineNumber(tkzr)
-				return true
-			}
-			return false
-		},
-		// Comment End
-		func(tkzr *tz.Tokenizer) bool {
-			num, err := getCommentLineNumber(tkzr)
-			if err != nil {
-				util.Error("FunctionSharedInfo in CommentEndFunction error (python)", err)
-				panic(err)
-			}
-			return tkzr.GetCurrentLineNumber() != num
-		},
-	)
-	tkzr.ConfigureScope(
-		// Scope Start
-		func(tkzr *tz.Tokenizer) bool {
-			if tkzr.CurrentChar() == ':' {
-				tkzr.StartInfo = ":"
-				pushScopeInfo(tkzr)
-				return true
-			}
-
-			return false
-		},
-		// Scope End
-		func(tkzr *tz.Tokenizer) bool {
-			lineNum, tabNum, err := frontScopeInfo(tkzr)
-			if err != nil {
-				if err.Error() != NoScopeInfoFoundErrorString {
-					util.Error("Python frontScopeInfo Error (see python tokenizer instance)", err)
-					panic(err)
-				}
-				return false
-			}
-
-			if tkzr.GetCurrentLineNumber() != lineNum && tkzr.GetCurrentTabLevel() <= tabNum {
-				tkzr.EndInfo = ""
-				err = popScopeInfo(tkzr)
-				if err != nil {
-					util.Error("Failed to pop value that should exist"+fmt.Sprintf(" line number: %d     tab number: %d   ", lineNum, tabNum), err)
-					panic(err)
-				}
-				tkzr.SkipIncrement() // THIS SHOULD MAKE SURE THAT IF MULTIPLE SCOPES NEED TO BE STOPPED AT THE SAME TOKEN, THAT IT STOPS MOVING TO DO ALL THE STOPS
-				return true
-			}
-
-			return false
-		},
-	)
-	tkzr.ConfigureString(
-		// String Start
-		func(tkzr *tz.Tokenizer) bool {
-			if tkzr.CurrentChar() == '"' {
-				tkzr.StartInfo = "\""
-				tkzr.EndInfo = "\""
-
-				substring, err := tkzr.TextRange(tkzr.Index(), tkzr.Index()+3)
-				if err == nil && substring == "\"\"\"" {
-					tkzr.StartInfo = "\"\"\""
-					tkzr.EndInfo = "\"\"\""
-				}
-				return true
-			}
-
-			if tkzr.CurrentChar() == '\'' {
-				tkzr.StartInfo = "'"
-				tkzr.EndInfo = "'"
-
-				substring, err := tkzr.TextRange(tkzr.Index(), tkzr.Index()+3)
-				if err == nil && substring == "'''" {
-					tkzr.StartInfo = "'''"
-					tkzr.EndInfo = "'''"
-				}
-				return true
-			}
-			return false
-		},
-		// String End
-		func(tkzr *tz.Tokenizer) bool {
-			if len(tkzr.EndInfo) == 3 {
-				substring, err := tkzr.TextRange(tkzr.Index(), tkzr.Index()+3)
-				if err == nil && tkzr.GetChar(tkzr.Index()-1) != '\\' && substring == tkzr.EndInfo {
-					return true
-				}
-				if err != nil { // TODO: this would only happen if a string never ended
-					return true
-				}
-			}
-			return tkzr.GetChar(tkzr.Index()-1) != '\\' && tkzr.CurrentChar() == rune(tkzr.EndInfo[0])
-		},
-	)
-
-	return &tkzr
+	// Statements end with their logical lines
+	lang.ConfigureScopeHeaders(tz.ScopeHeaderRules{
+		Brackets:               [][2]string{{"(", ")"}, {"[", "]"}, {"{", "}"}},
+		StatementsEndWithLines: true,
+		Classifiers:            []tz.ScopeClassifier{classifyScope},
+	})
+	return lang
 }
@@ -1,38 +0,0 @@
-package pythonTokenizer
-
-var keywords = []string{
-	"and",
-	"as",
-	"assert",
-	"break",
-	"class",
-	"continue",
-	"def",
-	"del",
-	"elif",
-	"else",
-	"except",
-	"False",
-	"finally",
-	"for",
-	"from",
-	"if",
-	"import",
-	"in",
-	"is",
-	"lambda",
-	"None",
-	"nonlocal",
-	"not",
-	"or",
-	"pass",
-	"raise",
-	"return",
-	"True",
-	"try",
-	"while",
-	"with",
-	"yield",
-	"match",
-	"case",
-}
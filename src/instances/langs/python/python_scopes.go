@@ -0,0 +1,40 @@
+package pythonTokenizer
+
+import (
+	tz "tp/src/tokenizer"
+	tk "tp/src/tokenizer/tokens"
+)
+
+// scopeTypes
+// The keywords which mark the type of a scope, ordered so a statement's own keyword is found before the keywords of
+// the expressions within it (e.g. the "if" of "if a if b else c:")
+var scopeTypes = []tz.ScopeTypeRule{
+	{Keyword: "class", Type: tz.SCOPE_TYPE_CLASS, Named: true},
+	{Keyword: "def", Type: tz.SCOPE_TYPE_FUNCTION, Named: true},
+	{Keyword: "if", Type: tz.SCOPE_TYPE_IF},
+	{Keyword: "elif", Type: tz.SCOPE_TYPE_IF},
+	{Keyword: "else", Type: tz.SCOPE_TYPE_ELSE},
+	{Keyword: "for", Type: tz.SCOPE_TYPE_LOOP},
+	{Keyword: "while", Type: tz.SCOPE_TYPE_LOOP},
+	{Keyword: "try", Type: tz.SCOPE_TYPE_TRY},
+	{Keyword: "except", Type: tz.SCOPE_TYPE_CATCH},
+	{Keyword: "finally", Type: tz.SCOPE_TYPE_FINALLY},
+	{Keyword: "with", Type: tz.SCOPE_TYPE_WITH},
+	{Keyword: "match", Type: tz.SCOPE_TYPE_SWITCH},
+	{Keyword: "case", Type: tz.SCOPE_TYPE_CASE},
+	{Keyword: "lambda", Type: tz.SCOPE_TYPE_LAMBDA},
+}
+
+// classifyKeywordScope
+// Labels a scope by the keywords of its header
+var classifyKeywordScope = tz.KeywordScopeClassifier(scopeTypes)
+
+// classifyScope
+// Labels a scope by its header. A function defined directly within a class is a method
+func classifyScope(header []*tk.Token, scope *tk.ScopeObj) (string, string, bool) {
+	scopeType, name, found := classifyKeywordScope(header, scope)
+	if scopeType == tz.SCOPE_TYPE_FUNCTION && scope.GetScopeParent() != nil && scope.GetScopeParent().GetType() == tz.SCOPE_TYPE_CLASS {
+		scopeType = tz.SCOPE_TYPE_METHOD
+	}
+	return scopeType, name, found
+}
@@ -0,0 +1,4 @@
+// bersicht: caf
+public class Caf {
+    String gre = "hllo";
+}
@@ -0,0 +1,4 @@
+// Übersicht: café
+public class Café {
+    String grüße = "héllo";
+}
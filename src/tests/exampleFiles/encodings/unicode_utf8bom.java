@@ -0,0 +1,7 @@
+﻿// Übersicht: 日本語のコメント
+public class Café {
+    public static void main(String[] args) {
+        String grüße = "héllo 🌍";
+        int 変数 = grüße;
+    }
+}
@@ -0,0 +1,11 @@
+/*
+This class is called hello!
+it is used to print out "Hello World"
+ */
+
+public class hello {
+    public static void main(String[] args) {
+        // This prints out stuff
+        System.out.println("Hello World");
+    }
+}
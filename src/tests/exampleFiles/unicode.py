@@ -0,0 +1,6 @@
+# Übersicht: 日本語のコメント
+
+def grüße(名前):
+    return "héllo 🌍" + 名前
+
+grüße('Zoë')
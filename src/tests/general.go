@@ -27,3 +27,34 @@ func VerifyUnknownSymbol(t *testing.T, tkn *tk.Token, expectedLineNum int, expec
 func VerifyUnknownKeyword(t *testing.T, tkn *tk.Token, expectedLineNum int, expectedTabNum int, expectedText string) {
 	ValidateToken(t, tkn, expectedLineNum, expectedTabNum, tz.RULENAME_KEYWORD, tz.SYMBOLIC_NAME_NON_KEYWORD, expectedText)
 }
+
+func ValidateTokenSpan(t *testing.T, tkn *tk.Token, expectedStart tk.Position, expectedEnd tk.Position) {
+	invalidSpanStr := fmt.Sprintf("This token had an invalid span: %s\nExpected span: %s - %s", tkn.ToString(), expectedStart.ToString(), expectedEnd.ToString())
+
+	assert.Equal(t, expectedStart, tkn.Start, invalidSpanStr)
+	assert.Equal(t, expectedEnd, tkn.End, invalidSpanStr)
+}
+
+func ValidateDiagnostic(t *testing.T, diagnostic tz.Diagnostic, expectedSeverity tz.Severity, expectedCode string, expectedStart tk.Position, expectedEnd tk.Position) {
+	invalidDiagnosticStr := fmt.Sprintf("This diagnostic was invalid: %s", diagnostic.ToString())
+
+	assert.Equal(t, expectedSeverity, diagnostic.Severity, invalidDiagnosticStr)
+	assert.Equal(t, expectedCode, diagnostic.Code, invalidDiagnosticStr)
+	assert.Equal(t, expectedStart, diagnostic.Start, invalidDiagnosticStr)
+	assert.Equal(t, expectedEnd, diagnostic.End, invalidDiagnosticStr)
+}
+
+func CreateTestToken(text string, kind tk.Kind, symbolicName string) *tk.Token {
+	token := tk.CreateUnidentifiedToken(text, 1, 0)
+	token.Kind = kind
+	token.SymbolicName = symbolicName
+	return &token
+}
+
+func TokenTexts(tokenList []*tk.Token) []string {
+	texts := make([]string, 0, len(tokenList))
+	for _, token := range tokenList {
+		texts = append(texts, token.Text)
+	}
+	return texts
+}
@@ -0,0 +1,137 @@
+package instances_test
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+	javaTokenizer "tp/src/instances/langs/java"
+	pythonTokenizer "tp/src/instances/langs/python"
+	tz "tp/src/tokenizer"
+	"tp/src/util"
+)
+
+// benchmarkCorpusSize
+// The rough size (in bytes) of the text each benchmark tokenizes
+const benchmarkCorpusSize = 1 << 20
+
+// javaCorpus
+// Builds a large java corpus by repeating the example java files
+func javaCorpus(b *testing.B) string {
+	var builder strings.Builder
+	for builder.Len() < benchmarkCorpusSize {
+		for _, path := range []string{"../exampleFiles/file.java", "../exampleFiles/charAndNums.java", "../exampleFiles/unicode.java"} {
+			text, err := util.GetTextOfFile(path)
+			if err != nil {
+				b.Fatalf("Failed to find file: %s", path)
+			}
+			builder.WriteString(text)
+			builder.WriteString("\n")
+		}
+	}
+	return builder.String()
+}
+
+// pythonCorpus
+// Builds a large python corpus out of the example python files and generated functions
+func pythonCorpus(b *testing.B) string {
+	var builder strings.Builder
+	for i := 0; builder.Len() < benchmarkCorpusSize; i++ {
+		for _, path := range []string{"../exampleFiles/hello.py", "../exampleFiles/unicode.py"} {
+			text, err := util.GetTextOfFile(path)
+			if err != nil {
+				b.Fatalf("Failed to find file: %s", path)
+			}
+			builder.WriteString(text)
+			builder.WriteString("\n")
+		}
+		builder.WriteString(fmt.Sprintf("class Shape%d:\n", i))
+		builder.WriteString("    \"\"\"A shape with a number of sides\"\"\"\n\n")
+		builder.WriteString(fmt.Sprintf("    def area(self, width, height=%d.5):\n", i))
+		builder.WriteString("        # Only rectangles are supported for now\n")
+		builder.WriteString("        if width > 0 and height > 0:\n")
+		builder.WriteString(fmt.Sprintf("            return width * height + 0x%X\n", i))
+		builder.WriteString("        else:\n")
+		builder.WriteString("            raise ValueError('sizes must be positive')\n\n")
+	}
+	return builder.String()
+}
+
+// benchmarkTokenize
+// Tokenizes the corpus b.N times, reporting the throughput along with the allocations made per token
+func benchmarkTokenize(b *testing.B, create func() *tz.Tokenizer, corpus string) {
+	tokenizer := create()
+	tokensScope, _, err := tokenizer.Tokenize(corpus)
+	if err != nil {
+		b.Fatal(err)
+	}
+	tokensPerRun := tokensScope.TotalSize()
+
+	var before runtime.MemStats
+	var after runtime.MemStats
+	b.SetBytes(int64(len(corpus)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	runtime.ReadMemStats(&before)
+	for i := 0; i < b.N; i++ {
+		_, _, _ = tokenizer.Tokenize(corpus)
+	}
+	runtime.ReadMemStats(&after)
+	b.StopTimer()
+
+	b.ReportMetric(float64(after.Mallocs-before.Mallocs)/float64(tokensPerRun*b.N), "allocs/token")
+}
+
+func Benchmark_javaTokenizer(b *testing.B) {
+	benchmarkTokenize(b, javaTokenizer.GetJavaTokenizer, javaCorpus(b))
+}
+
+func Benchmark_pythonTokenizer(b *testing.B) {
+	benchmarkTokenize(b, pythonTokenizer.GetPythonTokenizer, pythonCorpus(b))
+}
+
+func Benchmark_javaTokenizer_Stream(b *testing.B) {
+	corpus := javaCorpus(b)
+	tokenizer := javaTokenizer.GetJavaTokenizer()
+
+	var before runtime.MemStats
+	var after runtime.MemStats
+	tokens := 0
+	b.SetBytes(int64(len(corpus)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	runtime.ReadMemStats(&before)
+	for i := 0; i < b.N; i++ {
+		stream, err := tokenizer.TokenizeReader(strings.NewReader(corpus))
+		if err != nil {
+			b.Fatal(err)
+		}
+		for stream.Next() {
+			if stream.Event().Type == tz.EVENT_TOKEN {
+				tokens++
+			}
+		}
+	}
+	runtime.ReadMemStats(&after)
+	b.StopTimer()
+
+	b.ReportMetric(float64(after.Mallocs-before.Mallocs)/float64(tokens), "allocs/token")
+}
+
+func Test_tokenizerAllocations(t *testing.T) {
+	text := strings.Repeat("int value = other + 0x1F; if (value >= 10) { value = value * 2; }\n", 200)
+	tokenizer := javaTokenizer.GetJavaTokenizer()
+	tokensScope, _, err := tokenizer.Tokenize(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Apart from building the scope tree, the only allocation needed for a token is the token itself
+	allocs := testing.AllocsPerRun(5, func() {
+		_, _, _ = tokenizer.Tokenize(text)
+	})
+	allocsPerToken := allocs / float64(tokensScope.TotalSize())
+	if allocsPerToken > 1.5 {
+		t.Errorf("expected at most 1.5 allocations per token, got %.2f", allocsPerToken)
+	}
+}
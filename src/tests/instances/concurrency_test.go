@@ -0,0 +1,123 @@
+package instances_test
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"sync"
+	"testing"
+	javaTokenizer "tp/src/instances/langs/java"
+	pythonTokenizer "tp/src/instances/langs/python"
+	tz "tp/src/tokenizer"
+	tk "tp/src/tokenizer/tokens"
+	"tp/src/util"
+	"unicode"
+)
+
+// scopeFingerprint
+// Returns a string describing every token in the scope, used to compare results
+func scopeFingerprint(scope *tk.ScopeObj) string {
+	var builder strings.Builder
+	for _, tkn := range scope.ConvertToArray() {
+		builder.WriteString(tkn.ToString())
+		builder.WriteString(tkn.Start.ToString())
+		builder.WriteString(tkn.End.ToString())
+	}
+	return builder.String()
+}
+
+// tokenizeConcurrently
+// Tokenizes every file many times at once with tokenizers sharing the one language,
+// checking that each result matches the result of tokenizing the file on its own.
+// Run with -race to detect any state shared between the tokenizers.
+func tokenizeConcurrently(t *testing.T, language *tz.Language, filepaths []string) {
+	texts := make([]string, len(filepaths))
+	expected := make([]string, len(filepaths))
+	for i, filepath := range filepaths {
+		text, err := util.GetTextOfFile(filepath)
+		if err != nil {
+			util.Error(fmt.Sprintf("Failed to find file: %s", filepath), err)
+			assert.Fail(t, "No file found")
+		}
+		texts[i] = text
+
+		tokensScope, _, err := tz.NewTokenizer(language).Tokenize(text)
+		assert.Nil(t, err)
+		expected[i] = scopeFingerprint(&tokensScope)
+	}
+
+	numberOfWorkers := 16
+	runsPerWorker := 4
+	results := make(chan error, numberOfWorkers*runsPerWorker*len(filepaths))
+	var waitGroup sync.WaitGroup
+	for worker := 0; worker < numberOfWorkers; worker++ {
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+			for run := 0; run < runsPerWorker; run++ {
+				// A single tokenizer is reused for many files on the same goroutine
+				tokenizer := tz.NewTokenizer(language)
+				for i := range texts {
+					tokensScope, _, err := tokenizer.Tokenize(texts[i])
+					if err == nil && scopeFingerprint(&tokensScope) != expected[i] {
+						err = fmt.Errorf("tokens of %s differ when tokenized concurrently", filepaths[i])
+					}
+					results <- err
+				}
+			}
+		}()
+	}
+	waitGroup.Wait()
+	close(results)
+
+	for err := range results {
+		assert.Nil(t, err)
+	}
+}
+
+func Test_javaLanguage_Concurrent(t *testing.T) {
+	tokenizeConcurrently(t, javaTokenizer.GetJavaLanguage(), []string{
+		"../exampleFiles/hello.java",
+		"../exampleFiles/file.java",
+		"../exampleFiles/charAndNums.java",
+		"../exampleFiles/unicode.java",
+	})
+}
+
+func Test_pythonLanguage_Concurrent(t *testing.T) {
+	tokenizeConcurrently(t, pythonTokenizer.GetPythonLanguage(), []string{
+		"../exampleFiles/hello.py",
+		"../exampleFiles/unicode.py",
+	})
+}
+
+func Test_sharedLanguage(t *testing.T) {
+	// The shared language is only built once
+	assert.Same(t, javaTokenizer.GetJavaLanguage(), javaTokenizer.GetJavaLanguage())
+	assert.Same(t, pythonTokenizer.GetPythonLanguage(), pythonTokenizer.GetPythonLanguage())
+
+	// Configuring a tokenizer from GetJavaTokenizer does not affect the shared language
+	tokenizer := javaTokenizer.GetJavaTokenizer()
+	tokenizer.ConfigureIgnores(true, true, false, false)
+	assert.True(t, javaTokenizer.GetJavaLanguage().IncludeComments)
+
+	// Nor does configuring a tokenizer created with the shared language
+	tokenizer = tz.NewTokenizer(pythonTokenizer.GetPythonLanguage())
+	tokenizer.ConfigureIgnores(false, false, false, true)
+	tokenizer.ConfigureGeneral("changed", [][]string{}, []string{}, unicode.IsLetter)
+	assert.Equal(t, "changed", tokenizer.LanguageType)
+	assert.Equal(t, "python", pythonTokenizer.GetPythonLanguage().LanguageType)
+	assert.True(t, pythonTokenizer.GetPythonLanguage().IgnoreWhitespace)
+	assert.Equal(t, "python", pythonTokenizer.GetPythonTokenizer().LanguageType)
+
+	// Neither does configuring a copy of the shared language
+	languageCopy := javaTokenizer.GetJavaLanguage().Copy()
+	languageCopy.ConfigureIgnores(true, true, false, false)
+	assert.False(t, languageCopy.IncludeComments)
+	assert.True(t, javaTokenizer.GetJavaLanguage().IncludeComments)
+}
+
+func Test_tokenizerWithoutLanguage(t *testing.T) {
+	_, _, err := tz.NewTokenizer(nil).Tokenize("public class hello {}")
+	assert.NotNil(t, err)
+}
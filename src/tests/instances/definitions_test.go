@@ -0,0 +1,136 @@
+package instances_test
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"strings"
+	"testing"
+	javaTokenizer "tp/src/instances/langs/java"
+	pythonTokenizer "tp/src/instances/langs/python"
+	tz "tp/src/tokenizer"
+	tk "tp/src/tokenizer/tokens"
+	"tp/src/util"
+)
+
+// javaStreamFiles
+// The files the token streams of Java are pinned for
+var javaStreamFiles = []string{
+	"../exampleFiles/hello.java",
+	"../exampleFiles/file.java",
+	"../exampleFiles/charAndNums.java",
+	"../exampleFiles/unicode.java",
+}
+
+// javaStreamTexts
+// The texts the token streams of Java are pinned for, which cover what the files do not
+var javaStreamTexts = []string{
+	"String s = \"a\\\"b\\\\\"; char c = '\\'';",
+	"/* a /* b */ int x = 0x1F; // done\n}",
+	"class A { void f() { } } }",
+	"String s = \"abc\nint x = 1; /* never ended",
+	"",
+}
+
+// pythonStreamFiles
+// The files the token streams of Python are pinned for
+var pythonStreamFiles = []string{
+	"../exampleFiles/hello.py",
+	"../exampleFiles/unicode.py",
+}
+
+// pythonStreamTexts
+// The texts the token streams of Python are pinned for, which cover what the files do not
+var pythonStreamTexts = []string{
+	"x = \"\"\"doc \" string\"\"\"\ny = '''a\nb'''\n",
+	"s = 'it\\'s' + \"\\\\\"\n",
+	"if (n := 10) > 5:\n    print(n)\n    if n:\n        pass\nprint('done')\n",
+	"x = '''never ended\ny = 1\n",
+	"s = f'{{x}} {d[\"k\"]!r:>{w}} {n}' + rb'\\d' + F\"\"\"\n{ {1: 2}[1] }\"\"\"\n",
+	"s = u'a' + Rb\"b\" + bu'c' + xf'd' + fR'{e}' + f'{f\"{g}\"}'\n",
+	"s = f'{x\nt = f'{{{y} {z\n",
+	"if x:\n    s = f\"{y",
+	"def f(a,\n  b):\n\tif a:\n\t  x = [1,\n2]\n# c\n\n\t  y = \\\n1\n  z\n\treturn x\nf()\n",
+	"",
+}
+
+// describeTokens
+// Describes every token within the scope on a line of its own: its position, the names of its kind, and its text.
+// The tokens of a nested scope are indented beneath a line for the scope.
+func describeTokens(builder *strings.Builder, scope *tk.ScopeObj, depth int) {
+	for _, tkn := range scope.GetTokenList() {
+		builder.WriteString(strings.Repeat("\t", depth))
+		if tkn.ValidScopeToken() {
+			builder.WriteString("scope\n")
+			describeTokens(builder, tkn.GetScopeToken(), depth+1)
+			continue
+		}
+		builder.WriteString(fmt.Sprintf("%d:%d %s %s %q\n", tkn.Start.Line, tkn.Start.Column, tkn.RuleName, tkn.SymbolicName, tkn.Text))
+	}
+}
+
+// tokenStreams
+// Tokenizes every file and text with and without recovery mode, returning a description of the tokens (see describeTokens)
+// and of the diagnostics of each
+func tokenStreams(t *testing.T, language *tz.Language, filepaths []string, texts []string) string {
+	names := make([]string, 0, len(filepaths)+len(texts))
+	for _, text := range texts {
+		names = append(names, fmt.Sprintf("%q", text))
+	}
+	for _, filepath := range filepaths {
+		text, err := util.GetTextOfFile(filepath)
+		if err != nil {
+			util.Error(fmt.Sprintf("Failed to find file: %s", filepath), err)
+			assert.Fail(t, "No file found")
+		}
+		names = append(names, filepath)
+		texts = append(texts, text)
+	}
+
+	var builder strings.Builder
+	for i, text := range texts {
+		for _, recoveryMode := range []bool{false, true} {
+			tokenizer := tz.NewTokenizer(language)
+			tokenizer.RecoveryMode = recoveryMode
+			tokensScope, diagnostics, err := tokenizer.Tokenize(text)
+			assert.Nil(t, err)
+
+			builder.WriteString(fmt.Sprintf("== %s (recovery mode: %v)\n", names[i], recoveryMode))
+			describeTokens(&builder, &tokensScope, 0)
+			for _, diagnostic := range diagnostics {
+				builder.WriteString(fmt.Sprintf("diagnostic %s [%d:%d - %d:%d]\n", diagnostic.Code,
+					diagnostic.Start.Line, diagnostic.Start.Column, diagnostic.End.Line, diagnostic.End.Column))
+			}
+		}
+	}
+	return builder.String()
+}
+
+// checkTokenStreams
+// Checks that the language tokenizes every file and text into the tokens and diagnostics pinned in the file of token streams.
+// The streams were first recorded with the languages written with Go callbacks, which the bundled definitions replaced.
+func checkTokenStreams(t *testing.T, language *tz.Language, streamsPath string, filepaths []string, texts []string) {
+	expected, err := os.ReadFile(streamsPath)
+	assert.Nil(t, err)
+	assert.Equal(t, string(expected), tokenStreams(t, language, filepaths, texts), "the token streams differ from %s", streamsPath)
+}
+
+func Test_javaDefinition_TokenStreams(t *testing.T) {
+	definition, err := javaTokenizer.GetJavaDefinition()
+	assert.Nil(t, err)
+	definedLanguage, err := definition.BuildLanguage()
+	assert.Nil(t, err)
+	assert.Equal(t, "java", definedLanguage.LanguageType)
+
+	checkTokenStreams(t, javaTokenizer.GetJavaLanguage(), "../exampleFiles/tokenStreams/java.tokens", javaStreamFiles, javaStreamTexts)
+}
+
+func Test_pythonDefinition_TokenStreams(t *testing.T) {
+	definition, err := pythonTokenizer.GetPythonDefinition()
+	assert.Nil(t, err)
+	definedLanguage, err := definition.BuildLanguage()
+	assert.Nil(t, err)
+	assert.Equal(t, "python", definedLanguage.LanguageType)
+
+	checkTokenStreams(t, pythonTokenizer.GetPythonLanguage(), "../exampleFiles/tokenStreams/python.tokens", pythonStreamFiles, pythonStreamTexts)
+}
@@ -0,0 +1,88 @@
+package instances_test
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	javaTokenizer "tp/src/instances/langs/java"
+	pythonTokenizer "tp/src/instances/langs/python"
+	"tp/src/tests"
+	tz "tp/src/tokenizer"
+	tk "tp/src/tokenizer/tokens"
+	"tp/src/util"
+)
+
+func Test_Diagnostics_ExampleFiles(t *testing.T) {
+	files := []struct {
+		path      string
+		tokenizer func() *tz.Tokenizer
+	}{
+		{"../exampleFiles/hello.java", javaTokenizer.GetJavaTokenizer},
+		{"../exampleFiles/file.java", javaTokenizer.GetJavaTokenizer},
+		{"../exampleFiles/charAndNums.java", javaTokenizer.GetJavaTokenizer},
+		{"../exampleFiles/unicode.java", javaTokenizer.GetJavaTokenizer},
+		{"../exampleFiles/hello.py", pythonTokenizer.GetPythonTokenizer},
+		{"../exampleFiles/unicode.py", pythonTokenizer.GetPythonTokenizer},
+	}
+
+	for _, file := range files {
+		text, err := util.GetTextOfFile(file.path)
+		if err != nil {
+			util.Error(fmt.Sprintf("Failed to find file: %s", file.path), err)
+			assert.Fail(t, "No file found")
+		}
+
+		_, diagnostics, err := file.tokenizer().Tokenize(text)
+		assert.Nil(t, err)
+		assert.Empty(t, diagnostics, file.path)
+	}
+}
+
+func Test_javaDiagnostics(t *testing.T) {
+	cases := []struct {
+		text          string
+		expectedCode  string
+		expectedStart tk.Position
+		expectedEnd   tk.Position
+	}{
+		{"String s = \"abc", tz.DIAGNOSTIC_UNTERMINATED_STRING, tk.Position{Line: 1, Column: 12, Offset: 11}, tk.Position{Line: 1, Column: 16, Offset: 15}},
+		{"char c = 'a", tz.DIAGNOSTIC_UNTERMINATED_STRING, tk.Position{Line: 1, Column: 10, Offset: 9}, tk.Position{Line: 1, Column: 12, Offset: 11}},
+		{"int x;\n/* never\nends", tz.DIAGNOSTIC_UNTERMINATED_COMMENT, tk.Position{Line: 2, Column: 1, Offset: 7}, tk.Position{Line: 3, Column: 5, Offset: 20}},
+		{"x(); }", tz.DIAGNOSTIC_UNBALANCED_SCOPE_CLOSE, tk.Position{Line: 1, Column: 6, Offset: 5}, tk.Position{Line: 1, Column: 7, Offset: 6}},
+		{"class a {\n  void b() {\n  }\n", tz.DIAGNOSTIC_UNCLOSED_SCOPE, tk.Position{Line: 1, Column: 9, Offset: 8}, tk.Position{Line: 4, Column: 1, Offset: 27}},
+	}
+
+	for _, c := range cases {
+		tokensScope, diagnostics, err := javaTokenizer.GetJavaTokenizer().Tokenize(c.text)
+		assert.Nil(t, err)
+		assert.NotEqual(t, 0, tokensScope.Size(), c.text)
+		if assert.Equal(t, 1, len(diagnostics), c.text) {
+			tests.ValidateDiagnostic(t, diagnostics[0], tz.SEVERITY_ERROR, c.expectedCode, c.expectedStart, c.expectedEnd)
+		}
+	}
+
+	// A line comment may end with the text
+	_, diagnostics, err := javaTokenizer.GetJavaTokenizer().Tokenize("int x; // no newline")
+	assert.Nil(t, err)
+	assert.Empty(t, diagnostics)
+}
+
+func Test_pythonDiagnostics(t *testing.T) {
+	// Scopes of the last lines are still open when the text ends, which is expected in python
+	_, diagnostics, err := pythonTokenizer.GetPythonTokenizer().Tokenize("class a:\n    def b():\n        return 1")
+	assert.Nil(t, err)
+	assert.Empty(t, diagnostics)
+
+	// A triple quoted string may end right at the end of the text
+	tokensScope, diagnostics, err := pythonTokenizer.GetPythonTokenizer().Tokenize("x = \"\"\"ab\"\"\"")
+	assert.Nil(t, err)
+	assert.Empty(t, diagnostics)
+	assert.Equal(t, 3, tokensScope.Size())
+
+	_, diagnostics, err = pythonTokenizer.GetPythonTokenizer().Tokenize("x = '''abc\ndef")
+	assert.Nil(t, err)
+	if assert.Equal(t, 1, len(diagnostics)) {
+		tests.ValidateDiagnostic(t, diagnostics[0], tz.SEVERITY_ERROR, tz.DIAGNOSTIC_UNTERMINATED_STRING,
+			tk.Position{Line: 1, Column: 5, Offset: 4}, tk.Position{Line: 2, Column: 4, Offset: 14})
+	}
+}
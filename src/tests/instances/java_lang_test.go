@@ -7,6 +7,7 @@ import (
 	javaTokenizer "tp/src/instances/langs/java"
 	"tp/src/tests"
 	tz "tp/src/tokenizer"
+	tk "tp/src/tokenizer/tokens"
 	"tp/src/util"
 )
 
@@ -19,7 +20,7 @@ func Test_javaTokenizer(t *testing.T) {
 		assert.Fail(t, "No file found")
 	}
 
-	tokensScope, err := tokenizer.Tokenize(text)
+	tokensScope, _, err := tokenizer.Tokenize(text)
 	assert.Nil(t, err)
 
 	// FOR DEBUGGING
@@ -122,7 +123,7 @@ func Test_javaTokenizer_CharsAndNums(t *testing.T) {
 		assert.Fail(t, "No file found")
 	}
 
-	tokensScope, err := tokenizer.Tokenize(text)
+	tokensScope, _, err := tokenizer.Tokenize(text)
 	assert.Nil(t, err)
 
 	// FOR DEBUGGING
@@ -130,6 +131,15 @@ func Test_javaTokenizer_CharsAndNums(t *testing.T) {
 	util.MakeDir("../../../output")
 	_ = util.CreateFileWithInfo("../../../output/java_output_char_nums.json", jsonString)
 
+	numbers := make([]string, 0)
+	for _, token := range tokensScope.ConvertToArray() {
+		if token.Kind == tk.KIND_NUMBER {
+			assert.True(t, token.Kind.IsLiteral())
+			assert.Equal(t, tz.RULENAME_OTHER, token.RuleName)
+			numbers = append(numbers, token.Text)
+		}
+	}
+	assert.Equal(t, []string{"10", "119141_121", "1234_1.2345", "3.159F"}, numbers)
 }
 
 func Test_javaTokenizer_LargeFile(t *testing.T) {
@@ -141,7 +151,7 @@ func Test_javaTokenizer_LargeFile(t *testing.T) {
 		assert.Fail(t, "No file found")
 	}
 
-	tokensScope, err := tokenizer.Tokenize(text)
+	tokensScope, _, err := tokenizer.Tokenize(text)
 	assert.Nil(t, err)
 
 	// FOR DEBUGGING
@@ -150,3 +160,190 @@ func Test_javaTokenizer_LargeFile(t *testing.T) {
 	_ = util.CreateFileWithInfo("../../../output/java_output_file.json", jsonString)
 
 }
+
+func Test_javaTokenizer_Unicode(t *testing.T) {
+	tokenizer := javaTokenizer.GetJavaTokenizer()
+	filepath := "../exampleFiles/unicode.java"
+	text, err := util.GetTextOfFile(filepath)
+	if err != nil {
+		util.Error(fmt.Sprintf("Failed to find file: %s", filepath), err)
+		assert.Fail(t, "No file found")
+	}
+
+	tokensScope, _, err := tokenizer.Tokenize(text)
+	assert.Nil(t, err)
+
+	assert.Equal(t, 7, tokensScope.Size())
+	for i := 0; i < tokensScope.Size(); i++ {
+		st1, _ := tokensScope.At(i)
+		switch i {
+		case 0:
+			tests.ValidateToken(t, st1, 1, 0, tz.RULENAME_OTHER, tz.SYMBOLIC_NAME_COMMENT, "// Übersicht: 日本語のコメント\n")
+		case 3:
+			tests.ValidateToken(t, st1, 2, 0, tz.RULENAME_KEYWORD, tz.SYMBOLIC_NAME_NON_KEYWORD, "Café")
+		case 4:
+			tests.ValidateToken(t, st1, 2, 0, tz.RULENAME_SYMBOL, "LCURLY", "{")
+		case 5:
+			{
+				assert.True(t, st1.ValidScopeToken())
+				sc1 := st1.GetScopeToken()
+				assert.Equal(t, 13, sc1.Size())
+				st2, _ := sc1.At(11)
+				assert.True(t, st2.ValidScopeToken())
+				sc2 := st2.GetScopeToken()
+				assert.Equal(t, 10, sc2.Size())
+				for k := 0; k < sc2.Size(); k++ {
+					st3, _ := sc2.At(k)
+					switch k {
+					case 0:
+						tests.ValidateToken(t, st3, 4, 2, tz.RULENAME_KEYWORD, tz.SYMBOLIC_NAME_NON_KEYWORD, "String")
+					case 1:
+						tests.ValidateToken(t, st3, 4, 2, tz.RULENAME_KEYWORD, tz.SYMBOLIC_NAME_NON_KEYWORD, "grüße")
+					case 2:
+						tests.ValidateToken(t, st3, 4, 2, tz.RULENAME_SYMBOL, "EQUAL", "=")
+					case 3:
+						tests.ValidateToken(t, st3, 4, 2, tz.RULENAME_OTHER, tz.SYMBOLIC_NAME_STRING, "\"héllo 🌍\"")
+					case 4:
+						tests.ValidateToken(t, st3, 4, 2, tz.RULENAME_SYMBOL, "SEMICOLON", ";")
+					case 5:
+						tests.ValidateToken(t, st3, 5, 2, tz.RULENAME_KEYWORD, "INT", "int")
+					case 6:
+						tests.ValidateToken(t, st3, 5, 2, tz.RULENAME_KEYWORD, tz.SYMBOLIC_NAME_NON_KEYWORD, "変数")
+					case 7:
+						tests.ValidateToken(t, st3, 5, 2, tz.RULENAME_SYMBOL, "EQUAL", "=")
+					case 8:
+						tests.ValidateToken(t, st3, 5, 2, tz.RULENAME_KEYWORD, tz.SYMBOLIC_NAME_NON_KEYWORD, "grüße")
+					case 9:
+						tests.ValidateToken(t, st3, 5, 2, tz.RULENAME_SYMBOL, "SEMICOLON", ";")
+					}
+				}
+			}
+		case 6:
+			tests.ValidateToken(t, st1, 7, 0, tz.RULENAME_SYMBOL, "RCURLY", "}")
+		}
+	}
+}
+
+func Test_javaTokenizer_Spans(t *testing.T) {
+	tokenizer := javaTokenizer.GetJavaTokenizer()
+	filepath := "../exampleFiles/hello.java"
+	text, err := util.GetTextOfFile(filepath)
+	if err != nil {
+		util.Error(fmt.Sprintf("Failed to find file: %s", filepath), err)
+		assert.Fail(t, "No file found")
+	}
+
+	tokensScope, _, err := tokenizer.Tokenize(text)
+	assert.Nil(t, err)
+	assert.Equal(t, tk.Position{Line: 1, Column: 1, Offset: 0}, tokensScope.GetStart())
+	assert.Equal(t, tk.Position{Line: 12, Column: 1, Offset: 224}, tokensScope.GetEnd())
+
+	allTokens := tokensScope.ConvertToArray()
+	for _, token := range allTokens {
+		assert.Equal(t, token.Text, text[token.Start.Offset:token.End.Offset])
+	}
+
+	tests.ValidateTokenSpan(t, allTokens[0], tk.Position{Line: 1, Column: 1, Offset: 0}, tk.Position{Line: 4, Column: 4, Offset: 72})
+	tests.ValidateTokenSpan(t, allTokens[2], tk.Position{Line: 6, Column: 8, Offset: 81}, tk.Position{Line: 6, Column: 13, Offset: 86})
+	tests.ValidateTokenSpan(t, allTokens[16], tk.Position{Line: 8, Column: 9, Offset: 148}, tk.Position{Line: 9, Column: 1, Offset: 173})
+	tests.ValidateTokenSpan(t, allTokens[23], tk.Position{Line: 9, Column: 28, Offset: 200}, tk.Position{Line: 9, Column: 41, Offset: 213})
+	tests.ValidateTokenSpan(t, allTokens[27], tk.Position{Line: 11, Column: 1, Offset: 222}, tk.Position{Line: 11, Column: 2, Offset: 223})
+
+	classScope, err := tokensScope.GetScope(0)
+	assert.Nil(t, err)
+	assert.Equal(t, tk.Position{Line: 6, Column: 21, Offset: 94}, classScope.GetStart())
+	assert.Equal(t, tk.Position{Line: 11, Column: 1, Offset: 222}, classScope.GetEnd())
+
+	methodScope, err := classScope.GetScope(0)
+	assert.Nil(t, err)
+	assert.Equal(t, tk.Position{Line: 7, Column: 45, Offset: 139}, methodScope.GetStart())
+	assert.Equal(t, tk.Position{Line: 10, Column: 5, Offset: 220}, methodScope.GetEnd())
+}
+
+func Test_javaTokenizer_Operators(t *testing.T) {
+	tokenizer := javaTokenizer.GetJavaTokenizer()
+
+	tokensScope, _, err := tokenizer.Tokenize("a >>>= b >> c > d; e -> f::g; h == i != j <= k >= l && !m || n++;")
+	assert.Nil(t, err)
+
+	assert.Equal(t, 30, tokensScope.Size())
+	for i := 0; i < tokensScope.Size(); i++ {
+		st1, _ := tokensScope.At(i)
+		switch i {
+		case 1:
+			tests.ValidateToken(t, st1, 1, 0, tz.RULENAME_SYMBOL, "UNSIGNEDRIGHTSHIFTEQUAL", ">>>=")
+			tests.ValidateTokenSpan(t, st1, tk.Position{Line: 1, Column: 3, Offset: 2}, tk.Position{Line: 1, Column: 7, Offset: 6})
+		case 3:
+			tests.ValidateToken(t, st1, 1, 0, tz.RULENAME_SYMBOL, "RIGHTSHIFT", ">>")
+		case 5:
+			tests.ValidateToken(t, st1, 1, 0, tz.RULENAME_SYMBOL, "GREATERTHAN", ">")
+		case 9:
+			tests.ValidateToken(t, st1, 1, 0, tz.RULENAME_SYMBOL, "ARROW", "->")
+		case 11:
+			tests.ValidateToken(t, st1, 1, 0, tz.RULENAME_SYMBOL, "DOUBLECOLON", "::")
+		case 15:
+			tests.ValidateToken(t, st1, 1, 0, tz.RULENAME_SYMBOL, "EQUALEQUAL", "==")
+		case 17:
+			tests.ValidateToken(t, st1, 1, 0, tz.RULENAME_SYMBOL, "NOTEQUAL", "!=")
+		case 19:
+			tests.ValidateToken(t, st1, 1, 0, tz.RULENAME_SYMBOL, "LESSTHANEQUAL", "<=")
+		case 21:
+			tests.ValidateToken(t, st1, 1, 0, tz.RULENAME_SYMBOL, "GREATERTHANEQUAL", ">=")
+		case 23:
+			tests.ValidateToken(t, st1, 1, 0, tz.RULENAME_SYMBOL, "AND", "&&")
+		case 24:
+			tests.ValidateToken(t, st1, 1, 0, tz.RULENAME_SYMBOL, "NOT", "!")
+		case 26:
+			tests.ValidateToken(t, st1, 1, 0, tz.RULENAME_SYMBOL, "OR", "||")
+		case 28:
+			tests.ValidateToken(t, st1, 1, 0, tz.RULENAME_SYMBOL, "INCREMENT", "++")
+		}
+	}
+}
+
+func Test_javaTokenizer_Numbers(t *testing.T) {
+	tokenizer := javaTokenizer.GetJavaTokenizer()
+
+	tokensScope, _, err := tokenizer.Tokenize("x = 42 + 0x1F - 3.14e-2 * 1_000L / 0b1010 + .5f + 1e10 + a1.b2;")
+	assert.Nil(t, err)
+
+	assert.Equal(t, 20, tokensScope.Size())
+	for i := 0; i < tokensScope.Size(); i++ {
+		st1, _ := tokensScope.At(i)
+		switch i {
+		case 2:
+			tests.ValidateToken(t, st1, 1, 0, tz.RULENAME_OTHER, tz.SYMBOLIC_NAME_NUMBER, "42")
+			tests.ValidateTokenSpan(t, st1, tk.Position{Line: 1, Column: 5, Offset: 4}, tk.Position{Line: 1, Column: 7, Offset: 6})
+		case 4:
+			tests.ValidateToken(t, st1, 1, 0, tz.RULENAME_OTHER, tz.SYMBOLIC_NAME_NUMBER, "0x1F")
+		case 6:
+			tests.ValidateToken(t, st1, 1, 0, tz.RULENAME_OTHER, tz.SYMBOLIC_NAME_NUMBER, "3.14e-2")
+		case 8:
+			tests.ValidateToken(t, st1, 1, 0, tz.RULENAME_OTHER, tz.SYMBOLIC_NAME_NUMBER, "1_000L")
+		case 10:
+			tests.ValidateToken(t, st1, 1, 0, tz.RULENAME_OTHER, tz.SYMBOLIC_NAME_NUMBER, "0b1010")
+		case 12:
+			tests.ValidateToken(t, st1, 1, 0, tz.RULENAME_OTHER, tz.SYMBOLIC_NAME_NUMBER, ".5f")
+		case 14:
+			tests.ValidateToken(t, st1, 1, 0, tz.RULENAME_OTHER, tz.SYMBOLIC_NAME_NUMBER, "1e10")
+		case 16:
+			tests.ValidateToken(t, st1, 1, 0, tz.RULENAME_KEYWORD, tz.SYMBOLIC_NAME_NON_KEYWORD, "a1")
+		case 17:
+			tests.ValidateToken(t, st1, 1, 0, tz.RULENAME_SYMBOL, "PERIOD", ".")
+		case 18:
+			tests.ValidateToken(t, st1, 1, 0, tz.RULENAME_KEYWORD, tz.SYMBOLIC_NAME_NON_KEYWORD, "b2")
+		}
+	}
+}
+
+func Test_javaTokenizer_EscapedBackslash(t *testing.T) {
+	// The quote after an escaped backslash ends the string
+	tokensScope, _, err := javaTokenizer.GetJavaTokenizer().Tokenize("s = \"\\\\\"; t = \"\\\"\";")
+	assert.Nil(t, err)
+
+	assert.Equal(t, 8, tokensScope.Size())
+	st1, _ := tokensScope.At(2)
+	tests.ValidateToken(t, st1, 1, 0, tz.RULENAME_OTHER, tz.SYMBOLIC_NAME_STRING, "\"\\\\\"")
+	st1, _ = tokensScope.At(6)
+	tests.ValidateToken(t, st1, 1, 0, tz.RULENAME_OTHER, tz.SYMBOLIC_NAME_STRING, "\"\\\"\"")
+}
@@ -0,0 +1,83 @@
+package instances_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	javaTokenizer "tp/src/instances/langs/java"
+	pythonTokenizer "tp/src/instances/langs/python"
+	tz "tp/src/tokenizer"
+	tk "tp/src/tokenizer/tokens"
+)
+
+func Test_javaKinds(t *testing.T) {
+	language := javaTokenizer.GetJavaLanguage()
+	classKind, found := language.KeywordKind("class")
+	assert.True(t, found)
+	assert.True(t, classKind.IsKeyword())
+	lParenKind, found := language.SymbolKind("(")
+	assert.True(t, found)
+	assert.True(t, lParenKind.IsOperator())
+	_, found = language.KeywordKind("notAKeyword")
+	assert.False(t, found)
+
+	tokensScope, _, err := javaTokenizer.GetJavaTokenizer().Tokenize("class A { void f(int x) { return 10; } } // done")
+	assert.Nil(t, err)
+	tokensArray := tokensScope.ConvertToArray()
+	assert.Equal(t, classKind, tokensArray[0].Kind)
+	assert.Equal(t, tk.KIND_IDENTIFIER, tokensArray[1].Kind)
+	assert.Equal(t, lParenKind, tokensArray[5].Kind)
+
+	for _, tkn := range tokensArray {
+		// Every kind is registered under the names of the token
+		ruleName, symbolicName, found := language.Kinds().Names(tkn.Kind)
+		assert.True(t, found, tkn.ToString())
+		assert.Equal(t, ruleName, tkn.RuleName, tkn.ToString())
+		assert.Equal(t, symbolicName, tkn.SymbolicName, tkn.ToString())
+
+		switch tkn.Text {
+		case "10":
+			assert.True(t, tkn.Kind.IsLiteral())
+		case "// done":
+			assert.True(t, tkn.Kind.IsTrivia())
+		case "void", "int", "return":
+			assert.True(t, tkn.Kind.IsKeyword(), tkn.ToString())
+		case "{", "}", ";", ")":
+			assert.True(t, tkn.Kind.IsOperator(), tkn.ToString())
+		}
+	}
+}
+
+func Test_kindsAreStable(t *testing.T) {
+	// Separately built languages give their keywords and symbols the same kinds
+	for _, keyword := range []string{"class", "while", "return"} {
+		kind1, _ := javaTokenizer.GetJavaLanguage().KeywordKind(keyword)
+		kind2, _ := javaTokenizer.GetJavaTokenizer().KeywordKind(keyword)
+		assert.Equal(t, kind1, kind2)
+	}
+
+	// Built-in kinds are the same in every language
+	pythonKind, found := pythonTokenizer.GetPythonLanguage().Kinds().Lookup(tz.RULENAME_OTHER, tz.SYMBOLIC_NAME_STRING)
+	assert.True(t, found)
+	assert.Equal(t, tk.KIND_STRING, pythonKind)
+	javaKind, found := javaTokenizer.GetJavaLanguage().Kinds().Lookup(tz.RULENAME_OTHER, tz.SYMBOLIC_NAME_STRING)
+	assert.True(t, found)
+	assert.Equal(t, tk.KIND_STRING, javaKind)
+}
+
+func Test_keywordNamedIdentifier(t *testing.T) {
+	language := tz.CreateDullLanguage()
+	language.ConfigureGeneral("dull", [][]string{}, []string{"identifier"}, language.IsKeywordCharacter)
+
+	tokensScope, _, err := tz.NewTokenizer(language).Tokenize("identifier name")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, tokensScope.Size())
+	keywordToken, _ := tokensScope.At(0)
+	nameToken, _ := tokensScope.At(1)
+
+	// Both tokens are written the same way, but are of different kinds
+	assert.Equal(t, tz.SYMBOLIC_NAME_NON_KEYWORD, keywordToken.SymbolicName)
+	assert.Equal(t, tz.SYMBOLIC_NAME_NON_KEYWORD, nameToken.SymbolicName)
+	assert.True(t, keywordToken.Kind.IsKeyword())
+	assert.Equal(t, tk.KIND_IDENTIFIER, nameToken.Kind)
+	assert.False(t, keywordToken.Equal(*nameToken))
+}
@@ -7,6 +7,7 @@ import (
 	pyTokenizer "tp/src/instances/langs/python"
 	"tp/src/tests"
 	tz "tp/src/tokenizer"
+	tk "tp/src/tokenizer/tokens"
 	"tp/src/util"
 )
 
@@ -19,7 +20,7 @@ func Test_pythonTokenizer(t *testing.T) {
 		assert.Fail(t, "No file found")
 	}
 
-	tokensScope, err := tokenizer.Tokenize(text)
+	tokensScope, _, err := tokenizer.Tokenize(text)
 	assert.Nil(t, err)
 
 	// FOR DEBUGGING
@@ -50,7 +51,7 @@ func Test_pythonTokenizer(t *testing.T) {
 				//SCOPE
 				assert.True(t, st1.ValidScopeToken())
 				scope_a := st1.GetScopeToken()
-				assert.Equal(t, 7, scope_a.Size())
+				assert.Equal(t, 6, scope_a.Size())
 				for j := 0; j < scope_a.Size(); j++ {
 					st_a, _ := scope_a.At(j)
 					switch j {
@@ -59,14 +60,12 @@ func Test_pythonTokenizer(t *testing.T) {
 					case 1:
 						tests.ValidateToken(t, st_a, 4, 1, tz.RULENAME_KEYWORD, tz.SYMBOLIC_NAME_NON_KEYWORD, "str")
 					case 2:
-						tests.ValidateToken(t, st_a, 4, 1, tz.RULENAME_SYMBOL, "EQUAL", "=")
+						tests.ValidateToken(t, st_a, 4, 1, tz.RULENAME_SYMBOL, "EQUALEQUAL", "==")
 					case 3:
-						tests.ValidateToken(t, st_a, 4, 1, tz.RULENAME_SYMBOL, "EQUAL", "=")
-					case 4:
 						tests.ValidateToken(t, st_a, 4, 1, tz.RULENAME_OTHER, tz.SYMBOLIC_NAME_STRING, "'not hello world'")
-					case 5:
+					case 4:
 						tests.ValidateToken(t, st_a, 4, 1, tz.RULENAME_SYMBOL, "COLON", ":")
-					case 6:
+					case 5:
 						{
 							// SCOPE
 							assert.True(t, st_a.ValidScopeToken())
@@ -133,3 +132,258 @@ func Test_pythonTokenizer(t *testing.T) {
 
 	}
 }
+
+func Test_pythonTokenizer_Unicode(t *testing.T) {
+	tokenizer := pyTokenizer.GetPythonTokenizer()
+	filepath := "../exampleFiles/unicode.py"
+	text, err := util.GetTextOfFile(filepath)
+	if err != nil {
+		util.Error(fmt.Sprintf("Failed to find file: %s", filepath), err)
+		assert.Fail(t, "No file found")
+	}
+
+	tokensScope, _, err := tokenizer.Tokenize(text)
+	assert.Nil(t, err)
+
+	assert.Equal(t, 12, tokensScope.Size())
+	for i := 0; i < tokensScope.Size(); i++ {
+		st1, _ := tokensScope.At(i)
+		switch i {
+		case 0:
+			tests.ValidateToken(t, st1, 1, 0, tz.RULENAME_OTHER, tz.SYMBOLIC_NAME_COMMENT, "# Übersicht: 日本語のコメント\n")
+		case 1:
+			tests.ValidateToken(t, st1, 3, 0, tz.RULENAME_KEYWORD, "DEF", "def")
+		case 2:
+			tests.ValidateToken(t, st1, 3, 0, tz.RULENAME_KEYWORD, tz.SYMBOLIC_NAME_NON_KEYWORD, "grüße")
+		case 3:
+			tests.ValidateToken(t, st1, 3, 0, tz.RULENAME_SYMBOL, "LPAREN", "(")
+		case 4:
+			tests.ValidateToken(t, st1, 3, 0, tz.RULENAME_KEYWORD, tz.SYMBOLIC_NAME_NON_KEYWORD, "名前")
+		case 5:
+			tests.ValidateToken(t, st1, 3, 0, tz.RULENAME_SYMBOL, "RPAREN", ")")
+		case 6:
+			tests.ValidateToken(t, st1, 3, 0, tz.RULENAME_SYMBOL, "COLON", ":")
+		case 7:
+			{
+				assert.True(t, st1.ValidScopeToken())
+				scope_a := st1.GetScopeToken()
+				assert.Equal(t, 4, scope_a.Size())
+				for j := 0; j < scope_a.Size(); j++ {
+					st_a, _ := scope_a.At(j)
+					switch j {
+					case 0:
+						tests.ValidateToken(t, st_a, 4, 1, tz.RULENAME_KEYWORD, "RETURN", "return")
+					case 1:
+						tests.ValidateToken(t, st_a, 4, 1, tz.RULENAME_OTHER, tz.SYMBOLIC_NAME_STRING, "\"héllo 🌍\"")
+					case 2:
+						tests.ValidateToken(t, st_a, 4, 1, tz.RULENAME_SYMBOL, "ADDITION", "+")
+					case 3:
+						tests.ValidateToken(t, st_a, 4, 1, tz.RULENAME_KEYWORD, tz.SYMBOLIC_NAME_NON_KEYWORD, "名前")
+					}
+				}
+			}
+		case 8:
+			tests.ValidateToken(t, st1, 6, 0, tz.RULENAME_KEYWORD, tz.SYMBOLIC_NAME_NON_KEYWORD, "grüße")
+		case 10:
+			tests.ValidateToken(t, st1, 6, 0, tz.RULENAME_OTHER, tz.SYMBOLIC_NAME_STRING, "'Zoë'")
+		}
+	}
+}
+
+func Test_pythonTokenizer_Unicode_Spans(t *testing.T) {
+	tokenizer := pyTokenizer.GetPythonTokenizer()
+	filepath := "../exampleFiles/unicode.py"
+	text, err := util.GetTextOfFile(filepath)
+	if err != nil {
+		util.Error(fmt.Sprintf("Failed to find file: %s", filepath), err)
+		assert.Fail(t, "No file found")
+	}
+
+	tokensScope, _, err := tokenizer.Tokenize(text)
+	assert.Nil(t, err)
+
+	allTokens := tokensScope.ConvertToArray()
+	for _, token := range allTokens {
+		assert.Equal(t, token.Text, text[token.Start.Offset:token.End.Offset])
+	}
+
+	// Columns are counted in characters while offsets are counted in bytes
+	tests.ValidateTokenSpan(t, allTokens[2], tk.Position{Line: 3, Column: 5, Offset: 44}, tk.Position{Line: 3, Column: 10, Offset: 51})
+	tests.ValidateTokenSpan(t, allTokens[4], tk.Position{Line: 3, Column: 11, Offset: 52}, tk.Position{Line: 3, Column: 13, Offset: 58})
+	tests.ValidateTokenSpan(t, allTokens[8], tk.Position{Line: 4, Column: 12, Offset: 72}, tk.Position{Line: 4, Column: 21, Offset: 85})
+	tests.ValidateTokenSpan(t, allTokens[13], tk.Position{Line: 6, Column: 7, Offset: 104}, tk.Position{Line: 6, Column: 12, Offset: 110})
+
+	functionScope, err := tokensScope.GetScope(0)
+	assert.Nil(t, err)
+	assert.Equal(t, tk.Position{Line: 3, Column: 15, Offset: 60}, functionScope.GetStart())
+	// The blank line after the function does not end it, the next line which is not blank does
+	assert.Equal(t, tk.Position{Line: 6, Column: 1, Offset: 96}, functionScope.GetEnd())
+}
+
+func Test_pythonTokenizer_Operators(t *testing.T) {
+	tokenizer := pyTokenizer.GetPythonTokenizer()
+
+	tokensScope, _, err := tokenizer.Tokenize("a **= b // c != d\ne := f ** -g\n")
+	assert.Nil(t, err)
+
+	assert.Equal(t, 13, tokensScope.Size())
+	for i := 0; i < tokensScope.Size(); i++ {
+		st1, _ := tokensScope.At(i)
+		switch i {
+		case 1:
+			tests.ValidateToken(t, st1, 1, 0, tz.RULENAME_SYMBOL, "DOUBLESTAREQUAL", "**=")
+		case 3:
+			tests.ValidateToken(t, st1, 1, 0, tz.RULENAME_SYMBOL, "DOUBLEFORWARDSLASH", "//")
+		case 5:
+			tests.ValidateToken(t, st1, 1, 0, tz.RULENAME_SYMBOL, "NOTEQUAL", "!=")
+		case 8:
+			tests.ValidateToken(t, st1, 2, 0, tz.RULENAME_SYMBOL, "WALRUS", ":=")
+		case 10:
+			tests.ValidateToken(t, st1, 2, 0, tz.RULENAME_SYMBOL, "DOUBLESTAR", "**")
+		case 11:
+			tests.ValidateToken(t, st1, 2, 0, tz.RULENAME_SYMBOL, "SUBTRACTION", "-")
+		}
+	}
+}
+
+func Test_pythonTokenizer_Numbers(t *testing.T) {
+	tokenizer := pyTokenizer.GetPythonTokenizer()
+
+	tokensScope, _, err := tokenizer.Tokenize("x = 0o17 + 0XFF + 1_000 + 3.14e-2 + 2j + 1.5J + 1. + 10 .real\n")
+	assert.Nil(t, err)
+
+	assert.Equal(t, 19, tokensScope.Size())
+	for i := 0; i < tokensScope.Size(); i++ {
+		st1, _ := tokensScope.At(i)
+		switch i {
+		case 2:
+			tests.ValidateToken(t, st1, 1, 0, tz.RULENAME_OTHER, tz.SYMBOLIC_NAME_NUMBER, "0o17")
+		case 4:
+			tests.ValidateToken(t, st1, 1, 0, tz.RULENAME_OTHER, tz.SYMBOLIC_NAME_NUMBER, "0XFF")
+		case 6:
+			tests.ValidateToken(t, st1, 1, 0, tz.RULENAME_OTHER, tz.SYMBOLIC_NAME_NUMBER, "1_000")
+		case 8:
+			tests.ValidateToken(t, st1, 1, 0, tz.RULENAME_OTHER, tz.SYMBOLIC_NAME_NUMBER, "3.14e-2")
+		case 10:
+			tests.ValidateToken(t, st1, 1, 0, tz.RULENAME_OTHER, tz.SYMBOLIC_NAME_NUMBER, "2j")
+		case 12:
+			tests.ValidateToken(t, st1, 1, 0, tz.RULENAME_OTHER, tz.SYMBOLIC_NAME_NUMBER, "1.5J")
+		case 14:
+			tests.ValidateToken(t, st1, 1, 0, tz.RULENAME_OTHER, tz.SYMBOLIC_NAME_NUMBER, "1.")
+		case 16:
+			tests.ValidateToken(t, st1, 1, 0, tz.RULENAME_OTHER, tz.SYMBOLIC_NAME_NUMBER, "10")
+		case 17:
+			tests.ValidateToken(t, st1, 1, 0, tz.RULENAME_SYMBOL, "PERIOD", ".")
+		case 18:
+			tests.ValidateToken(t, st1, 1, 0, tz.RULENAME_KEYWORD, tz.SYMBOLIC_NAME_NON_KEYWORD, "real")
+		}
+	}
+}
+
+func Test_pythonTokenizer_TripleQuotedString(t *testing.T) {
+	// The quotes which start a triple quoted string cannot also end it
+	tokensScope, _, err := pyTokenizer.GetPythonTokenizer().Tokenize("x = \"\"\"doc\"\"\"\ny = '''''' + 1")
+	assert.Nil(t, err)
+
+	assert.Equal(t, 8, tokensScope.Size())
+	st1, _ := tokensScope.At(2)
+	tests.ValidateToken(t, st1, 1, 0, tz.RULENAME_OTHER, tz.SYMBOLIC_NAME_STRING, "\"\"\"doc\"\"\"")
+	tests.ValidateTokenSpan(t, st1, tk.Position{Line: 1, Column: 5, Offset: 4}, tk.Position{Line: 1, Column: 14, Offset: 13})
+	st1, _ = tokensScope.At(5)
+	tests.ValidateToken(t, st1, 2, 0, tz.RULENAME_OTHER, tz.SYMBOLIC_NAME_STRING, "''''''")
+}
+
+func Test_pythonTokenizer_EscapedBackslash(t *testing.T) {
+	// The quote after an escaped backslash ends the string
+	tokensScope, _, err := pyTokenizer.GetPythonTokenizer().Tokenize("s = 'it\\'s' + \"\\\\\"")
+	assert.Nil(t, err)
+
+	assert.Equal(t, 5, tokensScope.Size())
+	st1, _ := tokensScope.At(2)
+	tests.ValidateToken(t, st1, 1, 0, tz.RULENAME_OTHER, tz.SYMBOLIC_NAME_STRING, "'it\\'s'")
+	st1, _ = tokensScope.At(4)
+	tests.ValidateToken(t, st1, 1, 0, tz.RULENAME_OTHER, tz.SYMBOLIC_NAME_STRING, "\"\\\\\"")
+}
+
+func Test_pythonTokenizer_FStrings(t *testing.T) {
+	tokenizer := pyTokenizer.GetPythonTokenizer()
+
+	tokensScope, diagnostics, err := tokenizer.Tokenize("s = f'{{x}} {d[\"k\"]!r:>{w}} {n}' + rb'\\d' + F\"\"\"\n{ {1: 2}[1] }\"\"\"\n")
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(diagnostics))
+
+	assert.Equal(t, 19, tokensScope.Size())
+	for i := 0; i < tokensScope.Size(); i++ {
+		st1, _ := tokensScope.At(i)
+		switch i {
+		case 2:
+			// Doubled braces are part of the string
+			tests.ValidateToken(t, st1, 1, 0, tz.RULENAME_OTHER, tz.SYMBOLIC_NAME_STRING, "f'{{x}} ")
+			tests.ValidateTokenSpan(t, st1, tk.Position{Line: 1, Column: 5, Offset: 4}, tk.Position{Line: 1, Column: 13, Offset: 12})
+		case 3:
+			tests.ValidateToken(t, st1, 1, 0, tz.RULENAME_SYMBOL, "LCURLY", "{")
+		case 4:
+			// The replacement field's expression, including a nested replacement field in its format specification
+			assert.True(t, st1.ValidScopeToken())
+			field := st1.GetScopeToken()
+			assert.Equal(t, 11, field.Size())
+			st2, _ := field.At(2)
+			tests.ValidateToken(t, st2, 1, 0, tz.RULENAME_OTHER, tz.SYMBOLIC_NAME_STRING, "\"k\"")
+			st2, _ = field.At(5)
+			tests.ValidateToken(t, st2, 1, 0, tz.RULENAME_KEYWORD, tz.SYMBOLIC_NAME_NON_KEYWORD, "r")
+			st2, _ = field.At(9)
+			assert.True(t, st2.ValidScopeToken())
+		case 5:
+			tests.ValidateToken(t, st1, 1, 0, tz.RULENAME_SYMBOL, "RCURLY", "}")
+		case 6:
+			tests.ValidateToken(t, st1, 1, 0, tz.RULENAME_OTHER, tz.SYMBOLIC_NAME_STRING, " ")
+		case 10:
+			tests.ValidateToken(t, st1, 1, 0, tz.RULENAME_OTHER, tz.SYMBOLIC_NAME_STRING, "'")
+		case 12:
+			// Other prefixes are part of the string, but do not make it an f-string
+			tests.ValidateToken(t, st1, 1, 0, tz.RULENAME_OTHER, tz.SYMBOLIC_NAME_STRING, "rb'\\d'")
+		case 14:
+			tests.ValidateToken(t, st1, 1, 0, tz.RULENAME_OTHER, tz.SYMBOLIC_NAME_STRING, "F\"\"\"\n")
+		case 16:
+			// Braces within a replacement field are scopes
+			assert.True(t, st1.ValidScopeToken())
+			st2, _ := st1.GetScopeToken().At(1)
+			assert.True(t, st2.ValidScopeToken())
+		case 17:
+			tests.ValidateToken(t, st1, 2, 0, tz.RULENAME_SYMBOL, "RCURLY", "}")
+		case 18:
+			tests.ValidateToken(t, st1, 2, 0, tz.RULENAME_OTHER, tz.SYMBOLIC_NAME_STRING, "\"\"\"")
+		}
+	}
+}
+
+func Test_pythonTokenizer_UnterminatedFString(t *testing.T) {
+	// An f-string which never ends is reported, even though Python's scopes may end with the text
+	_, diagnostics, err := pyTokenizer.GetPythonTokenizer().Tokenize("if x:\n    s = f\"{y")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(diagnostics))
+	tests.ValidateDiagnostic(t, diagnostics[0], tz.SEVERITY_ERROR, tz.DIAGNOSTIC_UNCLOSED_SCOPE,
+		tk.Position{Line: 2, Column: 11, Offset: 16}, tk.Position{Line: 2, Column: 13, Offset: 18})
+}
+
+func Test_pythonTokenizer_IndentationScopes(t *testing.T) {
+	// Scopes follow the actual indentation, whether it is made of tabs or of any number of spaces
+	tokensScope, diagnostics, err := pyTokenizer.GetPythonTokenizer().Tokenize("if a:\n\tif b:\n\t  c(1,\n2)\n\n# done\n\td\ne")
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(diagnostics))
+	assert.Equal(t, 5, tokensScope.Size())
+
+	outerScope, err := tokensScope.GetScope(0)
+	assert.Nil(t, err)
+	assert.Equal(t, 5, outerScope.Size())
+	innerScope, err := outerScope.GetScope(0)
+	assert.Nil(t, err)
+	// The line within the brackets is part of the scope, and so is the comment, since lines with only a comment do not end scopes
+	assert.Equal(t, 7, innerScope.Size())
+	st1, _ := innerScope.At(6)
+	tests.ValidateToken(t, st1, 6, 0, tz.RULENAME_OTHER, tz.SYMBOLIC_NAME_COMMENT, "# done\n")
+	st1, _ = outerScope.At(4)
+	tests.VerifyUnknownKeyword(t, st1, 7, 0, "d")
+	st1, _ = tokensScope.At(4)
+	tests.VerifyUnknownKeyword(t, st1, 8, 0, "e")
+}
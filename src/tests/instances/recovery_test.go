@@ -0,0 +1,153 @@
+package instances_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	javaTokenizer "tp/src/instances/langs/java"
+	pythonTokenizer "tp/src/instances/langs/python"
+	"tp/src/tests"
+	tz "tp/src/tokenizer"
+	tk "tp/src/tokenizer/tokens"
+)
+
+func getRecoveringJavaTokenizer() *tz.Tokenizer {
+	tokenizer := javaTokenizer.GetJavaTokenizer()
+	tokenizer.RecoveryMode = true
+	return tokenizer
+}
+
+func Test_javaRecovery_UnterminatedString(t *testing.T) {
+	text := "String s = \"abc\nint x = 1;"
+
+	// Without recovery, the string takes up the rest of the text
+	tokensScope, diagnostics, err := javaTokenizer.GetJavaTokenizer().Tokenize(text)
+	assert.Nil(t, err)
+	assert.Equal(t, 4, tokensScope.Size())
+	assert.Equal(t, 1, len(diagnostics))
+	assert.Equal(t, "", diagnostics[0].Repair)
+
+	// With recovery, the string ends with its line
+	tokensScope, diagnostics, err = getRecoveringJavaTokenizer().Tokenize(text)
+	assert.Nil(t, err)
+	assert.Equal(t, 9, tokensScope.Size())
+	for i := 0; i < tokensScope.Size(); i++ {
+		st1, _ := tokensScope.At(i)
+		switch i {
+		case 3:
+			tests.ValidateToken(t, st1, 1, 0, tz.RULENAME_OTHER, tz.SYMBOLIC_NAME_STRING, "\"abc")
+			tests.ValidateTokenSpan(t, st1, tk.Position{Line: 1, Column: 12, Offset: 11}, tk.Position{Line: 1, Column: 16, Offset: 15})
+		case 4:
+			tests.ValidateToken(t, st1, 2, 0, tz.RULENAME_KEYWORD, "INT", "int")
+			tests.ValidateTokenSpan(t, st1, tk.Position{Line: 2, Column: 1, Offset: 16}, tk.Position{Line: 2, Column: 4, Offset: 19})
+		case 8:
+			tests.ValidateToken(t, st1, 2, 0, tz.RULENAME_SYMBOL, "SEMICOLON", ";")
+		}
+	}
+	if assert.Equal(t, 1, len(diagnostics)) {
+		tests.ValidateDiagnostic(t, diagnostics[0], tz.SEVERITY_ERROR, tz.DIAGNOSTIC_UNTERMINATED_STRING,
+			tk.Position{Line: 1, Column: 12, Offset: 11}, tk.Position{Line: 1, Column: 16, Offset: 15})
+		assert.Equal(t, "ended the string at the end of its line", diagnostics[0].Repair)
+	}
+
+	// A string on the last line ends with the text
+	tokensScope, diagnostics, err = getRecoveringJavaTokenizer().Tokenize("c = 'x")
+	assert.Nil(t, err)
+	assert.Equal(t, 3, tokensScope.Size())
+	if assert.Equal(t, 1, len(diagnostics)) {
+		assert.Equal(t, "ended the string at the end of the text", diagnostics[0].Repair)
+	}
+
+	// A string opened right before the end of a line is empty
+	tokensScope, _, err = getRecoveringJavaTokenizer().Tokenize("a = \"\nb;")
+	assert.Nil(t, err)
+	assert.Equal(t, 5, tokensScope.Size())
+	st1, _ := tokensScope.At(2)
+	tests.ValidateToken(t, st1, 1, 0, tz.RULENAME_OTHER, tz.SYMBOLIC_NAME_STRING, "\"")
+	st1, _ = tokensScope.At(3)
+	tests.ValidateToken(t, st1, 2, 0, tz.RULENAME_KEYWORD, tz.SYMBOLIC_NAME_NON_KEYWORD, "b")
+}
+
+func Test_javaRecovery_UnterminatedComment(t *testing.T) {
+	tokensScope, diagnostics, err := getRecoveringJavaTokenizer().Tokenize("/* abc\nint x;")
+	assert.Nil(t, err)
+
+	assert.Equal(t, 5, tokensScope.Size())
+	for i := 0; i < tokensScope.Size(); i++ {
+		st1, _ := tokensScope.At(i)
+		switch i {
+		case 0:
+			tests.ValidateToken(t, st1, 1, 0, tz.RULENAME_OTHER, tz.SYMBOLIC_NAME_ERROR, "/*")
+			tests.ValidateTokenSpan(t, st1, tk.Position{Line: 1, Column: 1, Offset: 0}, tk.Position{Line: 1, Column: 3, Offset: 2})
+		case 1:
+			tests.ValidateToken(t, st1, 1, 0, tz.RULENAME_KEYWORD, tz.SYMBOLIC_NAME_NON_KEYWORD, "abc")
+		case 2:
+			tests.ValidateToken(t, st1, 2, 0, tz.RULENAME_KEYWORD, "INT", "int")
+			tests.ValidateTokenSpan(t, st1, tk.Position{Line: 2, Column: 1, Offset: 7}, tk.Position{Line: 2, Column: 4, Offset: 10})
+		}
+	}
+	if assert.Equal(t, 1, len(diagnostics)) {
+		tests.ValidateDiagnostic(t, diagnostics[0], tz.SEVERITY_ERROR, tz.DIAGNOSTIC_UNTERMINATED_COMMENT,
+			tk.Position{Line: 1, Column: 1, Offset: 0}, tk.Position{Line: 2, Column: 7, Offset: 13})
+		assert.NotEqual(t, "", diagnostics[0].Repair)
+	}
+}
+
+func Test_javaRecovery_Scopes(t *testing.T) {
+	tokensScope, diagnostics, err := getRecoveringJavaTokenizer().Tokenize("}\nclass a { void b() { int c; } }\n}\nclass d {")
+	assert.Nil(t, err)
+
+	// }, class, a, {, SCOPE, }, }, class, d, {, SCOPE
+	assert.Equal(t, 11, tokensScope.Size())
+	assert.Equal(t, 2, tokensScope.GetNumberOfScopes())
+	st1, _ := tokensScope.At(0)
+	tests.ValidateToken(t, st1, 1, 0, tz.RULENAME_OTHER, tz.SYMBOLIC_NAME_ERROR, "}")
+	st1, _ = tokensScope.At(6)
+	tests.ValidateToken(t, st1, 3, 0, tz.RULENAME_OTHER, tz.SYMBOLIC_NAME_ERROR, "}")
+
+	// The scopes within the class are still found
+	classScope, err := tokensScope.GetScope(0)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, classScope.GetNumberOfScopes())
+
+	// The scope which is never closed is closed with the text
+	lastScope, err := tokensScope.GetScope(1)
+	assert.Nil(t, err)
+	assert.Equal(t, tk.Position{Line: 4, Column: 10, Offset: 45}, lastScope.GetEnd())
+
+	assert.Equal(t, 3, len(diagnostics))
+	tests.ValidateDiagnostic(t, diagnostics[0], tz.SEVERITY_ERROR, tz.DIAGNOSTIC_UNBALANCED_SCOPE_CLOSE,
+		tk.Position{Line: 1, Column: 1, Offset: 0}, tk.Position{Line: 1, Column: 2, Offset: 1})
+	tests.ValidateDiagnostic(t, diagnostics[1], tz.SEVERITY_ERROR, tz.DIAGNOSTIC_UNBALANCED_SCOPE_CLOSE,
+		tk.Position{Line: 3, Column: 1, Offset: 34}, tk.Position{Line: 3, Column: 2, Offset: 35})
+	tests.ValidateDiagnostic(t, diagnostics[2], tz.SEVERITY_ERROR, tz.DIAGNOSTIC_UNCLOSED_SCOPE,
+		tk.Position{Line: 4, Column: 9, Offset: 44}, tk.Position{Line: 4, Column: 10, Offset: 45})
+	for _, diagnostic := range diagnostics {
+		assert.NotEqual(t, "", diagnostic.Repair)
+	}
+}
+
+func Test_pythonRecovery(t *testing.T) {
+	tokenizer := pythonTokenizer.GetPythonTokenizer()
+	tokenizer.RecoveryMode = true
+
+	// Single quoted strings end with their line
+	tokensScope, diagnostics, err := tokenizer.Tokenize("x = 'abc\ny = 2\n")
+	assert.Nil(t, err)
+	assert.Equal(t, 6, tokensScope.Size())
+	st1, _ := tokensScope.At(2)
+	tests.ValidateToken(t, st1, 1, 0, tz.RULENAME_OTHER, tz.SYMBOLIC_NAME_STRING, "'abc")
+	assert.Equal(t, 1, len(diagnostics))
+
+	// Triple quoted strings may span lines, so one which is never ended becomes an error token
+	tokensScope, diagnostics, err = tokenizer.Tokenize("x = '''abc\ny = 2\n")
+	assert.Nil(t, err)
+	assert.Equal(t, 7, tokensScope.Size())
+	st1, _ = tokensScope.At(2)
+	tests.ValidateToken(t, st1, 1, 0, tz.RULENAME_OTHER, tz.SYMBOLIC_NAME_ERROR, "'''")
+	st1, _ = tokensScope.At(4)
+	tests.ValidateToken(t, st1, 2, 0, tz.RULENAME_KEYWORD, tz.SYMBOLIC_NAME_NON_KEYWORD, "y")
+	if assert.Equal(t, 1, len(diagnostics)) {
+		tests.ValidateDiagnostic(t, diagnostics[0], tz.SEVERITY_ERROR, tz.DIAGNOSTIC_UNTERMINATED_STRING,
+			tk.Position{Line: 1, Column: 5, Offset: 4}, tk.Position{Line: 3, Column: 1, Offset: 17})
+	}
+}
@@ -0,0 +1,126 @@
+package structure
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"tp/src/tokenizer/tokens"
+)
+
+func Test_Kind_Predicates(t *testing.T) {
+	assert.True(t, tokens.KIND_IDENTIFIER.IsIdentifier())
+	assert.True(t, tokens.KIND_STRING.IsLiteral())
+	assert.True(t, tokens.KIND_NUMBER.IsLiteral())
+	assert.True(t, tokens.KIND_WHITESPACE.IsTrivia())
+	assert.True(t, tokens.KIND_NEWLINE.IsTrivia())
+	assert.True(t, tokens.KIND_COMMENT.IsTrivia())
+	assert.True(t, tokens.NewKind(tokens.CATEGORY_KEYWORD, 3).IsKeyword())
+	assert.True(t, tokens.NewKind(tokens.CATEGORY_OPERATOR, 3).IsOperator())
+
+	assert.False(t, tokens.KIND_UNKNOWN.IsOperator())
+	assert.False(t, tokens.KIND_ERROR.IsLiteral())
+	assert.False(t, tokens.KIND_IDENTIFIER.IsKeyword())
+	assert.Equal(t, tokens.CATEGORY_OTHER, tokens.KIND_SCOPE.Category())
+}
+
+func Test_KindRegistry(t *testing.T) {
+	registry := tokens.NewKindRegistry()
+	assert.Equal(t, 2, registry.Size())
+
+	ruleName, symbolicName, found := registry.Names(tokens.KIND_UNIDENTIFIED)
+	assert.True(t, found)
+	assert.Equal(t, "unidentified", ruleName)
+	assert.Equal(t, "Unidentified", symbolicName)
+
+	ifKind := registry.RegisterNext(tokens.CATEGORY_KEYWORD, "KEYWORD", "IF")
+	elseKind := registry.RegisterNext(tokens.CATEGORY_KEYWORD, "KEYWORD", "ELSE")
+	plusKind := registry.RegisterNext(tokens.CATEGORY_OPERATOR, "SYMBOL", "PLUS")
+	assert.Equal(t, tokens.NewKind(tokens.CATEGORY_KEYWORD, 0), ifKind)
+	assert.Equal(t, tokens.NewKind(tokens.CATEGORY_KEYWORD, 1), elseKind)
+	assert.Equal(t, tokens.NewKind(tokens.CATEGORY_OPERATOR, 0), plusKind)
+
+	// Registering the same names again returns the same kind
+	assert.Equal(t, ifKind, registry.RegisterNext(tokens.CATEGORY_KEYWORD, "KEYWORD", "IF"))
+	assert.Equal(t, 5, registry.Size())
+
+	kind, found := registry.Lookup("SYMBOL", "PLUS")
+	assert.True(t, found)
+	assert.Equal(t, plusKind, kind)
+	_, found = registry.Lookup("SYMBOL", "MINUS")
+	assert.False(t, found)
+
+	assert.Equal(t, "ELSE", registry.Name(elseKind))
+	assert.Equal(t, "KIND(50331657)", registry.Name(tokens.NewKind(tokens.CATEGORY_OPERATOR, 9)))
+}
+
+func Test_KindRegistry_SameNames(t *testing.T) {
+	registry := tokens.NewKindRegistry()
+	registry.Register(tokens.KIND_IDENTIFIER, "KEYWORD", "IDENTIFIER")
+	keywordKind := registry.RegisterNext(tokens.CATEGORY_KEYWORD, "KEYWORD", "IDENTIFIER")
+	assert.NotEqual(t, tokens.KIND_IDENTIFIER, keywordKind)
+
+	// The kind registered first is found by its names
+	kind, found := registry.Lookup("KEYWORD", "IDENTIFIER")
+	assert.True(t, found)
+	assert.Equal(t, tokens.KIND_IDENTIFIER, kind)
+
+	// Tokens of the two kinds are not equal, even though their names are
+	token1 := tokens.CreateUnidentifiedToken("identifier", 1, 0)
+	token1.SetKind(keywordKind, registry)
+	token2 := tokens.CreateUnidentifiedToken("name", 1, 0)
+	token2.SetKind(tokens.KIND_IDENTIFIER, registry)
+	assert.Equal(t, token1.SymbolicName, token2.SymbolicName)
+	assert.Equal(t, token1.RuleName, token2.RuleName)
+	assert.False(t, token1.Equal(token2))
+}
+
+func Test_Equal_AcrossRegistries(t *testing.T) {
+	// The same keyword is numbered differently by registries which list their keywords in a different order
+	registry1 := tokens.NewKindRegistry()
+	registry1.RegisterNext(tokens.CATEGORY_KEYWORD, "KEYWORD", "ELSE")
+	ifKind1 := registry1.RegisterNext(tokens.CATEGORY_KEYWORD, "KEYWORD", "IF")
+	registry2 := tokens.NewKindRegistry()
+	ifKind2 := registry2.RegisterNext(tokens.CATEGORY_KEYWORD, "KEYWORD", "IF")
+	assert.NotEqual(t, ifKind1, ifKind2)
+
+	token1 := tokens.CreateUnidentifiedToken("if", 1, 0)
+	token1.SetKind(ifKind1, registry1)
+	token2 := tokens.CreateUnidentifiedToken("if", 3, 0)
+	token2.SetKind(ifKind2, registry2)
+	assert.True(t, token1.Equal(token2))
+	assert.True(t, token2.Equal(token1))
+}
+
+func Test_SetKind(t *testing.T) {
+	registry := tokens.NewKindRegistry()
+	plusKind := registry.RegisterNext(tokens.CATEGORY_OPERATOR, "SYMBOL", "PLUS")
+
+	token := tokens.CreateUnidentifiedToken("+", 1, 0)
+	assert.Equal(t, tokens.KIND_UNIDENTIFIED, token.Kind)
+	token.SetKind(plusKind, registry)
+	assert.Equal(t, plusKind, token.Kind)
+	assert.Equal(t, "SYMBOL", token.RuleName)
+	assert.Equal(t, "PLUS", token.SymbolicName)
+	assert.Contains(t, token.ToJsonString(0), "\"Kind\": 50331648,")
+
+	// An unregistered kind leaves the names alone
+	token.SetKind(tokens.NewKind(tokens.CATEGORY_OPERATOR, 7), registry)
+	assert.Equal(t, "PLUS", token.SymbolicName)
+}
+
+func Test_KindCategory_Text(t *testing.T) {
+	for _, category := range []tokens.KindCategory{tokens.CATEGORY_OTHER, tokens.CATEGORY_KEYWORD, tokens.CATEGORY_TRIVIA} {
+		text, err := category.MarshalText()
+		assert.Nil(t, err)
+		assert.Equal(t, category.String(), string(text))
+
+		var parsed tokens.KindCategory
+		assert.Nil(t, parsed.UnmarshalText(text))
+		assert.Equal(t, category, parsed)
+	}
+	assert.Equal(t, "literal", tokens.CATEGORY_LITERAL.String())
+
+	var parsed tokens.KindCategory
+	assert.NotNil(t, parsed.UnmarshalText([]byte("LITERAL")))
+	_, err := tokens.KindCategory(42).MarshalText()
+	assert.NotNil(t, err)
+}
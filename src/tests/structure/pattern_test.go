@@ -0,0 +1,244 @@
+package structure
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"tp/src/tests"
+	"tp/src/tokenizer/tokens"
+)
+
+// createPatternTestTokens
+// Creates a token for each of the texts, which are separated by spaces. A text starting with "#" is a comment,
+// and every other text is an identifier if it begins with a letter, or a symbol otherwise
+func createPatternTestTokens(texts string) []*tokens.Token {
+	tokenList := make([]*tokens.Token, 0)
+	for _, text := range strings.Fields(texts) {
+		switch {
+		case strings.HasPrefix(text, "#"):
+			tokenList = append(tokenList, tests.CreateTestToken(text, tokens.KIND_COMMENT, "COMMENT"))
+		case text[0] >= 'a' && text[0] <= 'z':
+			tokenList = append(tokenList, tests.CreateTestToken(text, tokens.KIND_IDENTIFIER, "IDENTIFIER"))
+		default:
+			tokenList = append(tokenList, tests.CreateTestToken(text, tokens.KIND_UNKNOWN, "SYMBOL"))
+		}
+	}
+	return tokenList
+}
+
+// findAllTexts
+// Returns the texts of every match of the pattern within the tokens
+func findAllTexts(t *testing.T, pattern string, tokenList []*tokens.Token) []string {
+	compiled, err := tokens.CompilePattern(pattern)
+	assert.Nil(t, err, pattern)
+	if err != nil {
+		return nil
+	}
+	matches := make([]string, 0)
+	for _, match := range compiled.FindAll(tokenList) {
+		matches = append(matches, strings.Join(tests.TokenTexts(match.Tokens), " "))
+	}
+	return matches
+}
+
+func Test_Pattern_FindAll(t *testing.T) {
+	tokenList := createPatternTestTokens("a = f ( b , c ) ; g ( ) ; h ( ( d ) ) ; x -> y")
+	tests := []struct {
+		pattern  string
+		expected []string
+	}{
+		{"IDENTIFIER", []string{"a", "f", "b", "c", "g", "h", "d", "x", "y"}},
+		{"SYMBOL", []string{"=", "(", ",", ")", ";", "(", ")", ";", "(", "(", ")", ")", ";", "->"}},
+		{"f", []string{"f"}},
+		{"'('  \")\"", []string{"( )"}},
+		{"IDENTIFIER ( ... ) ;", []string{"f ( b , c ) ;", "g ( ) ;", "h ( ( d ) ) ;"}},
+		{"IDENTIFIER(...);", []string{"f ( b , c ) ;", "g ( ) ;", "h ( ( d ) ) ;"}},
+		{"IDENTIFIER ( _* ) ;", []string{"f ( b , c ) ; g ( ) ; h ( ( d ) ) ;"}},
+		{"( _ )", []string{"( d )"}},
+		{"( IDENTIFIER [ , IDENTIFIER ]* )", []string{"( b , c )", "( d )"}},
+		{"_ ->  _", []string{"x -> y"}},
+		{"/^[a-c]$/", []string{"a", "b", "c"}},
+		{"/^[A-C]$/i", []string{"a", "b", "c"}},
+		{"g | x | ->", []string{"g", "x", "->"}},
+		{"[g | h] ( (? ", []string{"g (", "h ( ("}},
+		{"[g | h] ( (?? ", []string{"g (", "h ("}},
+		{"IDENTIFIER+", []string{"a", "f", "b", "c", "g", "h", "d", "x", "y"}},
+		{"; [IDENTIFIER _]+", []string{"; g (", "; h (", "; x ->"}},
+		{"; _+? ;", []string{"; g ( ) ;"}},
+		{"@", []string{}},
+		{"z", []string{}},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.expected, findAllTexts(t, test.pattern, tokenList), test.pattern)
+	}
+}
+
+func Test_Pattern_Captures(t *testing.T) {
+	tokenList := createPatternTestTokens("f ( b , c ) ;")
+	pattern := tokens.MustCompilePattern("name:IDENTIFIER ( args:[ IDENTIFIER [ , IDENTIFIER ]* ]? ) end:;")
+	assert.Equal(t, "name:IDENTIFIER ( args:[ IDENTIFIER [ , IDENTIFIER ]* ]? ) end:;", pattern.String())
+	match, found := pattern.Find(tokenList)
+	assert.True(t, found)
+	assert.Equal(t, 0, match.Start)
+	assert.Equal(t, 7, match.End)
+	assert.Nil(t, match.Scope)
+	assert.Equal(t, 3, len(match.Captures))
+	assert.Equal(t, []string{"f"}, tests.TokenTexts(match.Captures["name"].Tokens))
+	assert.Equal(t, []string{"b", ",", "c"}, tests.TokenTexts(match.Captures["args"].Tokens))
+	assert.Equal(t, 2, match.Captures["args"].Start)
+	assert.Equal(t, 5, match.Captures["args"].End)
+	assert.Equal(t, []string{";"}, tests.TokenTexts(match.Captures["end"].Tokens))
+
+	// A repeated capture keeps its last repetition, and one which takes no part in the match is left out
+	pattern = tokens.MustCompilePattern("( [arg:IDENTIFIER ,?]* ) | [other:;]")
+	match, found = pattern.Find(tokenList)
+	assert.True(t, found)
+	assert.Equal(t, []string{"c"}, tests.TokenTexts(match.Captures["arg"].Tokens))
+	_, found = match.Captures["other"]
+	assert.False(t, found)
+
+	// Captures are undone when the pattern backtracks
+	pattern = tokens.MustCompilePattern("one:IDENTIFIER ( ; | IDENTIFIER ( two:IDENTIFIER ,")
+	match, found = pattern.Find(createPatternTestTokens("a ( b ,"))
+	assert.True(t, found)
+	assert.Equal(t, 1, len(match.Captures))
+	assert.Equal(t, []string{"b"}, tests.TokenTexts(match.Captures["two"].Tokens))
+	assert.Equal(t, []string{"a", "(", "b", ","}, tests.TokenTexts(match.Tokens))
+
+	// An optional capture may capture no tokens
+	pattern = tokens.MustCompilePattern("( inner:IDENTIFIER* )")
+	match, found = pattern.Find(createPatternTestTokens("f ( ) ;"))
+	assert.True(t, found)
+	assert.Equal(t, 2, match.Captures["inner"].Start)
+	assert.Equal(t, 0, len(match.Captures["inner"].Tokens))
+}
+
+func Test_Pattern_Trivia(t *testing.T) {
+	// Trivia is skipped, but kept within the matched tokens
+	tokenList := createPatternTestTokens("#a f #b ( #c ) #d ;")
+	match, found := tokens.MustCompilePattern("IDENTIFIER ( ) ;").Find(tokenList)
+	assert.True(t, found)
+	assert.Equal(t, 1, match.Start)
+	assert.Equal(t, 8, match.End)
+	assert.Equal(t, []string{"f", "#b", "(", "#c", ")", "#d", ";"}, tests.TokenTexts(match.Tokens))
+	assert.Equal(t, []string{}, findAllTexts(t, "COMMENT", tokenList))
+
+	// Matching at a position begins at the first token after it which is not trivia
+	pattern := tokens.MustCompilePattern("( )")
+	_, found = pattern.MatchAt(tokenList, 1)
+	assert.False(t, found)
+	match, found = pattern.MatchAt(tokenList, 2)
+	assert.True(t, found)
+	assert.Equal(t, 3, match.Start)
+	assert.Equal(t, 6, match.End)
+}
+
+func Test_Pattern_Scopes(t *testing.T) {
+	emptyScope := tokens.InitScope(createPatternTestTokens("#comment"))
+	emptyScope.SetType("Block")
+	methodScope := tokens.InitScope(createPatternTestTokens("= a ;"))
+	methodScope.SetType("Method")
+	tokenList := createPatternTestTokens("f ( ) {")
+	tokenList = append(tokenList, tokens.InitScopeToken(&methodScope))
+	tokenList = append(tokenList, createPatternTestTokens("} g ( ) {")...)
+	tokenList = append(tokenList, tokens.InitScopeToken(&emptyScope))
+	tokenList = append(tokenList, createPatternTestTokens("}")...)
+
+	tests := []struct {
+		pattern  string
+		expected int
+	}{
+		{"{ @ }", 2},
+		{"{ @Method }", 1},
+		{"{ @Block[] }", 1},
+		{"{ @[] }", 1},
+		{"{ @Method[] }", 0},
+		{"{ @[= ...] }", 1},
+		{"{ @[= IDENTIFIER ;] }", 1},
+		{"{ @[IDENTIFIER] }", 0},
+		{"@ | @[...]", 2},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.expected, len(tokens.MustCompilePattern(test.pattern).FindAll(tokenList)), test.pattern)
+	}
+
+	// Scopes are searched one after another, and a match is within a single scope
+	fileScope := tokens.InitScope(tokenList)
+	matches := tokens.MustCompilePattern("IDENTIFIER").FindInScope(&fileScope)
+	assert.Equal(t, 3, len(matches))
+	assert.Equal(t, "f", matches[0].Tokens[0].Text)
+	assert.Same(t, &fileScope, matches[0].Scope)
+	assert.Equal(t, "g", matches[1].Tokens[0].Text)
+	assert.Equal(t, "a", matches[2].Tokens[0].Text)
+	assert.Equal(t, "Method", matches[2].Scope.GetType())
+	assert.Equal(t, 1, matches[2].Start)
+}
+
+func Test_Pattern_CompileErrors(t *testing.T) {
+	patterns := []string{
+		"",
+		"   ",
+		"* a",
+		"a | + b",
+		"a ?*",
+		"[a",
+		"a ]",
+		"'a",
+		"/a",
+		"/(/",
+		"@[a",
+		"@[name:a]",
+		"a | [ b ] ]",
+		"[_*]* NOPE",
+		"...+",
+		"[a | b?]+",
+	}
+	for _, pattern := range patterns {
+		compiled, err := tokens.CompilePattern(pattern)
+		assert.Nil(t, compiled, pattern)
+		assert.NotNil(t, err, pattern)
+	}
+
+	_, err := tokens.CompilePattern("IDENTIFIER ( * )")
+	assert.EqualError(t, err, `invalid pattern "IDENTIFIER ( * )" at offset 13: '*' has nothing to repeat (quote it to match a token with the text)`)
+	assert.Panics(t, func() { tokens.MustCompilePattern("[") })
+	_, err = tokens.CompilePattern("a [b? c*]+")
+	assert.EqualError(t, err, `invalid pattern "a [b? c*]+" at offset 9: '+' repeats an element which can match no tokens`)
+
+	// Texts which are special are matched once quoted
+	compiled, err := tokens.CompilePattern(`'*' "[" '\''`)
+	assert.Nil(t, err)
+	_, found := compiled.Find(createPatternTestTokens("* [ '"))
+	assert.True(t, found)
+}
+
+func Test_Pattern_LargeTokenArray(t *testing.T) {
+	// The tokens share a few tokens, so that very many of them can be matched without creating each one
+	shared := createPatternTestTokens("a b ( x ) z")
+	tokenList := make([]*tokens.Token, 0, 1600002)
+	tokenList = append(tokenList, shared[0])
+	for len(tokenList) < 1600001 {
+		tokenList = append(tokenList, shared[1])
+	}
+	tokenList = append(tokenList, shared[5])
+
+	// A match may reach across every token without running out of stack
+	match, found := tokens.MustCompilePattern("a _* z").Find(tokenList)
+	assert.True(t, found)
+	assert.Equal(t, len(tokenList), match.End)
+	match, found = tokens.MustCompilePattern("a [b | x]+? z").Find(tokenList)
+	assert.True(t, found)
+	assert.Equal(t, len(tokenList), match.End)
+
+	// Nested repeats which cannot match are given up on in linear time, rather than trying every way of splitting the tokens
+	_, found = tokens.MustCompilePattern("[_+]* NOPE").Find(tokenList)
+	assert.False(t, found)
+
+	// Searching at every position does not search the rest of the tokens again
+	calls := make([]*tokens.Token, 0, 1600000)
+	for len(calls) < 1600000 {
+		calls = append(calls, shared[1], shared[2], shared[3], shared[4])
+	}
+	assert.Equal(t, 0, len(tokens.MustCompilePattern("IDENTIFIER ( ... ) {").FindAll(calls)))
+	assert.Equal(t, 400000, len(tokens.MustCompilePattern("IDENTIFIER ( ... )").FindAll(calls)))
+}
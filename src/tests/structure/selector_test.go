@@ -0,0 +1,126 @@
+package structure
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"tp/src/tests"
+	"tp/src/tokenizer/tokens"
+)
+
+// createSelectorTestScope
+// Creates the scope "x = [ y ; 'a b' ] // c", where the tokens in brackets are within a scope,
+// with whitespace between the tokens
+func createSelectorTestScope() tokens.ScopeObj {
+	space := func() *tokens.Token { return tests.CreateTestToken(" ", tokens.KIND_WHITESPACE, "WHITESPACE") }
+	innerScope := tokens.InitScope([]*tokens.Token{
+		space(), tests.CreateTestToken("y", tokens.KIND_IDENTIFIER, "IDENTIFIER"), space(), tests.CreateTestToken(";", tokens.KIND_UNKNOWN, "SEMI"),
+		space(), tests.CreateTestToken("'a b'", tokens.KIND_STRING, "STRING"), space(),
+	})
+	innerScope.SetType("Block")
+	return tokens.InitScope([]*tokens.Token{
+		tests.CreateTestToken("x", tokens.KIND_IDENTIFIER, "IDENTIFIER"), space(), tests.CreateTestToken("=", tokens.KIND_UNKNOWN, "EQUAL"), space(),
+		tokens.InitScopeToken(&innerScope), space(), tests.CreateTestToken("// c", tokens.KIND_COMMENT, "COMMENT"),
+	})
+}
+
+// selectTexts
+// Returns the texts of the tokens the query matches within the scope, or the error if the query is invalid
+func selectTexts(scope *tokens.ScopeObj, query string) ([]string, error) {
+	selector, err := tokens.ParseSelector(query)
+	if err != nil {
+		return nil, err
+	}
+	return tests.TokenTexts(selector.MatchTokens(scope)), nil
+}
+
+func Test_Selector_Match(t *testing.T) {
+	exampleScope := createSelectorTestScope()
+	tests := []struct {
+		query    string
+		expected []string
+	}{
+		{"IDENTIFIER", []string{"x", "y"}},
+		{"scope IDENTIFIER", []string{"y"}},
+		{"scope > *", []string{" ", "y", " ", ";", " ", "'a b'", " "}},
+		{"EQUAL + *", []string{" "}},
+		{"EQUAL + scope > :first-child", []string{"y"}},
+		{":last-child", []string{"'a b'"}},
+		{"IDENTIFIER ~ token:not([category=trivia])", []string{"=", ";", "'a b'"}},
+		{"IDENTIFIER + SEMI + STRING", []string{"'a b'"}},
+		{`"'a b'", [text~=b]`, []string{"'a b'"}},
+		{`['a b'] , [text="'a b'"]`, nil},
+		{`[text='\'a b\'']`, []string{"'a b'"}},
+		{`[text=~"^/+ "]`, []string{"// c"}},
+		{`[text=~/^\/\/ c$/]`, []string{"// c"}},
+		{"[category=literal], [kind^=COMM]", []string{"'a b'", "// c"}},
+		{"[kind$=MI], [text!=x][category=identifier]", []string{"y", ";"}},
+		{"[depth=0]:not(WHITESPACE)", []string{"x", "=", "// c"}},
+		{"[depth=1]:not(WHITESPACE)", []string{"y", ";", "'a b'"}},
+		{".Block > [type]", []string{}},
+		{"  scope.Block  >  IDENTIFIER  ,IDENTIFIER  ", []string{"x", "y"}},
+	}
+	for _, test := range tests {
+		texts, err := selectTexts(&exampleScope, test.query)
+		if test.expected == nil {
+			assert.NotNil(t, err, test.query)
+			continue
+		}
+		assert.Nil(t, err, test.query)
+		assert.Equal(t, test.expected, texts, test.query)
+	}
+
+	// Scopes are matched along with tokens
+	selector := tokens.MustParseSelector(".Block, [type=Block] > STRING")
+	assert.Equal(t, ".Block, [type=Block] > STRING", selector.String())
+	matches := selector.Match(&exampleScope)
+	assert.Equal(t, 2, len(matches))
+	assert.Equal(t, "Block", matches[0].Scope.GetType())
+	assert.Equal(t, []int{4}, matches[0].Path)
+	assert.Equal(t, 1, matches[0].Depth)
+	assert.Nil(t, matches[1].Scope)
+	assert.Equal(t, []int{4, 5}, matches[1].Path)
+	assert.Equal(t, 1, matches[1].Depth)
+	assert.Equal(t, 1, len(selector.MatchScopes(&exampleScope)))
+	assert.Equal(t, 1, len(selector.MatchTokens(&exampleScope)))
+}
+
+func Test_Selector_ParseErrors(t *testing.T) {
+	queries := []string{
+		"",
+		",IDENTIFIER",
+		"IDENTIFIER,",
+		"IDENTIFIER >",
+		"> IDENTIFIER",
+		"IDENTIFIER )",
+		"a$b",
+		"[unknown=1]",
+		"[text",
+		"[text x]",
+		"[text=]",
+		"[text=x",
+		"[text<1]",
+		"[depth>x]",
+		"[text=~x]",
+		"[text=~/(/]",
+		"[text=\"x]",
+		":unknown",
+		":not",
+		":not(x",
+		":not()",
+		".",
+		"#[name]",
+	}
+	for _, query := range queries {
+		selector, err := tokens.ParseSelector(query)
+		assert.Nil(t, selector, query)
+		assert.NotNil(t, err, query)
+	}
+
+	// Errors tell where the query is wrong
+	_, err := tokens.ParseSelector("IDENTIFIER[depth>x]")
+	assert.EqualError(t, err, `invalid selector "IDENTIFIER[depth>x]" at offset 17: expected a number after ">"`)
+	_, err = tokens.ParseSelector("scope:unknown")
+	assert.EqualError(t, err, `invalid selector "scope:unknown" at offset 6: unknown pseudo-class "unknown" (expected first-child, last-child or not)`)
+
+	assert.Panics(t, func() { tokens.MustParseSelector("[") })
+}
@@ -81,3 +81,19 @@ func Test_GetScope_DoesNotExist(t *testing.T) {
 	var nullExampleScope *tokens.ScopeObj
 	assert.Equal(t, nullExampleScope, nullScope)
 }
+
+func Test_SetSpan(t *testing.T) {
+	token := tokens.CreateUnidentifiedToken("the text", 1, 2)
+	token.SetSpan(tokens.Position{Line: 1, Column: 3, Offset: 2}, tokens.Position{Line: 1, Column: 11, Offset: 10})
+	assert.Equal(t, tokens.Position{Line: 1, Column: 3, Offset: 2}, token.Start)
+	assert.Equal(t, tokens.Position{Line: 1, Column: 11, Offset: 10}, token.End)
+}
+
+func Test_ToJsonString_Span(t *testing.T) {
+	token := tokens.CreateUnidentifiedToken("if", 2, 0)
+	token.SetValues("KEYWORD", "IF")
+	token.SetSpan(tokens.Position{Line: 2, Column: 1, Offset: 5}, tokens.Position{Line: 2, Column: 3, Offset: 7})
+	jsonString := token.ToJsonString(0)
+	assert.Contains(t, jsonString, "\"Start\": {\"Line\": 2, \"Column\": 1, \"Offset\": 5}")
+	assert.Contains(t, jsonString, "\"End\": {\"Line\": 2, \"Column\": 3, \"Offset\": 7}")
+}
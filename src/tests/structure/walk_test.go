@@ -0,0 +1,171 @@
+package structure
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"tp/src/tests"
+	"tp/src/tokenizer/tokens"
+)
+
+// createWalkTestScope
+// Creates the scope "a [b [c] d] e [f]", where the tokens in brackets are within a scope
+func createWalkTestScope() tokens.ScopeObj {
+	token := func(text string) *tokens.Token {
+		return tests.CreateTestToken(text, tokens.KIND_IDENTIFIER, "IDENTIFIER")
+	}
+	innerScope := tokens.InitScope([]*tokens.Token{token("c")})
+	middleScope := tokens.InitScope([]*tokens.Token{token("b"), tokens.InitScopeToken(&innerScope), token("d")})
+	lastScope := tokens.InitScope([]*tokens.Token{token("f")})
+	return tokens.InitScope([]*tokens.Token{token("a"), tokens.InitScopeToken(&middleScope), token("e"), tokens.InitScopeToken(&lastScope)})
+}
+
+// recordingVisitor
+// Returns a visitor which records every callback it gets, along with the location of the token
+func recordingVisitor(calls *[]string) tokens.Visitor {
+	return tokens.Visitor{
+		EnterScope: func(token *tokens.Token, scope *tokens.ScopeObj, location tokens.WalkLocation) tokens.WalkAction {
+			*calls = append(*calls, fmt.Sprintf("enter %d %v", location.Depth, location.Path))
+			return tokens.WALK_CONTINUE
+		},
+		LeaveScope: func(token *tokens.Token, scope *tokens.ScopeObj, location tokens.WalkLocation) tokens.WalkAction {
+			*calls = append(*calls, fmt.Sprintf("leave %d %v", location.Depth, location.Path))
+			return tokens.WALK_CONTINUE
+		},
+		Token: func(token *tokens.Token, location tokens.WalkLocation) tokens.WalkAction {
+			*calls = append(*calls, fmt.Sprintf("%s %d %v", token.Text, location.Depth, location.Path))
+			return tokens.WALK_CONTINUE
+		},
+	}
+}
+
+func Test_Walk(t *testing.T) {
+	exampleScope := createWalkTestScope()
+	calls := make([]string, 0)
+	assert.True(t, exampleScope.Walk(recordingVisitor(&calls)))
+	assert.Equal(t, []string{
+		"a 0 [0]",
+		"enter 0 [1]",
+		"b 1 [1 0]",
+		"enter 1 [1 1]",
+		"c 2 [1 1 0]",
+		"leave 1 [1 1]",
+		"d 1 [1 2]",
+		"leave 0 [1]",
+		"e 0 [2]",
+		"enter 0 [3]",
+		"f 1 [3 0]",
+		"leave 0 [3]",
+	}, calls)
+
+	// Callbacks which are not provided are skipped
+	texts := ""
+	assert.True(t, exampleScope.Walk(tokens.Visitor{Token: func(token *tokens.Token, location tokens.WalkLocation) tokens.WalkAction {
+		texts += token.Text
+		return tokens.WALK_CONTINUE
+	}}))
+	assert.Equal(t, "abcdef", texts)
+}
+
+func Test_Walk_Parent(t *testing.T) {
+	exampleScope := createWalkTestScope()
+	exampleScope.Walk(tokens.Visitor{Token: func(token *tokens.Token, location tokens.WalkLocation) tokens.WalkAction {
+		parentToken, err := location.Parent.At(location.Index())
+		assert.Nil(t, err)
+		assert.Same(t, token, parentToken)
+		return tokens.WALK_CONTINUE
+	}})
+}
+
+func Test_Walk_Skip(t *testing.T) {
+	exampleScope := createWalkTestScope()
+	calls := make([]string, 0)
+	visitor := recordingVisitor(&calls)
+	visitor.EnterScope = func(token *tokens.Token, scope *tokens.ScopeObj, location tokens.WalkLocation) tokens.WalkAction {
+		calls = append(calls, fmt.Sprintf("enter %d %v", location.Depth, location.Path))
+		if location.Depth == 1 {
+			return tokens.WALK_SKIP
+		}
+		return tokens.WALK_CONTINUE
+	}
+	assert.True(t, exampleScope.Walk(visitor))
+	assert.Equal(t, []string{
+		"a 0 [0]",
+		"enter 0 [1]",
+		"b 1 [1 0]",
+		"enter 1 [1 1]",
+		"d 1 [1 2]",
+		"leave 0 [1]",
+		"e 0 [2]",
+		"enter 0 [3]",
+		"f 1 [3 0]",
+		"leave 0 [3]",
+	}, calls)
+}
+
+func Test_Walk_Stop(t *testing.T) {
+	exampleScope := createWalkTestScope()
+	calls := make([]string, 0)
+	visitor := recordingVisitor(&calls)
+	visitor.Token = func(token *tokens.Token, location tokens.WalkLocation) tokens.WalkAction {
+		calls = append(calls, token.Text)
+		if token.Text == "c" {
+			return tokens.WALK_STOP
+		}
+		return tokens.WALK_CONTINUE
+	}
+	assert.False(t, exampleScope.Walk(visitor))
+	assert.Equal(t, []string{"a", "enter 0 [1]", "b", "enter 1 [1 1]", "c"}, calls)
+
+	// Stopping while leaving a scope also stops the walk
+	calls = make([]string, 0)
+	visitor = recordingVisitor(&calls)
+	visitor.LeaveScope = func(token *tokens.Token, scope *tokens.ScopeObj, location tokens.WalkLocation) tokens.WalkAction {
+		calls = append(calls, "leave")
+		return tokens.WALK_STOP
+	}
+	assert.False(t, exampleScope.Walk(visitor))
+	assert.Equal(t, []string{"a 0 [0]", "enter 0 [1]", "b 1 [1 0]", "enter 1 [1 1]", "c 2 [1 1 0]", "leave"}, calls)
+}
+
+func Test_Inspect(t *testing.T) {
+	exampleScope := createWalkTestScope()
+	found := make([]string, 0)
+	exampleScope.Inspect(func(token *tokens.Token, location tokens.WalkLocation) bool {
+		if token.ValidScopeToken() {
+			found = append(found, fmt.Sprintf("scope %v", location.Path))
+			// Only the outermost scopes are looked into
+			return location.Depth == 0
+		}
+		found = append(found, token.Text)
+		return true
+	})
+	assert.Equal(t, []string{"a", "scope [1]", "b", "scope [1 1]", "d", "e", "scope [3]", "f"}, found)
+}
+
+func Test_Tokens(t *testing.T) {
+	exampleScope := createWalkTestScope()
+	texts := ""
+	for token := range exampleScope.Tokens() {
+		texts += token.Text
+	}
+	assert.Equal(t, "abcdef", texts)
+
+	// The iteration may be stopped early
+	texts = ""
+	for token := range exampleScope.Tokens() {
+		if token.Text == "d" {
+			break
+		}
+		texts += token.Text
+	}
+	assert.Equal(t, "abc", texts)
+
+	tokenArray := CreateTestTokenArray("testToken", 4, 4, 2, 1)
+	largerScope := tokens.InitScope(tokenArray)
+	iterated := make([]*tokens.Token, 0)
+	for token := range largerScope.Tokens() {
+		iterated = append(iterated, token)
+	}
+	assert.Equal(t, largerScope.ConvertToArray(), iterated)
+}
@@ -0,0 +1,111 @@
+package tokenizer_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"tp/src/tests"
+	tz "tp/src/tokenizer"
+	tk "tp/src/tokenizer/tokens"
+)
+
+// getBlockCommentLanguage
+// Returns a language with "/* */" and "(* *)" block comments and "//" line comments, written with callbacks.
+// Block comments may be nested if nested is true.
+func getBlockCommentLanguage(nested bool) *tz.Language {
+	lang := tz.CreateDullLanguage().Copy()
+	lang.ConfigureComment(func(tkzr *tz.Tokenizer) bool {
+		for _, delimiters := range [][]string{{"/*", "*/"}, {"(*", "*)"}, {"//", "\n"}} {
+			if text, err := tkzr.TextRange(tkzr.Index(), tkzr.Index()+len(delimiters[0])); err == nil && text == delimiters[0] {
+				tkzr.State().Set("commentLine", tkzr.GetCurrentLineNumber())
+				tkzr.IncrementIndex()
+				tkzr.StartInfo = delimiters[0]
+				tkzr.EndInfo = delimiters[1]
+				return true
+			}
+		}
+		return false
+	}, func(tkzr *tz.Tokenizer) bool {
+		if tkzr.EndInfo == "\n" {
+			lineNumber, _ := tz.GetState[int](tkzr, "commentLine")
+			return tkzr.GetCurrentLineNumber() != lineNumber
+		}
+		text, err := tkzr.TextRange(tkzr.Index(), tkzr.Index()+len(tkzr.EndInfo))
+		return err == nil && text == tkzr.EndInfo
+	})
+	if nested {
+		lang.NestedComments = []string{"/*", "(*"}
+	}
+	return lang
+}
+
+func Test_Comments_Nested(t *testing.T) {
+	text := "a /* 1 /* 2\n(* 3 *) */ 1 */ b /*/ */ c // /* d\ne"
+	tokensScope, diagnostics, err := tz.NewTokenizer(getBlockCommentLanguage(true)).Tokenize(text)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(diagnostics))
+	assert.Equal(t, "a /* 1 /* 2\n(* 3 *) */ 1 */ b /*/ */ c // /* d\n e", describeScope(&tokensScope))
+
+	st1, _ := tokensScope.At(1)
+	tests.ValidateToken(t, st1, 1, 0, tz.RULENAME_OTHER, tz.SYMBOLIC_NAME_COMMENT, "/* 1 /* 2\n(* 3 *) */ 1 */")
+	tests.ValidateTokenSpan(t, st1, tk.Position{Line: 1, Column: 3, Offset: 2}, tk.Position{Line: 2, Column: 16, Offset: 27})
+
+	// Without nesting, a comment ends at the first end it finds
+	tokensScope, _, err = tz.NewTokenizer(getBlockCommentLanguage(false)).Tokenize("a /* 1 /* 2 */ 1 */ b")
+	assert.Nil(t, err)
+	assert.Equal(t, "a /* 1 /* 2 */ 1 * / b", describeScope(&tokensScope))
+}
+
+func Test_Comments_DeeplyNested(t *testing.T) {
+	depth := 1000
+	comment := strings.Repeat("/* (* ", depth) + strings.Repeat(" *)", depth) + strings.Repeat(" */", depth)
+	tokensScope, diagnostics, err := tz.NewTokenizer(getBlockCommentLanguage(true)).Tokenize("a " + comment + " b")
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(diagnostics))
+	assert.Equal(t, 3, tokensScope.Size())
+	st1, _ := tokensScope.At(1)
+	assert.Equal(t, comment, st1.Text)
+}
+
+func Test_Comments_UnterminatedNested(t *testing.T) {
+	text := "a /* 1 /* 2 */ 1\nb"
+	tokensScope, diagnostics, err := tz.NewTokenizer(getBlockCommentLanguage(true)).Tokenize(text)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(diagnostics))
+	tests.ValidateDiagnostic(t, diagnostics[0], tz.SEVERITY_ERROR, tz.DIAGNOSTIC_UNTERMINATED_COMMENT,
+		tk.Position{Line: 1, Column: 3, Offset: 2}, tk.Position{Line: 2, Column: 2, Offset: 18})
+	assert.Equal(t, 2, tokensScope.Size())
+
+	// In recovery mode, the start of the comment becomes an error token and the comment within it is found instead
+	tokenizer := tz.NewTokenizer(getBlockCommentLanguage(true))
+	tokenizer.RecoveryMode = true
+	tokensScope, diagnostics, err = tokenizer.Tokenize(text)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(diagnostics))
+	assert.Equal(t, "a /* 1 /* 2 */ 1 b", describeScope(&tokensScope))
+	st1, _ := tokensScope.At(1)
+	assert.Equal(t, tk.KIND_ERROR, st1.Kind)
+	st1, _ = tokensScope.At(3)
+	tests.ValidateToken(t, st1, 1, 0, tz.RULENAME_OTHER, tz.SYMBOLIC_NAME_COMMENT, "/* 2 */")
+}
+
+func Test_Comments_NestedLosslessAndStream(t *testing.T) {
+	texts := []string{
+		"a /* 1 /* 2\n(* 3 *) */ 1 */ b /*/ */ c // /* d\ne",
+		"a /* 1 /* 2 */ 1\nb",
+	}
+	for _, text := range texts {
+		tokenizer := tz.NewTokenizer(getBlockCommentLanguage(true))
+		tokenizer.LosslessMode = true
+		tokensScope, _, err := tokenizer.Tokenize(text)
+		assert.Nil(t, err)
+		validateLossless(t, text, &tokensScope, text)
+
+		expected, _, err := tz.NewTokenizer(getBlockCommentLanguage(true)).Tokenize(text)
+		assert.Nil(t, err)
+		stream, err := tz.NewTokenizer(getBlockCommentLanguage(true)).TokenizeReader(iotest.OneByteReader(strings.NewReader(text)))
+		assert.Nil(t, err)
+		assert.Equal(t, scopeToEvents(&expected), collectEvents(t, stream))
+	}
+}
@@ -0,0 +1,251 @@
+package tokenizer_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+	"tp/src/tests"
+	tz "tp/src/tokenizer"
+	tk "tp/src/tokenizer/tokens"
+)
+
+const exampleDefinitionJson = `{
+  "name": "example",
+  "keywords": ["let", "fn"],
+  "symbols": [["=", "Equal"], [";", "SemiColon"], ["(", "LParen"], [")", "RParen"], ["[", "LBracket"], ["]", "RBracket"]],
+  "identifiers": {"letters": true, "digits": true, "extra": "_$"},
+  "numbers": {"allowDecimalPoint": true},
+  "comments": [{"start": "--"}, {"start": "{-", "end": "-}", "nested": true}],
+  "strings": [{"start": "\"", "end": "\"", "escape": "\\"}, {"start": "r'", "end": "'"}],
+  "scopes": [{"start": "{", "end": "}"}, {"start": "begin", "end": "end"}]
+}`
+
+const exampleDefinitionYaml = `
+name: example
+keywords: [let, fn]
+symbols:
+  - ["=", Equal]
+  - [";", SemiColon]
+  - ["(", LParen]
+  - [")", RParen]
+  - ["[", LBracket]
+  - ["]", RBracket]
+identifiers: {letters: true, digits: true, extra: "_$"}
+numbers: {allowDecimalPoint: true}
+comments:
+  - start: "--"
+  - {start: "{-", end: "-}", nested: true}
+strings:
+  - {start: '"', end: '"', escape: '\'}
+  - {start: "r'", end: "'"}
+scopes:
+  - {start: "{", end: "}"}
+  - {start: begin, end: end}
+`
+
+func getExampleDefinitionTokenizer(t *testing.T) *tz.Tokenizer {
+	definition, err := tz.ParseLanguageDefinition([]byte(exampleDefinitionJson), tz.DEFINITION_FORMAT_JSON)
+	assert.Nil(t, err)
+	lang, err := definition.BuildLanguage()
+	assert.Nil(t, err)
+	return tz.NewTokenizer(lang)
+}
+
+func Test_LanguageDefinition_JsonAndYaml(t *testing.T) {
+	jsonDefinition, err := tz.ParseLanguageDefinition([]byte(exampleDefinitionJson), tz.DEFINITION_FORMAT_JSON)
+	assert.Nil(t, err)
+	yamlDefinition, err := tz.ParseLanguageDefinition([]byte(exampleDefinitionYaml), tz.DEFINITION_FORMAT_YAML)
+	assert.Nil(t, err)
+	assert.Equal(t, jsonDefinition, yamlDefinition)
+
+	lang, err := yamlDefinition.BuildLanguage()
+	assert.Nil(t, err)
+	assert.Nil(t, lang.IsConfigured())
+	assert.Equal(t, "example", lang.LanguageType)
+	assert.True(t, lang.IsKeywordCharacter('$'))
+	assert.False(t, lang.IsKeywordCharacter('-'))
+	kind, found := lang.KeywordKind("let")
+	assert.True(t, found)
+	assert.True(t, kind.IsKeyword())
+}
+
+func Test_LanguageDefinition_Tokens(t *testing.T) {
+	text := "let x_$ = \"a\\\"b\\\\\"; -- note\nlet y = r'c:\\' {- a {- b -} c -} 1.5;"
+	tokensScope, diagnostics, err := getExampleDefinitionTokenizer(t).Tokenize(text)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(diagnostics))
+
+	assert.Equal(t, 13, tokensScope.Size())
+	for i := 0; i < tokensScope.Size(); i++ {
+		st1, _ := tokensScope.At(i)
+		switch i {
+		case 0:
+			tests.ValidateToken(t, st1, 1, 0, tz.RULENAME_KEYWORD, "LET", "let")
+		case 1:
+			tests.VerifyUnknownKeyword(t, st1, 1, 0, "x_$")
+		case 2:
+			tests.ValidateToken(t, st1, 1, 0, tz.RULENAME_SYMBOL, "EQUAL", "=")
+		case 3:
+			// An escaped quote does not end the string, while a quote after an escaped escape does
+			tests.ValidateToken(t, st1, 1, 0, tz.RULENAME_OTHER, tz.SYMBOLIC_NAME_STRING, "\"a\\\"b\\\\\"")
+			tests.ValidateTokenSpan(t, st1, tk.Position{Line: 1, Column: 11, Offset: 10}, tk.Position{Line: 1, Column: 19, Offset: 18})
+		case 4:
+			tests.ValidateToken(t, st1, 1, 0, tz.RULENAME_SYMBOL, "SEMICOLON", ";")
+		case 5:
+			tests.ValidateToken(t, st1, 1, 0, tz.RULENAME_OTHER, tz.SYMBOLIC_NAME_COMMENT, "-- note\n")
+		case 9:
+			// Strings without an escape end at the first end found
+			tests.ValidateToken(t, st1, 2, 0, tz.RULENAME_OTHER, tz.SYMBOLIC_NAME_STRING, "r'c:\\'")
+		case 10:
+			// A nested comment only ends once the comments within it have ended
+			tests.ValidateToken(t, st1, 2, 0, tz.RULENAME_OTHER, tz.SYMBOLIC_NAME_COMMENT, "{- a {- b -} c -}")
+			tests.ValidateTokenSpan(t, st1, tk.Position{Line: 2, Column: 16, Offset: 43}, tk.Position{Line: 2, Column: 33, Offset: 60})
+		case 11:
+			assert.Equal(t, tk.KIND_NUMBER, st1.Kind)
+			assert.Equal(t, "1.5", st1.Text)
+		}
+	}
+}
+
+func Test_LanguageDefinition_Scopes(t *testing.T) {
+	tokenizer := getExampleDefinitionTokenizer(t)
+
+	// Multi character delimiters open and close scopes, and a scope is only closed by its own end
+	tokensScope, diagnostics, err := tokenizer.Tokenize("begin x { y end } end")
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(diagnostics))
+
+	assert.Equal(t, 3, tokensScope.Size())
+	st1, _ := tokensScope.At(0)
+	tests.VerifyUnknownSymbol(t, st1, 1, 0, "begin")
+	tests.ValidateTokenSpan(t, st1, tk.Position{Line: 1, Column: 1, Offset: 0}, tk.Position{Line: 1, Column: 6, Offset: 5})
+	st1, _ = tokensScope.At(1)
+	assert.True(t, st1.ValidScopeToken())
+	outerScope := st1.GetScopeToken()
+	assert.Equal(t, 4, outerScope.Size())
+	st1, _ = outerScope.At(2)
+	assert.True(t, st1.ValidScopeToken())
+	innerScope := st1.GetScopeToken()
+	assert.Equal(t, 2, innerScope.Size())
+	st1, _ = innerScope.At(1)
+	// "end" does not close the scope opened by "{", so it is a name
+	tests.VerifyUnknownKeyword(t, st1, 1, 0, "end")
+	st1, _ = outerScope.At(3)
+	tests.VerifyUnknownSymbol(t, st1, 1, 0, "}")
+	st1, _ = tokensScope.At(2)
+	tests.VerifyUnknownSymbol(t, st1, 1, 0, "end")
+	tests.ValidateTokenSpan(t, st1, tk.Position{Line: 1, Column: 19, Offset: 18}, tk.Position{Line: 1, Column: 22, Offset: 21})
+
+	// Delimiters which are words are not found within longer words
+	tokensScope, diagnostics, err = tokenizer.Tokenize("beginning = x_end")
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(diagnostics))
+	assert.Equal(t, 3, tokensScope.Size())
+	st1, _ = tokensScope.At(0)
+	tests.VerifyUnknownKeyword(t, st1, 1, 0, "beginning")
+	st1, _ = tokensScope.At(2)
+	tests.VerifyUnknownKeyword(t, st1, 1, 0, "x_end")
+
+	// An end when no scope is open is reported
+	_, diagnostics, err = tokenizer.Tokenize("x }")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(diagnostics))
+	assert.Equal(t, tz.DIAGNOSTIC_UNBALANCED_SCOPE_CLOSE, diagnostics[0].Code)
+}
+
+func Test_LanguageDefinition_IndentationScopes(t *testing.T) {
+	definition := &tz.LanguageDefinition{
+		Name:              "indented",
+		Symbols:           [][]string{{"=", "Equal"}},
+		IndentationScopes: &tz.IndentationScopeDefinition{Opener: "=>", Exceptions: []string{"=>>"}},
+	}
+	lang, err := definition.BuildLanguage()
+	assert.Nil(t, err)
+	assert.True(t, lang.ScopesEndWithText)
+
+	tokensScope, diagnostics, err := tz.NewTokenizer(lang).Tokenize("a =>\n    b =>> c\nd")
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(diagnostics))
+
+	assert.Equal(t, 4, tokensScope.Size())
+	st1, _ := tokensScope.At(1)
+	tests.VerifyUnknownSymbol(t, st1, 1, 0, "=>")
+	st1, _ = tokensScope.At(2)
+	assert.True(t, st1.ValidScopeToken())
+	scope := st1.GetScopeToken()
+	assert.Equal(t, 5, scope.Size())
+	st1, _ = tokensScope.At(3)
+	tests.VerifyUnknownKeyword(t, st1, 3, 0, "d")
+}
+
+func Test_LanguageDefinition_Options(t *testing.T) {
+	definition, err := tz.ParseLanguageDefinition([]byte(`
+name: options
+strings: [{start: '"', end: '"'}]
+options:
+  tabSize: 2
+  includeStrings: false
+  ignoreNewLines: false
+`), tz.DEFINITION_FORMAT_YAML)
+	assert.Nil(t, err)
+	lang, err := definition.BuildLanguage()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, lang.NumOfSpacesEquallyTab)
+	assert.False(t, lang.IncludeStrings)
+	assert.True(t, lang.IncludeComments)
+	assert.True(t, lang.IgnoreWhitespace)
+	assert.False(t, lang.IgnoreNewLines)
+	// Strings cannot span multiple lines unless they are multiline
+	assert.Equal(t, []string{"\""}, lang.SingleLineStrings)
+}
+
+func Test_LanguageDefinition_Invalid(t *testing.T) {
+	invalidDefinitions := map[string]string{
+		"missing name":          `{"keywords": ["a"]}`,
+		"unknown field":         `{"name": "x", "keyword": ["a"]}`,
+		"symbol without name":   `{"name": "x", "symbols": [["+"]]}`,
+		"comment without start": `{"name": "x", "comments": [{"end": "*/"}]}`,
+		"nested line comment":   `{"name": "x", "comments": [{"start": "#", "nested": true}]}`,
+		"string without end":    `{"name": "x", "strings": [{"start": "\""}]}`,
+		"long escape":           `{"name": "x", "strings": [{"start": "\"", "end": "\"", "escape": "\\\\"}]}`,
+		"both scope kinds":      `{"name": "x", "scopes": [{"start": "{", "end": "}"}], "indentationScopes": {"opener": ":"}}`,
+		"negative tab size":     `{"name": "x", "options": {"tabSize": -1}}`,
+		"not json":              `name: x`,
+	}
+	for description, data := range invalidDefinitions {
+		_, err := tz.ParseLanguageDefinition([]byte(data), tz.DEFINITION_FORMAT_JSON)
+		assert.NotNil(t, err, description)
+	}
+
+	_, err := tz.ParseLanguageDefinition([]byte(`{"name": "x"}`), "toml")
+	assert.NotNil(t, err)
+	_, err = tz.ParseLanguageDefinition([]byte("name: x\nkeyword: [a]"), tz.DEFINITION_FORMAT_YAML)
+	assert.NotNil(t, err)
+
+	_, err = (&tz.LanguageDefinition{}).BuildLanguage()
+	assert.NotNil(t, err)
+}
+
+func Test_LoadTokenizer(t *testing.T) {
+	directory := t.TempDir()
+	jsonPath := filepath.Join(directory, "example.json")
+	yamlPath := filepath.Join(directory, "example.yml")
+	assert.Nil(t, os.WriteFile(jsonPath, []byte(exampleDefinitionJson), 0644))
+	assert.Nil(t, os.WriteFile(yamlPath, []byte(exampleDefinitionYaml), 0644))
+
+	for _, path := range []string{jsonPath, yamlPath} {
+		tokenizer, err := tz.LoadTokenizer(path)
+		assert.Nil(t, err)
+		tokensScope, _, err := tokenizer.Tokenize("let x = 1;")
+		assert.Nil(t, err)
+		assert.Equal(t, 5, tokensScope.Size())
+	}
+
+	textPath := filepath.Join(directory, "example.txt")
+	assert.Nil(t, os.WriteFile(textPath, []byte(exampleDefinitionJson), 0644))
+	_, err := tz.LoadTokenizer(textPath)
+	assert.NotNil(t, err)
+	_, err = tz.LoadTokenizer(filepath.Join(directory, "missing.json"))
+	assert.NotNil(t, err)
+}
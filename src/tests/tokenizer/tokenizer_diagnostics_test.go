@@ -0,0 +1,121 @@
+package tokenizer_test
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"tp/src/tests"
+	tz "tp/src/tokenizer"
+	tk "tp/src/tokenizer/tokens"
+)
+
+func Test_Diagnostics_CallbackPanic(t *testing.T) {
+	language := tz.CreateDullLanguage()
+	language.ConfigureString(
+		func(tkzr *tz.Tokenizer) bool {
+			if tkzr.CurrentChar() == '!' {
+				panic("bad callback")
+			}
+			return false
+		},
+		func(tkzr *tz.Tokenizer) bool {
+			return true
+		},
+	)
+
+	tokensScope, diagnostics, err := tz.NewTokenizer(language).Tokenize("a ! b")
+	assert.Nil(t, err)
+
+	// The character which caused the panic is skipped, but the rest of the text is still tokenized
+	assert.Equal(t, 2, tokensScope.Size())
+	first, _ := tokensScope.At(0)
+	tests.VerifyUnknownKeyword(t, first, 1, 0, "a")
+	second, _ := tokensScope.At(1)
+	tests.VerifyUnknownKeyword(t, second, 1, 0, "b")
+
+	if assert.Equal(t, 1, len(diagnostics)) {
+		tests.ValidateDiagnostic(t, diagnostics[0], tz.SEVERITY_ERROR, tz.DIAGNOSTIC_CALLBACK_PANIC,
+			tk.Position{Line: 1, Column: 3, Offset: 2}, tk.Position{Line: 1, Column: 4, Offset: 3})
+		assert.Contains(t, diagnostics[0].Message, "bad callback")
+	}
+}
+
+func Test_Diagnostics_CallbackOutOfBounds(t *testing.T) {
+	language := tz.CreateDullLanguage()
+	language.ConfigureComment(
+		func(tkzr *tz.Tokenizer) bool {
+			// Reads the next character without checking whether there is one
+			return tkzr.CurrentChar() == '/' && tkzr.GetChar(tkzr.NextIndex(tkzr.Index())) == '/'
+		},
+		func(tkzr *tz.Tokenizer) bool {
+			return tkzr.CurrentChar() == '\n'
+		},
+	)
+
+	tokensScope, diagnostics, err := tz.NewTokenizer(language).Tokenize("a /")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, tokensScope.Size())
+	if assert.Equal(t, 1, len(diagnostics)) {
+		tests.ValidateDiagnostic(t, diagnostics[0], tz.SEVERITY_WARNING, tz.DIAGNOSTIC_CALLBACK_OUT_OF_BOUNDS,
+			tk.Position{Line: 1, Column: 3, Offset: 2}, tk.Position{Line: 1, Column: 3, Offset: 2})
+	}
+}
+
+func Test_Diagnostics_CallbackNoProgress(t *testing.T) {
+	language := tz.CreateDullLanguage()
+	language.ConfigureScope(
+		func(tkzr *tz.Tokenizer) bool {
+			return false
+		},
+		// Always ends a scope on "}" without ever moving forward
+		func(tkzr *tz.Tokenizer) bool {
+			if tkzr.CurrentChar() == '}' {
+				tkzr.EndInfo = ""
+				tkzr.SkipIncrement()
+				return true
+			}
+			return false
+		},
+	)
+
+	tokensScope, diagnostics, err := tz.NewTokenizer(language).Tokenize("a } b")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, tokensScope.Size())
+
+	// Every attempt at closing a scope is unbalanced, until the character is skipped
+	codes := make(map[string]int)
+	for _, diagnostic := range diagnostics {
+		codes[diagnostic.Code]++
+	}
+	assert.Equal(t, 1, codes[tz.DIAGNOSTIC_CALLBACK_NO_PROGRESS])
+	assert.Less(t, 0, codes[tz.DIAGNOSTIC_UNBALANCED_SCOPE_CLOSE])
+	last := diagnostics[len(diagnostics)-1]
+	tests.ValidateDiagnostic(t, last, tz.SEVERITY_ERROR, tz.DIAGNOSTIC_CALLBACK_NO_PROGRESS,
+		tk.Position{Line: 1, Column: 3, Offset: 2}, tk.Position{Line: 1, Column: 4, Offset: 3})
+}
+
+func Test_Diagnostics_ReadError(t *testing.T) {
+	readErr := errors.New("disk failure")
+	stream, err := tz.CreateDullTokenizer().TokenizeReader(io.MultiReader(strings.NewReader("a b"), iotest.ErrReader(readErr)))
+	assert.Nil(t, err)
+	for stream.Next() {
+	}
+
+	diagnostics := stream.Diagnostics()
+	if assert.Equal(t, 1, len(diagnostics)) {
+		tests.ValidateDiagnostic(t, diagnostics[0], tz.SEVERITY_ERROR, tz.DIAGNOSTIC_READ_ERROR,
+			tk.Position{Line: 1, Column: 4, Offset: 3}, tk.Position{Line: 1, Column: 4, Offset: 3})
+		assert.Contains(t, diagnostics[0].Message, "disk failure")
+	}
+}
+
+func Test_Diagnostics_NoText(t *testing.T) {
+	// Nothing has been tokenized yet, so there is no text to read from
+	tokenizer := tz.CreateDullTokenizer()
+	assert.Equal(t, 0, tokenizer.TextSize())
+	assert.False(t, tokenizer.IndexInBound())
+	assert.Equal(t, rune(0), tokenizer.GetChar(0))
+}
@@ -0,0 +1,178 @@
+package tokenizer_test
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"strings"
+	"testing"
+	"testing/iotest"
+	javaTokenizer "tp/src/instances/langs/java"
+	tz "tp/src/tokenizer"
+	tk "tp/src/tokenizer/tokens"
+	"tp/src/util"
+	"unicode/utf16"
+)
+
+func Test_Encoding_ExampleFiles(t *testing.T) {
+	files := []struct {
+		path     string
+		expected string
+		encoding tk.Encoding
+	}{
+		{"../exampleFiles/encodings/unicode_utf8bom.java", "../exampleFiles/unicode.java", tk.Encoding{Name: tz.ENCODING_UTF8, ByteOrderMark: true}},
+		{"../exampleFiles/encodings/unicode_utf16le.java", "../exampleFiles/unicode.java", tk.Encoding{Name: tz.ENCODING_UTF16LE, ByteOrderMark: true}},
+		{"../exampleFiles/encodings/unicode_utf16be.java", "../exampleFiles/unicode.java", tk.Encoding{Name: tz.ENCODING_UTF16BE, ByteOrderMark: true}},
+		{"../exampleFiles/encodings/unicode_utf16le_nobom.java", "../exampleFiles/unicode.java", tk.Encoding{Name: tz.ENCODING_UTF16LE}},
+		{"../exampleFiles/encodings/latin1.java", "../exampleFiles/encodings/latin1_utf8.java", tk.Encoding{Name: tz.ENCODING_LATIN_1}},
+		{"../exampleFiles/unicode.java", "../exampleFiles/unicode.java", tk.Encoding{Name: tz.ENCODING_UTF8}},
+		// Mostly ASCII text with a few NUL bytes, all of them in even positions, is not UTF-16
+		{"../exampleFiles/encodings/ascii_nuls.java", "../exampleFiles/encodings/ascii_nuls.java", tk.Encoding{Name: tz.ENCODING_UTF8}},
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file.path)
+		assert.Nil(t, err)
+		expectedText, err := os.ReadFile(file.expected)
+		assert.Nil(t, err)
+
+		expected, _, err := javaTokenizer.GetJavaTokenizer().Tokenize(string(expectedText))
+		assert.Nil(t, err)
+		tokensScope, diagnostics, err := javaTokenizer.GetJavaTokenizer().TokenizeBytes(data)
+		assert.Nil(t, err, file.path)
+		assert.Equal(t, 0, len(diagnostics), file.path)
+		assert.Equal(t, file.encoding, tokensScope.GetEncoding(), file.path)
+		assert.Equal(t, describeLines(&expected), describeLines(&tokensScope), file.path)
+
+		// The byte-order mark is not part of the text, even in lossless mode
+		tokenizer := javaTokenizer.GetJavaTokenizer()
+		tokenizer.LosslessMode = true
+		losslessScope, _, err := tokenizer.TokenizeBytes(data)
+		assert.Nil(t, err, file.path)
+		validateLossless(t, string(expectedText), &losslessScope, file.path)
+
+		// Every other way of reading the text decodes it the same way
+		tokensScope, _, err = javaTokenizer.GetJavaTokenizer().Tokenize(string(data))
+		assert.Nil(t, err, file.path)
+		assert.Equal(t, file.encoding, tokensScope.GetEncoding(), file.path)
+		assert.Equal(t, describeLines(&expected), describeLines(&tokensScope), file.path)
+
+		stream, err := javaTokenizer.GetJavaTokenizer().TokenizeReader(iotest.OneByteReader(bytes.NewReader(data)))
+		assert.Nil(t, err, file.path)
+		assert.Equal(t, scopeToEvents(&tokensScope), collectEvents(t, stream), file.path)
+		assert.Equal(t, file.encoding, stream.Encoding(), file.path)
+
+		text, err := util.GetTextOfFile(file.path)
+		assert.Nil(t, err, file.path)
+		assert.Equal(t, string(expectedText), text, file.path)
+	}
+}
+
+func Test_Encoding_Stream(t *testing.T) {
+	// The text is longer than what the encoding is determined from, and characters (including surrogate pairs)
+	// are cut off between reads, so they are only decoded once all of their bytes have been read
+	text := strings.Repeat("String s = \"\U0001F601\u00e9\"; // \u00fc\n", 100)
+	expected, _, err := javaTokenizer.GetJavaTokenizer().Tokenize(text)
+	assert.Nil(t, err)
+
+	for _, encoding := range []tk.Encoding{{Name: tz.ENCODING_UTF16LE, ByteOrderMark: true}, {Name: tz.ENCODING_UTF16BE}, {Name: tz.ENCODING_UTF8}} {
+		data := []byte(text)
+		if encoding.Name != tz.ENCODING_UTF8 {
+			data = make([]byte, 0)
+			if encoding.ByteOrderMark {
+				data = append(data, 0xFF, 0xFE)
+			}
+			for _, unit := range utf16.Encode([]rune(text)) {
+				if encoding.Name == tz.ENCODING_UTF16BE {
+					data = append(data, byte(unit>>8), byte(unit))
+				} else {
+					data = append(data, byte(unit), byte(unit>>8))
+				}
+			}
+		}
+
+		stream, err := javaTokenizer.GetJavaTokenizer().TokenizeReader(iotest.OneByteReader(bytes.NewReader(data)))
+		assert.Nil(t, err, encoding.Name)
+		assert.Equal(t, tk.Encoding{}, stream.Encoding(), encoding.Name)
+		assert.Equal(t, scopeToEvents(&expected), collectEvents(t, stream), encoding.Name)
+		assert.Equal(t, encoding, stream.Encoding(), encoding.Name)
+	}
+
+	// Text which is not UTF-8 is decoded with the fallback encoding
+	tokenizer := javaTokenizer.GetJavaTokenizer()
+	windows1252 := tz.Windows1252Encoding()
+	tokenizer.FallbackEncoding = &windows1252
+	stream, err := tokenizer.TokenizeReader(strings.NewReader("int \x80 = 1;"))
+	assert.Nil(t, err)
+	events := collectEvents(t, stream)
+	assert.Equal(t, "\u20ac", events[1].Token.Text)
+	assert.Equal(t, tz.ENCODING_WINDOWS_1252, stream.Encoding().Name)
+}
+
+func Test_Encoding_ByteOrderMark(t *testing.T) {
+	text := "\xef\xbb\xbfpublic class A {}"
+
+	// Tokenize decodes text like TokenizeBytes does, so the byte-order mark is never part of a token
+	tokensScope, _, err := javaTokenizer.GetJavaTokenizer().Tokenize(text)
+	assert.Nil(t, err)
+	st1, _ := tokensScope.At(0)
+	assert.Equal(t, "public", st1.Text)
+	assert.Equal(t, tk.Position{Line: 1, Column: 1, Offset: 0}, st1.Start)
+	assert.Equal(t, tk.Encoding{Name: tz.ENCODING_UTF8, ByteOrderMark: true}, tokensScope.GetEncoding())
+
+	tokensScope, _, err = javaTokenizer.GetJavaTokenizer().TokenizeBytes([]byte(text))
+	assert.Nil(t, err)
+	st1, _ = tokensScope.At(0)
+	assert.Equal(t, "public", st1.Text)
+	assert.Equal(t, tk.Position{Line: 1, Column: 1, Offset: 0}, st1.Start)
+}
+
+func Test_Encoding_DecodeText(t *testing.T) {
+	// windows-1252 differs from ISO-8859-1 between 0x80 and 0x9F
+	data := []byte("\x93caf\xe9\x94 \x80\x81")
+	text, encoding := tz.DecodeText(data, nil)
+	assert.Equal(t, "\u0093café\u0094 \u0080\u0081", text)
+	assert.Equal(t, tk.Encoding{Name: tz.ENCODING_LATIN_1}, encoding)
+
+	windows1252 := tz.Windows1252Encoding()
+	text, encoding = tz.DecodeText(data, &windows1252)
+	assert.Equal(t, "“café” €\u0081", text)
+	assert.Equal(t, tk.Encoding{Name: tz.ENCODING_WINDOWS_1252}, encoding)
+
+	// A single byte encoding is only used for text which is not valid UTF-8
+	text, encoding = tz.DecodeText([]byte("café"), &windows1252)
+	assert.Equal(t, "café", text)
+	assert.Equal(t, tk.Encoding{Name: tz.ENCODING_UTF8}, encoding)
+
+	// Encodings may be configured
+	custom := tz.SingleByteEncoding{Name: "custom"}
+	custom.Characters[0xA4-0x80] = '€'
+	text, encoding = tz.DecodeText([]byte("\xa4\xa3"), &custom)
+	assert.Equal(t, "€£", text)
+	assert.Equal(t, "custom", encoding.Name)
+
+	// Broken UTF-16 is replaced rather than rejected
+	text, encoding = tz.DecodeText([]byte("\xfe\xff\xd8\x3d\x00a\x00"), nil)
+	assert.Equal(t, "\ufffda\ufffd", text)
+	assert.Equal(t, tk.Encoding{Name: tz.ENCODING_UTF16BE, ByteOrderMark: true}, encoding)
+
+	// Text without a byte-order mark is too short to be taken for UTF-16
+	text, encoding = tz.DecodeText([]byte("a\x00b\x00"), nil)
+	assert.Equal(t, "a\x00b\x00", text)
+	assert.Equal(t, tk.Encoding{Name: tz.ENCODING_UTF8}, encoding)
+
+	text, encoding = tz.DecodeText(nil, nil)
+	assert.Equal(t, "", text)
+	assert.Equal(t, tk.Encoding{Name: tz.ENCODING_UTF8}, encoding)
+}
+
+func Test_Encoding_FallbackEncoding(t *testing.T) {
+	tokenizer := javaTokenizer.GetJavaTokenizer()
+	windows1252 := tz.Windows1252Encoding()
+	tokenizer.FallbackEncoding = &windows1252
+	tokensScope, _, err := tokenizer.TokenizeBytes([]byte("int \x80 = 1;"))
+	assert.Nil(t, err)
+	assert.Equal(t, tz.ENCODING_WINDOWS_1252, tokensScope.GetEncoding().Name)
+	st1, _ := tokensScope.At(1)
+	assert.Equal(t, "€", st1.Text)
+	assert.Equal(t, tk.Position{Line: 1, Column: 6, Offset: 7}, st1.End)
+}
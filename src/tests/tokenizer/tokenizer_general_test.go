@@ -6,6 +6,7 @@ import (
 	"testing"
 	"tp/src/tests"
 	tz "tp/src/tokenizer"
+	tk "tp/src/tokenizer/tokens"
 	"tp/src/util"
 )
 
@@ -18,7 +19,7 @@ func Test_dullTokenizer(t *testing.T) {
 		assert.Fail(t, "No file found")
 	}
 
-	tokensScope, err := tokenizer.Tokenize(text)
+	tokensScope, _, err := tokenizer.Tokenize(text)
 	assert.Nil(t, err)
 
 	// FOR DEBUGGING
@@ -165,7 +166,7 @@ func Test_dullTokenizer_dotText(t *testing.T) {
 		assert.Fail(t, "No file found")
 	}
 
-	tokensScope, err := tokenizer.Tokenize(text)
+	tokensScope, _, err := tokenizer.Tokenize(text)
 	assert.Nil(t, err)
 
 	// FOR DEBUGGING
@@ -174,3 +175,73 @@ func Test_dullTokenizer_dotText(t *testing.T) {
 	//_ = util.CreateFileWithInfo("../../../output/dull_output_txt.json", jsonString)
 	assert.Equal(t, 7, tokensScope.Size())
 }
+
+func Test_dullTokenizer_WhitespaceSpans(t *testing.T) {
+	tokenizer := tz.CreateDullTokenizer()
+	tokenizer.ConfigureIgnores(false, false, false, false)
+
+	tokensScope, _, err := tokenizer.Tokenize("ab c\n  d")
+	assert.Nil(t, err)
+
+	assert.Equal(t, 6, tokensScope.Size())
+	for i := 0; i < tokensScope.Size(); i++ {
+		st1, _ := tokensScope.At(i)
+		switch i {
+		case 0:
+			tests.ValidateTokenSpan(t, st1, tk.Position{Line: 1, Column: 1, Offset: 0}, tk.Position{Line: 1, Column: 3, Offset: 2})
+		case 1:
+			tests.ValidateToken(t, st1, 1, 0, tz.RULENAME_OTHER, tz.SYMBOLIC_NAME_WHITESPACE, " ")
+			tests.ValidateTokenSpan(t, st1, tk.Position{Line: 1, Column: 3, Offset: 2}, tk.Position{Line: 1, Column: 4, Offset: 3})
+		case 3:
+			tests.ValidateToken(t, st1, 1, 0, tz.RULENAME_OTHER, tz.SYMBOLIC_NAME_NEWLINE, "\n")
+			tests.ValidateTokenSpan(t, st1, tk.Position{Line: 1, Column: 5, Offset: 4}, tk.Position{Line: 2, Column: 1, Offset: 5})
+		case 4:
+			tests.ValidateToken(t, st1, 2, 0, tz.RULENAME_OTHER, tz.SYMBOLIC_NAME_WHITESPACE, "  ")
+			tests.ValidateTokenSpan(t, st1, tk.Position{Line: 2, Column: 1, Offset: 5}, tk.Position{Line: 2, Column: 3, Offset: 7})
+		case 5:
+			tests.ValidateTokenSpan(t, st1, tk.Position{Line: 2, Column: 3, Offset: 7}, tk.Position{Line: 2, Column: 4, Offset: 8})
+		}
+	}
+}
+
+func Test_dullTokenizer_IgnoredTabs(t *testing.T) {
+	// Tabs between tokens are left out like spaces when whitespace is ignored
+	tokensScope, _, err := tz.CreateDullTokenizer().Tokenize("a\tb \t c")
+	assert.Nil(t, err)
+
+	assert.Equal(t, 3, tokensScope.Size())
+	for i := 0; i < tokensScope.Size(); i++ {
+		st1, _ := tokensScope.At(i)
+		switch i {
+		case 0:
+			tests.VerifyUnknownKeyword(t, st1, 1, 0, "a")
+		case 1:
+			tests.VerifyUnknownKeyword(t, st1, 1, 0, "b")
+			tests.ValidateTokenSpan(t, st1, tk.Position{Line: 1, Column: 3, Offset: 2}, tk.Position{Line: 1, Column: 4, Offset: 3})
+		case 2:
+			tests.VerifyUnknownKeyword(t, st1, 1, 0, "c")
+		}
+	}
+}
+
+func Test_dullTokenizer_NoNumberRules(t *testing.T) {
+	tokenizer := tz.CreateDullTokenizer()
+
+	tokensScope, _, err := tokenizer.Tokenize("42 3.5")
+	assert.Nil(t, err)
+
+	assert.Equal(t, 4, tokensScope.Size())
+	for i := 0; i < tokensScope.Size(); i++ {
+		st1, _ := tokensScope.At(i)
+		switch i {
+		case 0:
+			tests.VerifyUnknownKeyword(t, st1, 1, 0, "42")
+		case 1:
+			tests.VerifyUnknownKeyword(t, st1, 1, 0, "3")
+		case 2:
+			tests.VerifyUnknownSymbol(t, st1, 1, 0, ".")
+		case 3:
+			tests.VerifyUnknownKeyword(t, st1, 1, 0, "5")
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package tokenizer_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"testing/iotest"
+	pythonTokenizer "tp/src/instances/langs/python"
+	"tp/src/tests"
+	tz "tp/src/tokenizer"
+	tk "tp/src/tokenizer/tokens"
+)
+
+// getIndentingPythonTokenizer
+// Returns a tokenizer for a copy of the Python language which adds indent and dedent tokens
+func getIndentingPythonTokenizer() *tz.Tokenizer {
+	lang := pythonTokenizer.GetPythonLanguage().Copy()
+	rules := *lang.IndentationRules
+	rules.EmitTokens = true
+	lang.ConfigureIndentation(rules)
+	return tz.NewTokenizer(lang)
+}
+
+func Test_Indentation_Tokens(t *testing.T) {
+	text := "def f(a,\n        b):\n  if a:\n  \tx = [1,\n  2]\n# comment\n\n  \ty = a + \\\n  b\n  return x\nz"
+	tokensScope, diagnostics, err := getIndentingPythonTokenizer().Tokenize(text)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(diagnostics))
+	// Lines within brackets, after a continuation, or with only a comment do not change the indentation,
+	// and a tab indents a line to the next multiple of 8 columns
+	assert.Equal(t, "def f ( a , b ) : [INDENT if a : [INDENT x = [ 1 , 2 ] # comment\n y = a + \\ b] DEDENT return x] DEDENT z",
+		describeScope(&tokensScope))
+
+	st1, _ := tokensScope.At(8)
+	scope := st1.GetScopeToken()
+	st1, _ = scope.At(0)
+	tests.ValidateToken(t, st1, 3, 0, tz.RULENAME_OTHER, tz.SYMBOLIC_NAME_INDENT, "")
+	tests.ValidateTokenSpan(t, st1, tk.Position{Line: 3, Column: 3, Offset: 23}, tk.Position{Line: 3, Column: 3, Offset: 23})
+
+	// Blocks still open at the end of the text are ended there
+	tokensScope, diagnostics, err = getIndentingPythonTokenizer().Tokenize("if a:\n    if b:\n        c\n")
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(diagnostics))
+	assert.Equal(t, "if a : [INDENT if b : [INDENT c]] DEDENT DEDENT", describeScope(&tokensScope))
+	st1, _ = tokensScope.At(4)
+	tests.ValidateTokenSpan(t, st1, tk.Position{Line: 4, Column: 1, Offset: 26}, tk.Position{Line: 4, Column: 1, Offset: 26})
+
+	// The tokens are only added when they are asked for
+	tokensScope, _, err = pythonTokenizer.GetPythonTokenizer().Tokenize("if a:\n    b\nc")
+	assert.Nil(t, err)
+	assert.Equal(t, "if a : [b] c", describeScope(&tokensScope))
+}
+
+func Test_Indentation_Diagnostics(t *testing.T) {
+	tokensScope, diagnostics, err := getIndentingPythonTokenizer().Tokenize("if a:\n    b\n  c\nd")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(diagnostics))
+	tests.ValidateDiagnostic(t, diagnostics[0], tz.SEVERITY_ERROR, tz.DIAGNOSTIC_INCONSISTENT_DEDENT,
+		tk.Position{Line: 3, Column: 1, Offset: 12}, tk.Position{Line: 3, Column: 3, Offset: 14})
+	assert.Equal(t, "if a : [INDENT b DEDENT c] d", describeScope(&tokensScope))
+
+	// A tab and 8 spaces are the same width, but not for every tab size
+	_, diagnostics, err = getIndentingPythonTokenizer().Tokenize("if a:\n\tb\n        c\n")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(diagnostics))
+	tests.ValidateDiagnostic(t, diagnostics[0], tz.SEVERITY_WARNING, tz.DIAGNOSTIC_INCONSISTENT_TABS,
+		tk.Position{Line: 3, Column: 1, Offset: 9}, tk.Position{Line: 3, Column: 9, Offset: 17})
+}
+
+func Test_Indentation_LosslessAndStream(t *testing.T) {
+	texts := []string{
+		"def f(a,\n        b):\n  if a:\n  \tx = [1,\n  2]\n# comment\n\n  \ty = a + \\\n  b\n  return x\nz",
+		"  x\nif a:\n    if b:\n        c\n",
+		"if a:\n    b\n  c\n",
+	}
+	for _, text := range texts {
+		tokenizer := getIndentingPythonTokenizer()
+		tokenizer.LosslessMode = true
+		tokensScope, _, err := tokenizer.Tokenize(text)
+		assert.Nil(t, err)
+		assert.Equal(t, text, tokensScope.Source())
+
+		expected, _, err := getIndentingPythonTokenizer().Tokenize(text)
+		assert.Nil(t, err)
+		stream, err := getIndentingPythonTokenizer().TokenizeReader(iotest.OneByteReader(strings.NewReader(text)))
+		assert.Nil(t, err)
+		assert.Equal(t, scopeToEvents(&expected), collectEvents(t, stream))
+	}
+}
+
+func Test_Indentation_Definition(t *testing.T) {
+	definition, err := tz.ParseLanguageDefinition([]byte(`
+name: blocks
+symbols: [[":", Colon], ["(", LParen], [")", RParen], ["\\", Backslash]]
+comments: [{start: "--"}]
+indentationScopes: {opener: ":"}
+indentation:
+  emitTokens: true
+  brackets: [["(", ")"]]
+  lineContinuation: "\\"
+  tabSize: 4
+`), tz.DEFINITION_FORMAT_YAML)
+	assert.Nil(t, err)
+	lang, err := definition.BuildLanguage()
+	assert.Nil(t, err)
+
+	tokensScope, diagnostics, err := tz.NewTokenizer(lang).Tokenize("a:\n\tb (\nc)\n-- c\n\td \\\ne\nf")
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(diagnostics))
+	assert.Equal(t, "a : [INDENT b ( c ) -- c\n d \\ e] DEDENT f", describeScope(&tokensScope))
+
+	_, err = tz.ParseLanguageDefinition([]byte(`{"name": "x", "indentation": {"brackets": [["(", ""]]}}`), tz.DEFINITION_FORMAT_JSON)
+	assert.NotNil(t, err)
+	_, err = tz.ParseLanguageDefinition([]byte(`{"name": "x", "indentation": {"tabSize": -1}}`), tz.DEFINITION_FORMAT_JSON)
+	assert.NotNil(t, err)
+}
+
+func Test_Indentation_NonPositiveTabWidth(t *testing.T) {
+	// The tab level of a line is counted in multiples of the tab width, so a tab width which is not positive is rejected
+	for _, tabWidth := range []int{0, -4} {
+		lang := pythonTokenizer.GetPythonLanguage().Copy()
+		lang.NumOfSpacesEquallyTab = tabWidth
+		assert.NotNil(t, lang.IsConfigured())
+
+		_, _, err := tz.NewTokenizer(lang).Tokenize("if a:\n    b")
+		assert.NotNil(t, err)
+	}
+}
@@ -0,0 +1,138 @@
+package tokenizer_test
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"testing/iotest"
+	javaTokenizer "tp/src/instances/langs/java"
+	pythonTokenizer "tp/src/instances/langs/python"
+	tz "tp/src/tokenizer"
+	tk "tp/src/tokenizer/tokens"
+)
+
+// validateLimitError
+// Makes sure the error is a LimitError for the provided limit which stopped tokenizing at the position
+func validateLimitError(t *testing.T, err error, limit string, max int, position tk.Position) {
+	var limitError *tz.LimitError
+	if assert.True(t, errors.As(err, &limitError), "%v", err) {
+		assert.Equal(t, tz.LimitError{Limit: limit, Max: max, Position: position}, *limitError)
+	}
+}
+
+func Test_Limits_Context(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	tokensScope, _, err := tz.CreateDullTokenizer().TokenizeContext(ctx, "a b c")
+	assert.True(t, errors.Is(err, context.Canceled))
+	assert.Equal(t, 0, tokensScope.Size())
+
+	// A context cancelled while tokenizing stops it soon after
+	text := strings.Repeat("a ", 100000)
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	lang := tz.CreateDullLanguage().Copy()
+	lang.ConfigureComment(func(tkzr *tz.Tokenizer) bool {
+		if tkzr.Index() == 1000 {
+			cancel()
+		}
+		return false
+	}, func(tkzr *tz.Tokenizer) bool { return true })
+	tokensScope, _, err = tz.NewTokenizer(lang).TokenizeContext(ctx, text)
+	assert.True(t, errors.Is(err, context.Canceled))
+	assert.Greater(t, tokensScope.Size(), 0)
+	assert.Less(t, tokensScope.Size(), 1000)
+	assert.Equal(t, tokensScope.Size(), tokensScope.GetEnd().Offset/2)
+
+	// Long tokens are stopped within as well
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	stream, err := pythonTokenizer.GetPythonTokenizer().TokenizeReaderContext(ctx, strings.NewReader("x = '''"+text))
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(collectEventsIgnoringError(stream)))
+	assert.True(t, errors.Is(stream.Err(), context.Canceled))
+}
+
+func Test_Limits_MaxInputBytes(t *testing.T) {
+	text := "a { bé c } d"
+	tokenizer := javaTokenizer.GetJavaTokenizer()
+	tokenizer.Limits.MaxInputBytes = 6
+	tokensScope, diagnostics, err := tokenizer.Tokenize(text)
+	validateLimitError(t, err, tz.LIMIT_INPUT_BYTES, 6, tk.Position{Line: 1, Column: 6, Offset: 5})
+	// The text is cut before the character the limit falls within, and the scopes open there are not reported
+	assert.Equal(t, 0, len(diagnostics))
+	assert.Equal(t, "a { [b]", describeScope(&tokensScope))
+	assert.Equal(t, tk.Position{Line: 1, Column: 6, Offset: 5}, tokensScope.GetEnd())
+
+	streamTokenizer := javaTokenizer.GetJavaTokenizer()
+	streamTokenizer.Limits.MaxInputBytes = 6
+	stream, err := streamTokenizer.TokenizeReader(iotest.OneByteReader(strings.NewReader(text)))
+	assert.Nil(t, err)
+	assert.Equal(t, scopeToEvents(&tokensScope), collectEventsIgnoringError(stream))
+	validateLimitError(t, stream.Err(), tz.LIMIT_INPUT_BYTES, 6, tk.Position{Line: 1, Column: 6, Offset: 5})
+
+	// Text which fits is tokenized as usual
+	tokenizer.Limits.MaxInputBytes = len(text)
+	_, _, err = tokenizer.Tokenize(text)
+	assert.Nil(t, err)
+}
+
+func Test_Limits_MaxTokens(t *testing.T) {
+	tokenizer := tz.CreateDullTokenizer()
+	tokenizer.Limits.MaxTokens = 2
+	tokensScope, _, err := tokenizer.Tokenize("a b c d")
+	validateLimitError(t, err, tz.LIMIT_TOKENS, 2, tk.Position{Line: 1, Column: 5, Offset: 4})
+	assert.Equal(t, "a b", describeScope(&tokensScope))
+
+	// In lossless mode, whitespace is counted as well
+	tokenizer.LosslessMode = true
+	tokensScope, _, err = tokenizer.Tokenize("a b c d")
+	validateLimitError(t, err, tz.LIMIT_TOKENS, 2, tk.Position{Line: 1, Column: 3, Offset: 2})
+	assert.Equal(t, "a ", tokensScope.Source())
+}
+
+func Test_Limits_MaxScopeDepth(t *testing.T) {
+	tokenizer := javaTokenizer.GetJavaTokenizer()
+	tokenizer.Limits.MaxScopeDepth = 2
+	tokensScope, diagnostics, err := tokenizer.Tokenize("class A { void f() { if (x) { y; } } }")
+	validateLimitError(t, err, tz.LIMIT_SCOPE_DEPTH, 2, tk.Position{Line: 1, Column: 29, Offset: 28})
+	assert.Equal(t, 0, len(diagnostics))
+	assert.Equal(t, "class A { [void f ( ) { [if ( x ) {]]", describeScope(&tokensScope))
+	st1, _ := tokensScope.At(3)
+	assert.Equal(t, tk.Position{Line: 1, Column: 29, Offset: 28}, st1.GetScopeToken().GetEnd())
+}
+
+func Test_Limits_MaxTokenLength(t *testing.T) {
+	// A string which is never ended is stopped once it is too long, rather than taking up the rest of the text
+	text := "x = '''" + strings.Repeat("a\n", 100000)
+	tokenizer := pythonTokenizer.GetPythonTokenizer()
+	tokenizer.Limits.MaxTokenLength = 100
+	tokensScope, diagnostics, err := tokenizer.Tokenize(text)
+	validateLimitError(t, err, tz.LIMIT_TOKEN_LENGTH, 100, tk.Position{Line: 1, Column: 5, Offset: 4})
+	assert.Equal(t, 0, len(diagnostics))
+	assert.Equal(t, "x =", describeScope(&tokensScope))
+
+	// As are keywords
+	tokenizer = tz.CreateDullTokenizer()
+	tokenizer.Limits.MaxTokenLength = 3
+	tokensScope, _, err = tokenizer.Tokenize("abc defg")
+	validateLimitError(t, err, tz.LIMIT_TOKEN_LENGTH, 3, tk.Position{Line: 1, Column: 5, Offset: 4})
+	assert.Equal(t, "abc", describeScope(&tokensScope))
+}
+
+func Test_Limits_EscapedStrings(t *testing.T) {
+	// Every character of a string checks whether it is escaped, which does not look back over every escape before it
+	text := "x = '" + strings.Repeat("\\", 200000) + "' + '{{{{'"
+	tokensScope, diagnostics, err := pythonTokenizer.GetPythonTokenizer().Tokenize(text)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(diagnostics))
+	assert.Equal(t, 5, tokensScope.Size())
+
+	text = "x = f'" + strings.Repeat("{{", 100000) + "{y}'"
+	tokensScope, diagnostics, err = pythonTokenizer.GetPythonTokenizer().Tokenize(text)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(diagnostics))
+	assert.Equal(t, "x = f'"+strings.Repeat("{{", 100000)+" { [y] } '", describeScope(&tokensScope))
+}
@@ -0,0 +1,152 @@
+package tokenizer_test
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"testing/iotest"
+	javaTokenizer "tp/src/instances/langs/java"
+	pythonTokenizer "tp/src/instances/langs/python"
+	"tp/src/tests"
+	tz "tp/src/tokenizer"
+	tk "tp/src/tokenizer/tokens"
+	"tp/src/util"
+)
+
+var lineEndingExampleFiles = []struct {
+	path      string
+	tokenizer func() *tz.Tokenizer
+}{
+	{"../exampleFiles/lineEndings/hello_%s.java", javaTokenizer.GetJavaTokenizer},
+	{"../exampleFiles/lineEndings/hello_%s.py", pythonTokenizer.GetPythonTokenizer},
+}
+
+// describeLines
+// Returns the text, kind and span (without offsets) of every token, which are the same whatever the line endings are
+func describeLines(scope *tk.ScopeObj) []string {
+	lines := make([]string, 0)
+	for _, tkn := range scope.ConvertToArray() {
+		lines = append(lines, fmt.Sprintf("%d:%d-%d:%d %d %q", tkn.Start.Line, tkn.Start.Column, tkn.End.Line, tkn.End.Column, tkn.Kind, tkn.Text))
+	}
+	return lines
+}
+
+func Test_LineEndings_ExampleFiles(t *testing.T) {
+	for _, example := range lineEndingExampleFiles {
+		expectedText, err := util.GetTextOfFile(fmt.Sprintf(example.path, "lf"))
+		assert.Nil(t, err)
+		expected, _, err := example.tokenizer().Tokenize(expectedText)
+		assert.Nil(t, err)
+
+		for _, ending := range []string{"crlf", "cr"} {
+			path := fmt.Sprintf(example.path, ending)
+			text, err := util.GetTextOfFile(path)
+			assert.Nil(t, err)
+			assert.Contains(t, text, "\r", path)
+
+			tokensScope, diagnostics, err := example.tokenizer().Tokenize(text)
+			assert.Nil(t, err, path)
+			assert.Equal(t, 0, len(diagnostics), path)
+			assert.Equal(t, describeLines(&expected), describeLines(&tokensScope), path)
+
+			// The original line endings are kept in lossless mode
+			tokenizer := example.tokenizer()
+			tokenizer.LosslessMode = true
+			losslessScope, _, err := tokenizer.Tokenize(text)
+			assert.Nil(t, err, path)
+			validateLossless(t, text, &losslessScope, path)
+
+			stream, err := example.tokenizer().TokenizeReader(iotest.OneByteReader(strings.NewReader(text)))
+			assert.Nil(t, err, path)
+			assert.Equal(t, scopeToEvents(&tokensScope), collectEvents(t, stream), path)
+		}
+	}
+}
+
+func Test_LineEndings_LineComment(t *testing.T) {
+	for _, lineBreak := range []string{"\n", "\r\n", "\r"} {
+		text := "a // b" + lineBreak + "c"
+		tokensScope, _, err := javaTokenizer.GetJavaTokenizer().Tokenize(text)
+		assert.Nil(t, err)
+		assert.Equal(t, 3, tokensScope.Size())
+
+		// The comment ends with its line break, which is always a newline within its text
+		st1, _ := tokensScope.At(1)
+		tests.ValidateToken(t, st1, 1, 0, tz.RULENAME_OTHER, tz.SYMBOLIC_NAME_COMMENT, "// b\n")
+		tests.ValidateTokenSpan(t, st1, tk.Position{Line: 1, Column: 3, Offset: 2}, tk.Position{Line: 2, Column: 1, Offset: 6 + len(lineBreak)})
+		st1, _ = tokensScope.At(2)
+		tests.ValidateTokenSpan(t, st1, tk.Position{Line: 2, Column: 1, Offset: 6 + len(lineBreak)}, tk.Position{Line: 2, Column: 2, Offset: 7 + len(lineBreak)})
+	}
+
+	// A CRLF which is split between two reads is still one line break
+	text := "a // b\r\n\r\nc\rd"
+	expected, _, err := javaTokenizer.GetJavaTokenizer().Tokenize(text)
+	assert.Nil(t, err)
+	st1, _ := expected.At(3)
+	tests.ValidateTokenSpan(t, st1, tk.Position{Line: 4, Column: 1, Offset: 12}, tk.Position{Line: 4, Column: 2, Offset: 13})
+	stream, err := javaTokenizer.GetJavaTokenizer().TokenizeReader(iotest.OneByteReader(strings.NewReader(text)))
+	assert.Nil(t, err)
+	assert.Equal(t, scopeToEvents(&expected), collectEvents(t, stream))
+}
+
+func Test_LineEndings_Newlines(t *testing.T) {
+	tokenizer := tz.CreateDullTokenizer()
+	tokenizer.IgnoreNewLines = false
+	tokensScope, _, err := tokenizer.Tokenize("a\r\nb\rc\n\r\nd")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"1:1-1:2 " + fmt.Sprint(tk.KIND_IDENTIFIER) + ` "a"`,
+		"1:2-2:1 " + fmt.Sprint(tk.KIND_NEWLINE) + ` "\n"`,
+		"2:1-2:2 " + fmt.Sprint(tk.KIND_IDENTIFIER) + ` "b"`,
+		"2:2-3:1 " + fmt.Sprint(tk.KIND_NEWLINE) + ` "\n"`,
+		"3:1-3:2 " + fmt.Sprint(tk.KIND_IDENTIFIER) + ` "c"`,
+		"3:2-4:1 " + fmt.Sprint(tk.KIND_NEWLINE) + ` "\n"`,
+		"4:1-5:1 " + fmt.Sprint(tk.KIND_NEWLINE) + ` "\n"`,
+		"5:1-5:2 " + fmt.Sprint(tk.KIND_IDENTIFIER) + ` "d"`,
+	}, describeLines(&tokensScope))
+}
+
+func Test_LineEndings_UnicodeLineBreaks(t *testing.T) {
+	text := "a\u2028b\u2029c"
+	tokensScope, _, err := tz.CreateDullTokenizer().Tokenize(text)
+	assert.Nil(t, err)
+	st1, _ := tokensScope.At(tokensScope.Size() - 1)
+	assert.Equal(t, 1, st1.Start.Line)
+
+	// The separators only end lines when the language asks for it
+	lang := tz.CreateDullLanguage().Copy()
+	lang.UnicodeLineBreaks = true
+	tokensScope, _, err = tz.NewTokenizer(lang).Tokenize(text)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, tokensScope.Size())
+	st1, _ = tokensScope.At(2)
+	tests.ValidateTokenSpan(t, st1, tk.Position{Line: 3, Column: 1, Offset: 8}, tk.Position{Line: 3, Column: 2, Offset: 9})
+
+	tokenizer := tz.NewTokenizer(lang)
+	tokenizer.LosslessMode = true
+	losslessScope, _, err := tokenizer.Tokenize(text)
+	assert.Nil(t, err)
+	validateLossless(t, text, &losslessScope, text)
+	st1, _ = losslessScope.At(1)
+	assert.Equal(t, tk.KIND_NEWLINE, st1.Kind)
+
+	// Token rules match up to any line break
+	assert.Nil(t, lang.ConfigureTokenRule(tz.TokenRule{Name: "note", Pattern: `#.*`}))
+	for _, text := range []string{"#a\u2028b", "#a\rb", "#a\r\nb"} {
+		tokensScope, _, err = tz.NewTokenizer(lang).Tokenize(text)
+		assert.Nil(t, err)
+		st1, _ = tokensScope.At(0)
+		assert.Equal(t, "#a", st1.Text, text)
+	}
+
+	definition, err := tz.ParseLanguageDefinition([]byte(`{"name": "x", "comments": [{"start": "--"}], "options": {"unicodeLineBreaks": true}}`), tz.DEFINITION_FORMAT_JSON)
+	assert.Nil(t, err)
+	lang, err = definition.BuildLanguage()
+	assert.Nil(t, err)
+	assert.True(t, lang.UnicodeLineBreaks)
+	tokensScope, _, err = tz.NewTokenizer(lang).Tokenize("a -- b\u2028c")
+	assert.Nil(t, err)
+	st1, _ = tokensScope.At(2)
+	tests.ValidateTokenSpan(t, st1, tk.Position{Line: 2, Column: 1, Offset: 9}, tk.Position{Line: 2, Column: 2, Offset: 10})
+}
@@ -0,0 +1,195 @@
+package tokenizer_test
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"strings"
+	"testing"
+	"testing/iotest"
+	javaTokenizer "tp/src/instances/langs/java"
+	pythonTokenizer "tp/src/instances/langs/python"
+	"tp/src/tests"
+	tz "tp/src/tokenizer"
+	tk "tp/src/tokenizer/tokens"
+	"tp/src/util"
+)
+
+var losslessExampleFiles = []string{
+	"../exampleFiles/charAndNums.java",
+	"../exampleFiles/file.java",
+	"../exampleFiles/hello.java",
+	"../exampleFiles/hello.py",
+	"../exampleFiles/unicode.java",
+	"../exampleFiles/unicode.py",
+	"../exampleFiles/words.txt",
+	"../exampleFiles/lineEndings/hello_cr.java",
+	"../exampleFiles/lineEndings/hello_cr.py",
+	"../exampleFiles/lineEndings/hello_crlf.java",
+	"../exampleFiles/lineEndings/hello_crlf.py",
+	"../exampleFiles/lineEndings/hello_lf.java",
+	"../exampleFiles/lineEndings/hello_lf.py",
+	"../exampleFiles/encodings/latin1.java",
+	"../exampleFiles/encodings/latin1_utf8.java",
+	"../exampleFiles/encodings/unicode_utf16be.java",
+	"../exampleFiles/encodings/unicode_utf16le.java",
+	"../exampleFiles/encodings/unicode_utf16le_nobom.java",
+	"../exampleFiles/encodings/unicode_utf8bom.java",
+}
+
+var losslessTokenizers = []struct {
+	name      string
+	tokenizer func() *tz.Tokenizer
+}{
+	{"java", javaTokenizer.GetJavaTokenizer},
+	{"python", pythonTokenizer.GetPythonTokenizer},
+	{"dull", tz.CreateDullTokenizer},
+}
+
+// getLosslessTokenizer
+// Creates a tokenizer in lossless mode
+func getLosslessTokenizer(create func() *tz.Tokenizer, recovery bool) *tz.Tokenizer {
+	tokenizer := create()
+	tokenizer.LosslessMode = true
+	tokenizer.RecoveryMode = recovery
+	return tokenizer
+}
+
+// validateLossless
+// Makes sure the tokens cover every byte of the text exactly once, in order
+func validateLossless(t *testing.T, text string, tokensScope *tk.ScopeObj, context string) {
+	assert.Equal(t, text, tokensScope.Source(), context)
+
+	offset := 0
+	for _, tkn := range tokensScope.ConvertToArray() {
+		if !assert.Equal(t, offset, tkn.Start.Offset, "%s: %s", context, tkn.ToString()) {
+			return
+		}
+		assert.NotEqual(t, "", tkn.Text, "%s: %s", context, tkn.ToString())
+		assert.Equal(t, text[tkn.Start.Offset:tkn.End.Offset], tkn.Text, "%s: %s", context, tkn.ToString())
+		offset = tkn.End.Offset
+	}
+	assert.Equal(t, len(text), offset, context)
+}
+
+func Test_Lossless_ExampleFiles(t *testing.T) {
+	for _, path := range losslessExampleFiles {
+		text, err := util.GetTextOfFile(path)
+		assert.Nil(t, err)
+
+		for _, language := range losslessTokenizers {
+			for _, recovery := range []bool{false, true} {
+				context := fmt.Sprintf("%s with %s (recovery: %t)", path, language.name, recovery)
+				tokensScope, _, err := getLosslessTokenizer(language.tokenizer, recovery).Tokenize(text)
+				assert.Nil(t, err, context)
+				// A byte-order mark is recorded in the encoding rather than kept in a token
+				validateLossless(t, strings.TrimPrefix(text, "\ufeff"), &tokensScope, context)
+			}
+		}
+	}
+}
+
+func Test_Lossless_DecodedFiles(t *testing.T) {
+	// Files which are decoded are kept exactly as they were decoded, without their byte-order marks
+	for _, path := range losslessExampleFiles {
+		data, err := os.ReadFile(path)
+		assert.Nil(t, err)
+		text, _ := tz.DecodeText(data, nil)
+
+		for _, language := range losslessTokenizers {
+			context := fmt.Sprintf("%s decoded with %s", path, language.name)
+			tokensScope, _, err := getLosslessTokenizer(language.tokenizer, false).TokenizeBytes(data)
+			assert.Nil(t, err, context)
+			validateLossless(t, text, &tokensScope, context)
+		}
+	}
+}
+
+func Test_Lossless_TrickyText(t *testing.T) {
+	texts := []string{
+		"",
+		" ",
+		"\n",
+		"a",
+		"int x = 1;   \n\n\t\tint y = 2;\t\n   ",
+		"class A {\r\n\tint x; // comment\r\n}\r\n",
+		"class A {\r\tint x;\r}",
+		"String s = \"abc\nint x = 1;",
+		"/* never ended\n int x;",
+		"} } int x; {",
+		"char c = 'a'; String s = \"\\\"q\\\"\";\n\n",
+		"def f(a):\n    if a:\n\n        return 1\n    return 2\n\n\n",
+		"x = \"\"\"doc\n  string\"\"\"  # comment\n\ty = '''\n",
+		"\ufeffint \u00e9 = 1;\u00a0\n",
+	}
+
+	for _, text := range texts {
+		for _, language := range losslessTokenizers {
+			for _, recovery := range []bool{false, true} {
+				context := fmt.Sprintf("%q with %s (recovery: %t)", text, language.name, recovery)
+				tokensScope, _, err := getLosslessTokenizer(language.tokenizer, recovery).Tokenize(text)
+				assert.Nil(t, err, context)
+				// A byte-order mark is recorded in the encoding rather than kept in a token
+				validateLossless(t, strings.TrimPrefix(text, "\ufeff"), &tokensScope, context)
+			}
+		}
+	}
+}
+
+func Test_Lossless_Trivia(t *testing.T) {
+	tokensScope, _, err := getLosslessTokenizer(tz.CreateDullTokenizer, false).Tokenize("a  \r\n    b\n")
+	assert.Nil(t, err)
+	assert.Equal(t, 6, tokensScope.Size())
+	for i := 0; i < tokensScope.Size(); i++ {
+		st1, _ := tokensScope.At(i)
+		switch i {
+		case 0:
+			tests.VerifyUnknownKeyword(t, st1, 1, 0, "a")
+		case 1:
+			tests.ValidateToken(t, st1, 1, 0, tz.RULENAME_OTHER, tz.SYMBOLIC_NAME_WHITESPACE, "  ")
+			tests.ValidateTokenSpan(t, st1, tk.Position{Line: 1, Column: 2, Offset: 1}, tk.Position{Line: 1, Column: 4, Offset: 3})
+		case 2:
+			tests.ValidateToken(t, st1, 1, 0, tz.RULENAME_OTHER, tz.SYMBOLIC_NAME_NEWLINE, "\r\n")
+			tests.ValidateTokenSpan(t, st1, tk.Position{Line: 1, Column: 4, Offset: 3}, tk.Position{Line: 2, Column: 1, Offset: 5})
+		case 3:
+			tests.ValidateToken(t, st1, 2, 1, tz.RULENAME_OTHER, tz.SYMBOLIC_NAME_WHITESPACE, "    ")
+		case 4:
+			tests.VerifyUnknownKeyword(t, st1, 2, 1, "b")
+		case 5:
+			tests.ValidateToken(t, st1, 2, 1, tz.RULENAME_OTHER, tz.SYMBOLIC_NAME_NEWLINE, "\n")
+		}
+	}
+}
+
+func Test_Lossless_IncludesStringsAndComments(t *testing.T) {
+	tokenizer := javaTokenizer.GetJavaTokenizer()
+	tokenizer.LosslessMode = true
+	tokenizer.Language = tokenizer.Language.Copy()
+	tokenizer.IncludeStrings = false
+	tokenizer.IncludeComments = false
+
+	text := "s = \"a b\"; // c\n"
+	tokensScope, _, err := tokenizer.Tokenize(text)
+	assert.Nil(t, err)
+	validateLossless(t, text, &tokensScope, text)
+}
+
+func Test_Lossless_Stream(t *testing.T) {
+	for _, path := range losslessExampleFiles {
+		text, err := util.GetTextOfFile(path)
+		assert.Nil(t, err)
+
+		for _, language := range losslessTokenizers {
+			stream, err := getLosslessTokenizer(language.tokenizer, false).TokenizeReader(iotest.OneByteReader(strings.NewReader(text)))
+			assert.Nil(t, err)
+
+			var builder strings.Builder
+			for _, event := range collectEvents(t, stream) {
+				if event.Type == tz.EVENT_TOKEN {
+					builder.WriteString(event.Token.Text)
+				}
+			}
+			assert.Equal(t, text, builder.String(), "%s with %s", path, language.name)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package tokenizer_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"tp/src/tests"
+	tz "tp/src/tokenizer"
+	tk "tp/src/tokenizer/tokens"
+)
+
+const templateDefinitionYaml = `
+name: template
+keywords: [let]
+symbols:
+  - ["+", Plus]
+  - [";", SemiColon]
+  - ["=", Equal]
+  - ["$", Dollar]
+strings:
+  - {start: '"', end: '"', escape: '\'}
+  - start: "` + "`" + `"
+    end: "` + "`" + `"
+    escape: '\'
+    multiline: true
+    interpolation: {start: "${", end: "}"}
+scopes:
+  - {start: "{", end: "}"}
+  - {start: "(", end: ")"}
+`
+
+func getTemplateTokenizer(t *testing.T) *tz.Tokenizer {
+	definition, err := tz.ParseLanguageDefinition([]byte(templateDefinitionYaml), tz.DEFINITION_FORMAT_YAML)
+	assert.Nil(t, err)
+	lang, err := definition.BuildLanguage()
+	assert.Nil(t, err)
+	return tz.NewTokenizer(lang)
+}
+
+// describeScope
+// Returns the text of every token in the scope separated by spaces, with nested scopes placed in brackets.
+// Indent and dedent tokens, which have no text, are written as their symbolic names.
+func describeScope(scope *tk.ScopeObj) string {
+	parts := make([]string, 0, scope.Size())
+	for i := 0; i < scope.Size(); i++ {
+		tkn, _ := scope.At(i)
+		if tkn.ValidScopeToken() {
+			parts = append(parts, "["+describeScope(tkn.GetScopeToken())+"]")
+		} else if tkn.Kind == tk.KIND_INDENT || tkn.Kind == tk.KIND_DEDENT {
+			parts = append(parts, tkn.SymbolicName)
+		} else {
+			parts = append(parts, tkn.Text)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+func Test_Modes_Interpolation(t *testing.T) {
+	tokensScope, diagnostics, err := getTemplateTokenizer(t).Tokenize("let s = `a ${b + \"}\"} c`;")
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(diagnostics))
+	assert.Equal(t, "let s = `a  ${ [b + \"}\"] }  c` ;", describeScope(&tokensScope))
+
+	st1, _ := tokensScope.At(3)
+	tests.ValidateToken(t, st1, 1, 0, tz.RULENAME_OTHER, tz.SYMBOLIC_NAME_STRING, "`a ")
+	tests.ValidateTokenSpan(t, st1, tk.Position{Line: 1, Column: 9, Offset: 8}, tk.Position{Line: 1, Column: 12, Offset: 11})
+	st1, _ = tokensScope.At(4)
+	tests.VerifyUnknownSymbol(t, st1, 1, 0, "${")
+	st1, _ = tokensScope.At(6)
+	tests.VerifyUnknownSymbol(t, st1, 1, 0, "}")
+	tests.ValidateTokenSpan(t, st1, tk.Position{Line: 1, Column: 21, Offset: 20}, tk.Position{Line: 1, Column: 22, Offset: 21})
+	st1, _ = tokensScope.At(7)
+	tests.ValidateToken(t, st1, 1, 0, tz.RULENAME_OTHER, tz.SYMBOLIC_NAME_STRING, " c`")
+	tests.ValidateTokenSpan(t, st1, tk.Position{Line: 1, Column: 22, Offset: 21}, tk.Position{Line: 1, Column: 25, Offset: 24})
+}
+
+func Test_Modes_NestedInterpolation(t *testing.T) {
+	tokenizer := getTemplateTokenizer(t)
+
+	// Scopes within an expression are closed before the expression ends, and strings within it may have their own expressions
+	tokensScope, diagnostics, err := tokenizer.Tokenize("`${ {x} }${`${y}`}$${z}\\${w}`")
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(diagnostics))
+	assert.Equal(t, "` ${ [{ [x] }] } ${ [` ${ [y] } `] } $ ${ [z] } \\${w}`", describeScope(&tokensScope))
+
+	// A string which ends within an expression does not end the string the expression is in
+	tokensScope, diagnostics, err = tokenizer.Tokenize("`a${\"`\"}b`")
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(diagnostics))
+	assert.Equal(t, "`a ${ [\"`\"] } b`", describeScope(&tokensScope))
+}
+
+func Test_Modes_IncompleteInterpolation(t *testing.T) {
+	tokensScope, diagnostics, err := getTemplateTokenizer(t).Tokenize("`a ${b")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(diagnostics))
+	assert.Equal(t, tz.DIAGNOSTIC_UNCLOSED_SCOPE, diagnostics[0].Code)
+	assert.Equal(t, "`a  ${ [b]", describeScope(&tokensScope))
+}
+
+func Test_Modes_LosslessAndStream(t *testing.T) {
+	texts := []string{
+		"let s = `a ${b + \"}\"} c`;\nlet t = `${ {x} }${`${y}`}`;",
+		"`line ${one}\nline ${ (two) }\n`",
+		"`a ${b",
+	}
+	for _, text := range texts {
+		tokenizer := getTemplateTokenizer(t)
+		tokenizer.LosslessMode = true
+		tokensScope, _, err := tokenizer.Tokenize(text)
+		assert.Nil(t, err)
+		validateLossless(t, text, &tokensScope, text)
+
+		expected, _, err := getTemplateTokenizer(t).Tokenize(text)
+		assert.Nil(t, err)
+		stream, err := getTemplateTokenizer(t).TokenizeReader(iotest.OneByteReader(strings.NewReader(text)))
+		assert.Nil(t, err)
+		assert.Equal(t, scopeToEvents(&expected), collectEvents(t, stream))
+	}
+}
+
+func Test_Modes_PushAndPop(t *testing.T) {
+	// A language which switches to a mode where "<" and ">" are scopes after "#", until the end of the line
+	lang := tz.CreateDullTokenizer().Language.Copy()
+	lang.ConfigureMode("directive", tz.LexerMode{
+		ScopeStartFunction: func(tkzr *tz.Tokenizer) bool {
+			assert.Equal(t, "directive", tkzr.Mode())
+			if tkzr.CurrentChar() != '<' {
+				return false
+			}
+			tkzr.StartInfo = "<"
+			return true
+		},
+		ScopeEndFunction: func(tkzr *tz.Tokenizer) bool {
+			if tkzr.CurrentChar() != '>' {
+				return false
+			}
+			tkzr.EndInfo = ">"
+			return true
+		},
+		EndFunction: func(tkzr *tz.Tokenizer) bool {
+			return tkzr.CurrentChar() == '\n'
+		},
+	})
+	var modes []string
+	lang.ConfigureScope(func(tkzr *tz.Tokenizer) bool {
+		modes = append(modes, tkzr.Mode())
+		if tkzr.CurrentChar() == '#' {
+			assert.True(t, tkzr.PushMode("directive"))
+			assert.False(t, tkzr.PushMode("unknown"))
+		}
+		return false
+	}, func(tkzr *tz.Tokenizer) bool {
+		return false
+	})
+
+	tokensScope, diagnostics, err := tz.NewTokenizer(lang).Tokenize("#include <a>\na <b>")
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(diagnostics))
+	assert.Equal(t, "# include < [a] > a < b >", describeScope(&tokensScope))
+	// The language's own scope start is not used while the mode is
+	assert.NotContains(t, modes, "directive")
+	assert.Equal(t, 7, len(modes))
+	// The original language has no modes
+	assert.Equal(t, 0, len(tz.CreateDullTokenizer().Modes))
+}
+
+func Test_Modes_Definition(t *testing.T) {
+	_, err := tz.ParseLanguageDefinition([]byte(`{"name": "x", "strings": [{"start": "'", "end": "'", "interpolation": {"start": "{"}}]}`), tz.DEFINITION_FORMAT_JSON)
+	assert.NotNil(t, err)
+	_, err = tz.ParseLanguageDefinition([]byte(`{"name": "x", "strings": [{"start": "'", "end": "'", "interpolation": {"start": "{", "end": "}", "scopes": [{"start": "("}]}}]}`), tz.DEFINITION_FORMAT_JSON)
+	assert.NotNil(t, err)
+
+	// Prefixed strings with doubled delimiters, in a language with indentation scopes
+	definition, err := tz.ParseLanguageDefinition([]byte(`
+name: formatted
+symbols: [[":", Colon], ["{", LBrace], ["}", RBrace]]
+strings:
+  - {start: "'", end: "'", prefixes: ["", r]}
+  - start: "'"
+    end: "'"
+    prefixes: [f]
+    interpolation: {start: "{", end: "}", doubled: true, scopes: [{start: "{", end: "}"}]}
+indentationScopes: {opener: ":"}
+`), tz.DEFINITION_FORMAT_YAML)
+	assert.Nil(t, err)
+	lang, err := definition.BuildLanguage()
+	assert.Nil(t, err)
+
+	tokensScope, diagnostics, err := tz.NewTokenizer(lang).Tokenize("if x:\n    F'{{a}} {b:>{w}} {{{ {c} }'\n    r'{x}' rf'{x}'")
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(diagnostics))
+	assert.Equal(t, "if x : [F'{{a}}  { [b : > { [w] }] }  {{ { [{ [c] }] } ' r'{x}' rf '{x}']", describeScope(&tokensScope))
+}
@@ -0,0 +1,106 @@
+package tokenizer_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	javaTokenizer "tp/src/instances/langs/java"
+	pythonTokenizer "tp/src/instances/langs/python"
+	"tp/src/tests"
+	tk "tp/src/tokenizer/tokens"
+)
+
+// patternJavaText
+// The Java text the pattern tests match
+const patternJavaText = `class A {
+	void read(String path) throws IOException {
+		try {
+			load(path);
+		} catch (IOException e) {
+		} catch (RuntimeException e) {
+			// Ignored on purpose
+		} catch (Exception e) {
+			log(e);
+		}
+	}
+	int sum(int a, int b) { return a + b; }
+}`
+
+func Test_Pattern_Java(t *testing.T) {
+	tokenizer := javaTokenizer.GetJavaTokenizer()
+	tokensScope, _, err := tokenizer.Tokenize(patternJavaText)
+	assert.Nil(t, err)
+
+	// Empty catch clauses, including those with only a comment, are found within the scope of the try statement's method
+	emptyCatch := tk.MustCompilePattern("catch ( type:IDENTIFIER IDENTIFIER ) { @[] }")
+	matches := emptyCatch.FindInScope(&tokensScope)
+	assert.Equal(t, 2, len(matches))
+	assert.Equal(t, []string{"IOException"}, tests.TokenTexts(matches[0].Captures["type"].Tokens))
+	assert.Equal(t, []string{"RuntimeException"}, tests.TokenTexts(matches[1].Captures["type"].Tokens))
+	assert.Equal(t, "Method", matches[0].Scope.GetType())
+	assert.Equal(t, 5, matches[0].Tokens[0].LineNumber)
+	assert.Equal(t, 8, len(matches[0].Tokens))
+
+	// The starts of methods, with and without the exceptions they throw
+	methodStart := tk.MustCompilePattern("name:IDENTIFIER ( parameters:... ) [throws IDENTIFIER [, IDENTIFIER]*]? {")
+	matches = methodStart.FindInScope(&tokensScope)
+	assert.Equal(t, 2, len(matches))
+	assert.Equal(t, []string{"read"}, tests.TokenTexts(matches[0].Captures["name"].Tokens))
+	assert.Equal(t, []string{"String", "path"}, tests.TokenTexts(matches[0].Captures["parameters"].Tokens))
+	assert.Equal(t, []string{"sum"}, tests.TokenTexts(matches[1].Captures["name"].Tokens))
+	assert.Equal(t, []string{"int", "a", ",", "int", "b"}, tests.TokenTexts(matches[1].Captures["parameters"].Tokens))
+
+	// Calls, which are found within their own scopes. Once the tokens are flattened, "..." may reach across scopes
+	call := tk.MustCompilePattern("IDENTIFIER LPAREN ... RPAREN SEMICOLON")
+	matches = call.FindInScope(&tokensScope)
+	assert.Equal(t, 2, len(matches))
+	assert.Equal(t, []string{"load", "(", "path", ")", ";"}, tests.TokenTexts(matches[0].Tokens))
+	assert.Equal(t, []string{"log", "(", "e", ")", ";"}, tests.TokenTexts(matches[1].Tokens))
+	flattened := call.FindAll(tokensScope.ConvertToArray())
+	assert.Equal(t, 2, len(flattened))
+	assert.Equal(t, []string{"read", "(", "String", "path", ")", "throws", "IOException", "{", "try", "{", "load", "(", "path", ")", ";"}, tests.TokenTexts(flattened[0].Tokens))
+	flattened = tk.MustCompilePattern("IDENTIFIER LPAREN IDENTIFIER* RPAREN SEMICOLON").FindAll(tokensScope.ConvertToArray())
+	assert.Equal(t, 2, len(flattened))
+	assert.Equal(t, []string{"load", "(", "path", ")", ";"}, tests.TokenTexts(flattened[0].Tokens))
+
+	// Scopes of a type, and scopes whose contents match
+	assert.Equal(t, 2, len(tk.MustCompilePattern("{ @Method }").FindInScope(&tokensScope)))
+	assert.Equal(t, 1, len(tk.MustCompilePattern("{ @Method[return _ '+' _ ;] }").FindInScope(&tokensScope)))
+
+	// Lossless mode matches the same tokens, with the trivia between them kept
+	tokenizer = javaTokenizer.GetJavaTokenizer()
+	tokenizer.LosslessMode = true
+	losslessScope, _, err := tokenizer.Tokenize(patternJavaText)
+	assert.Nil(t, err)
+	losslessMatches := emptyCatch.FindInScope(&losslessScope)
+	assert.Equal(t, 2, len(losslessMatches))
+	assert.Equal(t, "catch (IOException e) {\n\t\t}", losslessScopeText(losslessMatches[0].Tokens))
+}
+
+// losslessScopeText
+// Returns the text of the tokens, including the text of the scopes among them
+func losslessScopeText(tokenList []*tk.Token) string {
+	text := ""
+	for _, token := range tokenList {
+		if token.ValidScopeToken() {
+			text += token.GetScopeToken().Source()
+		} else {
+			text += token.Text
+		}
+	}
+	return text
+}
+
+func Test_Pattern_Python(t *testing.T) {
+	text := "def area(width, height):\n    return width * height\n\ndef main():\n    pass\n"
+	tokensScope, _, err := pythonTokenizer.GetPythonTokenizer().Tokenize(text)
+	assert.Nil(t, err)
+
+	// Functions which only pass. The parameters are matched one by one, since "..." could reach the next function
+	matches := tk.MustCompilePattern("def name:IDENTIFIER ( [IDENTIFIER ,?]* ) : @[INDENT? pass DEDENT?]").FindInScope(&tokensScope)
+	assert.Equal(t, 1, len(matches))
+	assert.Equal(t, []string{"main"}, tests.TokenTexts(matches[0].Captures["name"].Tokens))
+
+	matches = tk.MustCompilePattern("return IDENTIFIER '*' IDENTIFIER").FindInScope(&tokensScope)
+	assert.Equal(t, 1, len(matches))
+	assert.Equal(t, 2, matches[0].Tokens[0].LineNumber)
+}
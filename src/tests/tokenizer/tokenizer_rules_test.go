@@ -0,0 +1,178 @@
+package tokenizer_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"testing/iotest"
+	javaTokenizer "tp/src/instances/langs/java"
+	pythonTokenizer "tp/src/instances/langs/python"
+	"tp/src/tests"
+	tz "tp/src/tokenizer"
+	tk "tp/src/tokenizer/tokens"
+)
+
+// getAnnotatedJavaLanguage
+// Returns a copy of the Java language which also finds annotations and hex colors with token rules
+func getAnnotatedJavaLanguage(t *testing.T) *tz.Language {
+	lang := javaTokenizer.GetJavaLanguage().Copy()
+	assert.Nil(t, lang.ConfigureTokenRule(tz.TokenRule{Name: "annotation", Pattern: `@[A-Za-z_]\w*`}))
+	assert.Nil(t, lang.ConfigureTokenRule(tz.TokenRule{
+		Name: "color", Pattern: `#[0-9a-fA-F]{6}\b`, Priority: 1,
+		RuleName: "LITERAL", SymbolicName: "HexColor", Category: tk.CATEGORY_LITERAL,
+	}))
+	return lang
+}
+
+func Test_TokenRules_Tokens(t *testing.T) {
+	lang := getAnnotatedJavaLanguage(t)
+	tokensScope, diagnostics, err := tz.NewTokenizer(lang).Tokenize("@Override int x=#00ff00;\n// @NotAnAnnotation\nString s = \"@x\";")
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(diagnostics))
+
+	assert.Equal(t, 12, tokensScope.Size())
+	for i := 0; i < tokensScope.Size(); i++ {
+		st1, _ := tokensScope.At(i)
+		switch i {
+		case 0:
+			tests.ValidateToken(t, st1, 1, 0, tz.RULENAME_OTHER, "ANNOTATION", "@Override")
+			tests.ValidateTokenSpan(t, st1, tk.Position{Line: 1, Column: 1, Offset: 0}, tk.Position{Line: 1, Column: 10, Offset: 9})
+		case 1:
+			tests.ValidateToken(t, st1, 1, 0, tz.RULENAME_KEYWORD, "INT", "int")
+		case 2:
+			tests.VerifyUnknownKeyword(t, st1, 1, 0, "x")
+		case 4:
+			tests.ValidateToken(t, st1, 1, 0, "LITERAL", "HexColor", "#00ff00")
+			assert.True(t, st1.Kind.IsLiteral())
+			kind, found := lang.Kinds().Lookup("LITERAL", "HexColor")
+			assert.True(t, found)
+			assert.Equal(t, kind, st1.Kind)
+		case 5:
+			tests.ValidateToken(t, st1, 1, 0, tz.RULENAME_SYMBOL, "SEMICOLON", ";")
+		case 6:
+			// Comments and strings are found before token rules
+			tests.ValidateToken(t, st1, 2, 0, tz.RULENAME_OTHER, tz.SYMBOLIC_NAME_COMMENT, "// @NotAnAnnotation\n")
+		case 10:
+			tests.ValidateToken(t, st1, 3, 0, tz.RULENAME_OTHER, tz.SYMBOLIC_NAME_STRING, "\"@x\"")
+		}
+	}
+
+	// The original language is not affected
+	assert.Equal(t, 0, len(javaTokenizer.GetJavaLanguage().TokenRules))
+}
+
+func Test_TokenRules_Priority(t *testing.T) {
+	lang := pythonTokenizer.GetPythonLanguage().Copy()
+	assert.Nil(t, lang.ConfigureTokenRule(tz.TokenRule{Name: "decorator", Pattern: `@\w+`}))
+	assert.Nil(t, lang.ConfigureTokenRule(tz.TokenRule{Name: "property", Pattern: `@property\b`, Priority: 10}))
+	assert.Nil(t, lang.ConfigureTokenRule(tz.TokenRule{Name: "matmul", Pattern: `@`, Priority: -1, RuleName: tz.RULENAME_SYMBOL}))
+	assert.Equal(t, "property", lang.TokenRules[0].Name)
+	assert.Equal(t, "decorator", lang.TokenRules[1].Name)
+	assert.Equal(t, "matmul", lang.TokenRules[2].Name)
+
+	tokensScope, _, err := tz.NewTokenizer(lang).Tokenize("@property\n@cache\nx = a @ b\n")
+	assert.Nil(t, err)
+	assert.Equal(t, 7, tokensScope.Size())
+	st1, _ := tokensScope.At(0)
+	tests.ValidateToken(t, st1, 1, 0, tz.RULENAME_OTHER, "PROPERTY", "@property")
+	st1, _ = tokensScope.At(1)
+	tests.ValidateToken(t, st1, 2, 0, tz.RULENAME_OTHER, "DECORATOR", "@cache")
+	st1, _ = tokensScope.At(5)
+	tests.ValidateToken(t, st1, 3, 0, tz.RULENAME_SYMBOL, "MATMUL", "@")
+}
+
+func Test_TokenRules_KeywordsAndNumbers(t *testing.T) {
+	lang := javaTokenizer.GetJavaLanguage().Copy()
+	assert.Nil(t, lang.ConfigureTokenRule(tz.TokenRule{Name: "version", Pattern: `v\d+(\.\d+)*`}))
+	// A rule with the names and category of a built-in kind creates tokens of that kind
+	assert.Nil(t, lang.ConfigureTokenRule(tz.TokenRule{
+		Name: "duration", Pattern: `\d+(ms|s)\b`, SymbolicName: tz.SYMBOLIC_NAME_NUMBER, Category: tk.CATEGORY_LITERAL,
+	}))
+
+	tokensScope, _, err := tz.NewTokenizer(lang).Tokenize("v1.2 dev2 wait(30ms, 5)")
+	assert.Nil(t, err)
+	assert.Equal(t, 8, tokensScope.Size())
+	st1, _ := tokensScope.At(0)
+	tests.ValidateToken(t, st1, 1, 0, tz.RULENAME_OTHER, "VERSION", "v1.2")
+	st1, _ = tokensScope.At(1)
+	// Rules are not matched in the middle of a keyword
+	tests.VerifyUnknownKeyword(t, st1, 1, 0, "dev2")
+	st1, _ = tokensScope.At(4)
+	assert.Equal(t, tk.KIND_NUMBER, st1.Kind)
+	assert.Equal(t, "30ms", st1.Text)
+	st1, _ = tokensScope.At(6)
+	assert.Equal(t, tk.KIND_NUMBER, st1.Kind)
+	assert.Equal(t, "5", st1.Text)
+}
+
+func Test_TokenRules_SingleLine(t *testing.T) {
+	lang := javaTokenizer.GetJavaLanguage().Copy()
+	assert.Nil(t, lang.ConfigureTokenRule(tz.TokenRule{Name: "regex", Pattern: `~/(\\.|[^/\\])*/`}))
+	text := "x = ~/a\\/b/;\ny = ~/never\nended/;"
+
+	tokensScope, _, err := tz.NewTokenizer(lang).Tokenize(text)
+	assert.Nil(t, err)
+	st1, _ := tokensScope.At(2)
+	tests.ValidateToken(t, st1, 1, 0, tz.RULENAME_OTHER, "REGEX", "~/a\\/b/")
+	// A match cannot continue onto the next line
+	st1, _ = tokensScope.At(6)
+	tests.ValidateToken(t, st1, 2, 0, tz.RULENAME_SYMBOL, "TILDE", "~")
+
+	// Reading from a reader results in the same tokens
+	stream, err := tz.NewTokenizer(lang).TokenizeReader(iotest.OneByteReader(strings.NewReader(text)))
+	assert.Nil(t, err)
+	assert.Equal(t, scopeToEvents(&tokensScope), collectEvents(t, stream))
+}
+
+func Test_TokenRules_LongLine(t *testing.T) {
+	// The end of a line is only searched for once, so a long line is tokenized in linear time
+	lang := getAnnotatedJavaLanguage(t)
+	text := strings.Repeat("@a b ", 50000) + "\n@c"
+	tokensScope, _, err := tz.NewTokenizer(lang).Tokenize(text)
+	assert.Nil(t, err)
+	assert.Equal(t, 100001, tokensScope.Size())
+	st1, _ := tokensScope.At(99998)
+	tests.ValidateToken(t, st1, 1, 0, tz.RULENAME_OTHER, "ANNOTATION", "@a")
+	st1, _ = tokensScope.At(100000)
+	tests.ValidateToken(t, st1, 2, 0, tz.RULENAME_OTHER, "ANNOTATION", "@c")
+}
+
+func Test_TokenRules_Invalid(t *testing.T) {
+	lang := javaTokenizer.GetJavaLanguage().Copy()
+	assert.NotNil(t, lang.ConfigureTokenRule(tz.TokenRule{Pattern: `@\w+`}))
+	assert.NotNil(t, lang.ConfigureTokenRule(tz.TokenRule{Name: "empty"}))
+	assert.NotNil(t, lang.ConfigureTokenRule(tz.TokenRule{Name: "invalid", Pattern: `(`}))
+	assert.NotNil(t, lang.ConfigureTokenRule(tz.TokenRule{Name: "optional", Pattern: `@?`}))
+	assert.Nil(t, lang.ConfigureTokenRule(tz.TokenRule{Name: "annotation", Pattern: `@\w+`}))
+	assert.NotNil(t, lang.ConfigureTokenRule(tz.TokenRule{Name: "annotation", Pattern: `@`}))
+	assert.Equal(t, 1, len(lang.TokenRules))
+}
+
+func Test_TokenRules_Definition(t *testing.T) {
+	definition, err := tz.ParseLanguageDefinition([]byte(`
+name: rules
+symbols: [["@", "At"]]
+tokenRules:
+  - {name: decorator, pattern: '@\w+', symbolicName: Decorator, category: operator}
+`), tz.DEFINITION_FORMAT_YAML)
+	assert.Nil(t, err)
+	assert.Equal(t, tk.CATEGORY_OPERATOR, definition.TokenRules[0].Category)
+	lang, err := definition.BuildLanguage()
+	assert.Nil(t, err)
+
+	tokensScope, _, err := tz.NewTokenizer(lang).Tokenize("@cache @")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, tokensScope.Size())
+	st1, _ := tokensScope.At(0)
+	tests.ValidateToken(t, st1, 1, 0, tz.RULENAME_OTHER, "Decorator", "@cache")
+	assert.True(t, st1.Kind.IsOperator())
+	st1, _ = tokensScope.At(1)
+	tests.ValidateToken(t, st1, 1, 0, tz.RULENAME_SYMBOL, "AT", "@")
+
+	_, err = tz.ParseLanguageDefinition([]byte(`{"name": "x", "tokenRules": [{"name": "a", "pattern": "a", "category": "unknown"}]}`), tz.DEFINITION_FORMAT_JSON)
+	assert.NotNil(t, err)
+	_, err = tz.ParseLanguageDefinition([]byte(`{"name": "x", "tokenRules": [{"name": "a", "pattern": "a*"}]}`), tz.DEFINITION_FORMAT_JSON)
+	assert.NotNil(t, err)
+	_, err = tz.ParseLanguageDefinition([]byte(`{"name": "x", "tokenRules": [{"name": "a", "pattern": "a"}, {"name": "a", "pattern": "b"}]}`), tz.DEFINITION_FORMAT_JSON)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,289 @@
+package tokenizer_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	javaTokenizer "tp/src/instances/langs/java"
+	pythonTokenizer "tp/src/instances/langs/python"
+	"tp/src/tests"
+	tz "tp/src/tokenizer"
+	tk "tp/src/tokenizer/tokens"
+	"tp/src/util"
+)
+
+func Test_Scopes_Braces(t *testing.T) {
+	text := "class A {\n\tvoid f() {\n\t}\n}"
+	tokensScope, _, err := javaTokenizer.GetJavaTokenizer().Tokenize(text)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, tokensScope.GetDepth())
+	assert.Nil(t, tokensScope.GetOpener())
+	assert.Nil(t, tokensScope.GetCloser())
+
+	// The opener and closer are the tokens around the scope token
+	classScope, err := tokensScope.GetScope(0)
+	assert.Nil(t, err)
+	st1, _ := tokensScope.At(2)
+	assert.Same(t, st1, classScope.GetOpener())
+	st1, _ = tokensScope.At(4)
+	assert.Same(t, st1, classScope.GetCloser())
+	tests.ValidateToken(t, classScope.GetCloser(), 4, 0, tz.RULENAME_SYMBOL, "RCURLY", "}")
+	assert.Equal(t, 1, classScope.GetDepth())
+	assert.False(t, classScope.IsImplicitlyClosed())
+	assert.Equal(t, tk.Position{Line: 1, Column: 10, Offset: 9}, classScope.GetStart())
+	assert.Equal(t, tk.Position{Line: 4, Column: 1, Offset: 25}, classScope.GetEnd())
+
+	// The scope token spans the contents of its scope, and is on the line of its opener
+	st1, _ = tokensScope.At(3)
+	tests.ValidateTokenSpan(t, st1, classScope.GetStart(), classScope.GetEnd())
+	assert.Equal(t, 1, st1.LineNumber)
+
+	methodScope, err := classScope.GetScope(0)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, methodScope.GetDepth())
+	assert.Equal(t, "{", methodScope.GetOpener().Text)
+	tests.ValidateTokenSpan(t, methodScope.GetCloser(), tk.Position{Line: 3, Column: 2, Offset: 23}, tk.Position{Line: 3, Column: 3, Offset: 24})
+	assert.False(t, methodScope.IsImplicitlyClosed())
+}
+
+func Test_Scopes_ImplicitlyClosed(t *testing.T) {
+	// A scope which is never closed is closed at the end of the text
+	tokensScope, diagnostics, err := javaTokenizer.GetJavaTokenizer().Tokenize("class A {\n\tint b;")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(diagnostics))
+	classScope, _ := tokensScope.GetScope(0)
+	assert.Equal(t, "{", classScope.GetOpener().Text)
+	assert.Nil(t, classScope.GetCloser())
+	assert.True(t, classScope.IsImplicitlyClosed())
+	assert.Equal(t, tk.Position{Line: 2, Column: 8, Offset: 17}, classScope.GetEnd())
+
+	// Python blocks are closed by dedents and the end of the text
+	text := "def f():\n    if a:\n        b\n    c\n"
+	tokensScope, _, err = pythonTokenizer.GetPythonTokenizer().Tokenize(text)
+	assert.Nil(t, err)
+	functionScope, _ := tokensScope.GetScope(0)
+	assert.Equal(t, ":", functionScope.GetOpener().Text)
+	assert.Equal(t, 1, functionScope.GetOpener().LineNumber)
+	assert.Nil(t, functionScope.GetCloser())
+	assert.True(t, functionScope.IsImplicitlyClosed())
+	assert.Equal(t, 1, functionScope.GetDepth())
+
+	ifScope, _ := functionScope.GetScope(0)
+	assert.Equal(t, 2, ifScope.GetOpener().LineNumber)
+	assert.True(t, ifScope.IsImplicitlyClosed())
+	assert.Equal(t, 2, ifScope.GetDepth())
+}
+
+func Test_Scopes_ToDetailedJsonString(t *testing.T) {
+	files := []struct {
+		path      string
+		tokenizer func() *tz.Tokenizer
+	}{
+		{"../exampleFiles/file.java", javaTokenizer.GetJavaTokenizer},
+		{"../exampleFiles/unicode.py", pythonTokenizer.GetPythonTokenizer},
+	}
+	for _, file := range files {
+		text, err := util.GetTextOfFile(file.path)
+		assert.Nil(t, err)
+		tokensScope, _, err := file.tokenizer().Tokenize(text)
+		assert.Nil(t, err)
+
+		var output map[string]struct {
+			Depth            int
+			End              tk.Position
+			ImplicitlyClosed bool
+			Opener           *struct{ Text string }
+			Encoding         tk.Encoding
+			Tokens           []json.RawMessage
+		}
+		jsonString := tokensScope.ToDetailedJsonString("test")
+		assert.Nil(t, json.Unmarshal([]byte(jsonString), &output), file.path)
+		fileScope := output["test"]
+		assert.Equal(t, 0, fileScope.Depth)
+		assert.Nil(t, fileScope.Opener)
+		assert.Equal(t, tokensScope.GetEnd(), fileScope.End)
+		assert.Equal(t, tz.ENCODING_UTF8, fileScope.Encoding.Name)
+		assert.Equal(t, tokensScope.Size(), len(fileScope.Tokens))
+
+		// Without the details, every scope is an array of its tokens
+		var arrays map[string][]json.RawMessage
+		assert.Nil(t, json.Unmarshal([]byte(tokensScope.ToJsonString("test")), &arrays), file.path)
+		assert.Equal(t, tokensScope.Size(), len(arrays["test"]))
+		innerScope, err := tokensScope.GetScope(0)
+		assert.Nil(t, err)
+		for i, tkn := range tokensScope.GetTokenList() {
+			if tkn.ValidScopeToken() {
+				var innerArray []json.RawMessage
+				assert.Nil(t, json.Unmarshal(arrays["test"][i], &innerArray), file.path)
+				assert.Equal(t, innerScope.Size(), len(innerArray), file.path)
+				break
+			}
+		}
+	}
+}
+
+// describeScopeTypes
+// Returns the type, name and header of every scope within the scope, with the scopes within them indented
+func describeScopeTypes(scope *tk.ScopeObj, indentation string) []string {
+	lines := make([]string, 0)
+	for i := 0; i < scope.GetNumberOfScopes(); i++ {
+		innerScope, _ := scope.GetScope(i)
+		header := make([]string, 0, len(innerScope.GetHeader()))
+		for _, tkn := range innerScope.GetHeader() {
+			header = append(header, tkn.Text)
+		}
+		lines = append(lines, fmt.Sprintf("%s%s %q: %s", indentation, innerScope.GetType(), innerScope.GetName(), strings.Join(header, " ")))
+		lines = append(lines, describeScopeTypes(innerScope, indentation+"  ")...)
+	}
+	return lines
+}
+
+func Test_Scopes_ClassifyJava(t *testing.T) {
+	text := `package a;
+@Deprecated
+public final class A<T> extends B implements C {
+	static { count = 0; }
+	interface I { }
+	enum E { X, Y }
+	/** Creates an A */
+	public A(int a) throws IOException, java.lang.Exception {
+		Runnable r = () -> { go(); };
+		list.forEach(x -> { if (x) { } else if (y) { } else { } });
+		Comparator<String> c = new java.util.Comparator<String>() { };
+		if (a) try { } catch (Exception e) { } finally { }
+		for (int i = 0; i < 2; i++) { }
+		do { } while (b);
+		switch (a) { case 1 -> { } default -> { } }
+		synchronized (this) { }
+		{ }
+		int[] numbers = { 1, 2 };
+	}
+}`
+	tokensScope, _, err := javaTokenizer.GetJavaTokenizer().Tokenize(text)
+	assert.Nil(t, err)
+	assert.Equal(t, tz.SCOPE_TYPE_FILE, tokensScope.GetType())
+	assert.Equal(t, []string{
+		`Class "A": @ Deprecated public final class A < T > extends B implements C`,
+		`  Block "": static`,
+		`  Interface "I": interface I`,
+		`  Enum "E": enum E`,
+		`  Method "A": public A ( int a ) throws IOException , java . lang . Exception`,
+		`    Lambda "": Runnable r = ( ) ->`,
+		`    Lambda "": x ->`,
+		`      If "": if ( x )`,
+		`      If "": else if ( y )`,
+		`      Else "": else`,
+		`    Class "": Comparator < String > c = new java . util . Comparator < String > ( )`,
+		`    Try "": if ( a ) try`,
+		`    Catch "": catch ( Exception e )`,
+		`    Finally "": finally`,
+		`    Loop "": for ( int i = 0 ; i < 2 ; i ++ )`,
+		`    Loop "": do`,
+		`    Switch "": switch ( a )`,
+		`      Case "": case 1 ->`,
+		`      Case "": default ->`,
+		`    Block "": synchronized ( this )`,
+		`    Block "": `,
+		`    __UNKNOWN__ "": int [ ] numbers =`,
+	}, describeScopeTypes(&tokensScope, ""))
+
+	// The header is the same in lossless mode, as trivia is not part of it
+	tokenizer := javaTokenizer.GetJavaTokenizer()
+	tokenizer.LosslessMode = true
+	losslessScope, _, err := tokenizer.Tokenize(text)
+	assert.Nil(t, err)
+	assert.Equal(t, describeScopeTypes(&tokensScope, ""), describeScopeTypes(&losslessScope, ""))
+}
+
+func Test_Scopes_ClassifyPython(t *testing.T) {
+	text := `import os
+class Shape(Base):
+    def area(self, width: int, height: int = 2) -> int:
+        if width > 0 and \
+                height > 0:
+            return width * height
+        elif width == 0:
+            pass
+        else:
+            pass
+
+def main():
+    handlers = {"a": 1}
+    for x in range(10):
+        while x:
+            x = x[1:]
+    try:
+        with open(path) as f:
+            pass
+    except OSError:
+        pass
+    finally:
+        pass
+    match handlers:
+        case {"a": 1}:
+            pass
+    square = lambda n: n * n
+`
+	tokensScope, _, err := pythonTokenizer.GetPythonTokenizer().Tokenize(text)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		`Class "Shape": class Shape ( Base )`,
+		`  Method "area": def area ( self , width : int , height : int = 2 ) -> int`,
+		`    If "": if width > 0 and height > 0`,
+		`    If "": elif width == 0`,
+		`    Else "": else`,
+		`Function "main": def main ( )`,
+		`  Loop "": for x in range ( 10 )`,
+		`    Loop "": while x`,
+		`  Try "": try`,
+		`    With "": with open ( path ) as f`,
+		`  Catch "": except OSError`,
+		`  Finally "": finally`,
+		`  Switch "": match handlers`,
+		`    Case "": case { "a" : 1 }`,
+		`  Lambda "": square = lambda n`,
+	}, describeScopeTypes(&tokensScope, ""))
+
+	// The colons within brackets (e.g. of annotations and dictionaries) do not open scopes in the definition of Python either
+	definition, err := pythonTokenizer.GetPythonDefinition()
+	assert.Nil(t, err)
+	lang, err := definition.BuildLanguage()
+	assert.Nil(t, err)
+	definitionScope, _, err := tz.NewTokenizer(lang).Tokenize(text)
+	assert.Nil(t, err)
+	assert.Equal(t, describeScope(&tokensScope), describeScope(&definitionScope))
+}
+
+func Test_Scopes_ConfigureScopeHeaders(t *testing.T) {
+	definition, err := tz.ParseLanguageDefinition([]byte(`{"name": "x", "symbols": [[";", "Semi"], ["(", "LParen"], [")", "RParen"]], "scopes": [{"start": "{", "end": "}"}]}`), tz.DEFINITION_FORMAT_JSON)
+	assert.Nil(t, err)
+	lang, err := definition.BuildLanguage()
+	assert.Nil(t, err)
+	text := "a; class A { f(b) { } }; g { }"
+
+	// Languages which do not find headers leave nested scopes unclassified
+	tokensScope, _, err := tz.NewTokenizer(lang).Tokenize(text)
+	assert.Nil(t, err)
+	assert.Equal(t, tz.SCOPE_TYPE_FILE, tokensScope.GetType())
+	classScope, _ := tokensScope.GetScope(0)
+	assert.Equal(t, tk.UNKNOWN_SCOPE_STRING, classScope.GetType())
+	assert.Nil(t, classScope.GetHeader())
+
+	// Classifiers may be given to any language
+	lang = lang.Copy()
+	lang.ConfigureScopeHeaders(tz.ScopeHeaderRules{
+		StatementEnds: []string{";"},
+		Classifiers: []tz.ScopeClassifier{
+			tz.KeywordScopeClassifier([]tz.ScopeTypeRule{{Keyword: "class", Type: tz.SCOPE_TYPE_CLASS, Named: true}}),
+			func(header []*tk.Token, scope *tk.ScopeObj) (string, string, bool) {
+				name, found := tz.CallableName(header)
+				return "Call", name, found
+			},
+		},
+	})
+	tokensScope, _, err = tz.NewTokenizer(lang).Tokenize(text)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{`Class "A": class A`, `  Call "f": f ( b )`, `__UNKNOWN__ "": g`}, describeScopeTypes(&tokensScope, ""))
+}
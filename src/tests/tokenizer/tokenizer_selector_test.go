@@ -0,0 +1,144 @@
+package tokenizer_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	javaTokenizer "tp/src/instances/langs/java"
+	pythonTokenizer "tp/src/instances/langs/python"
+	"tp/src/tests"
+	tk "tp/src/tokenizer/tokens"
+)
+
+// selectorJavaText
+// The Java text the selector tests query
+const selectorJavaText = `class A {
+	int count = 0;
+	/** Gets the count */
+	int getCount() {
+		for (int i = 0; i < 2; i++) {
+			while (b) {
+				if (c) {
+					for (String s : list) { count++; }
+				}
+			}
+		}
+		return count;
+	}
+	interface I { void run(); }
+	class B { void getName() { } }
+}`
+
+// matchTexts
+// Returns the texts of the tokens the query matches within the scope, failing the test if the query is invalid
+func matchTexts(t *testing.T, scope *tk.ScopeObj, query string) []string {
+	selector, err := tk.ParseSelector(query)
+	assert.Nil(t, err, query)
+	if err != nil {
+		return nil
+	}
+	return tests.TokenTexts(selector.MatchTokens(scope))
+}
+
+// matchScopes
+// Returns the type and name of the scopes the query matches within the scope, failing the test if the query is invalid
+func matchScopes(t *testing.T, scope *tk.ScopeObj, query string) []string {
+	selector, err := tk.ParseSelector(query)
+	assert.Nil(t, err, query)
+	if err != nil {
+		return nil
+	}
+	scopes := make([]string, 0)
+	for _, matched := range selector.MatchScopes(scope) {
+		scopes = append(scopes, matched.GetType()+" "+matched.GetName())
+	}
+	return scopes
+}
+
+func Test_Selector_Tokens(t *testing.T) {
+	tokensScope, _, err := javaTokenizer.GetJavaTokenizer().Tokenize(selectorJavaText)
+	assert.Nil(t, err)
+
+	// The identifiers directly after the keyword class
+	assert.Equal(t, []string{"A", "B"}, matchTexts(t, &tokensScope, `"class" + IDENTIFIER`))
+	assert.Equal(t, []string{"A", "B"}, matchTexts(t, &tokensScope, `[kind=CLASS] + [category=identifier]`))
+
+	// Kinds, texts, regular expressions and numbers
+	assert.Equal(t, []string{"0", "0", "2"}, matchTexts(t, &tokensScope, "NUMBER"))
+	assert.Equal(t, []string{"getCount", "getName"}, matchTexts(t, &tokensScope, "IDENTIFIER[text^=get]"))
+	assert.Equal(t, []string{"getCount", "getName"}, matchTexts(t, &tokensScope, `IDENTIFIER[text=~/^GET/i]`))
+	assert.Equal(t, []string{"count", "getCount", "count", "count"}, matchTexts(t, &tokensScope, `IDENTIFIER[text*="ount"]`))
+	assert.Equal(t, []string{"getCount"}, matchTexts(t, &tokensScope, `IDENTIFIER[text$=Count][line=4]`))
+	assert.Equal(t, []string{"return", "count", ";"}, matchTexts(t, &tokensScope, "[line>=12][line<13]"))
+
+	// Children, descendants and siblings
+	assert.Equal(t, []string{"int", "count", "=", "0", ";", "/** Gets the count */", "int", "getCount", "(", ")", "{", "}", "interface", "I", "{", "}", "class", "B", "{", "}"},
+		matchTexts(t, &tokensScope, ".Class#A > token"))
+	assert.Equal(t, []string{"count"}, matchTexts(t, &tokensScope, `scope.Loop scope.Loop .Loop IDENTIFIER`))
+	assert.Equal(t, []string{"count", ";"}, matchTexts(t, &tokensScope, `.Method > "return" ~ *`))
+	assert.Equal(t, []string{"i", "i", "i"}, matchTexts(t, &tokensScope, `"(" ~ IDENTIFIER[text=i]`))
+
+	// Pseudo-classes, which skip trivia
+	assert.Equal(t, []string{"int", "for", "while", "if", "for", "count", "void", "void"}, matchTexts(t, &tokensScope, "scope > :first-child"))
+	assert.Equal(t, []string{";", "}", "}", "}", ";", ";", "}", "}"}, matchTexts(t, &tokensScope, "scope > :last-child"))
+	assert.Equal(t, []string{"A", "count", "getCount", "I", "B"}, matchTexts(t, &tokensScope, "IDENTIFIER:not(scope[depth>1] *)"))
+
+	// Lists of selectors match each token once, in the order of the text
+	assert.Equal(t, []string{"class", "A", "interface", "I", "class", "B"}, matchTexts(t, &tokensScope, `"class", "interface", "class" + *, "interface" + IDENTIFIER`))
+
+	// The same tokens are found in lossless mode
+	tokenizer := javaTokenizer.GetJavaTokenizer()
+	tokenizer.LosslessMode = true
+	losslessScope, _, err := tokenizer.Tokenize(selectorJavaText)
+	assert.Nil(t, err)
+	for _, query := range []string{`"class" + IDENTIFIER`, "scope > :first-child", "scope > :last-child", `.Method > "return" ~ :not([category=trivia])`} {
+		assert.Equal(t, matchTexts(t, &tokensScope, query), matchTexts(t, &losslessScope, query), query)
+	}
+	assert.Equal(t, []string{"/** Gets the count */"}, matchTexts(t, &losslessScope, "COMMENT"))
+}
+
+func Test_Selector_Scopes(t *testing.T) {
+	tokensScope, _, err := javaTokenizer.GetJavaTokenizer().Tokenize(selectorJavaText)
+	assert.Nil(t, err)
+
+	// The scopes nested more than 3 deep whose header contains "for"
+	assert.Equal(t, []string{"Loop "}, matchScopes(t, &tokensScope, "scope[depth>3][header~=for]"))
+	assert.Equal(t, []string{"Loop ", "Loop "}, matchScopes(t, &tokensScope, "scope[header~=for]"))
+
+	assert.Equal(t, []string{"Class A", "Method getCount", "Interface I", "Class B", "Method getName"}, matchScopes(t, &tokensScope, "scope[name]"))
+	assert.Equal(t, []string{"Method getCount", "Interface I", "Method getName"}, matchScopes(t, &tokensScope, `.Method, [header^="interface "]`))
+	assert.Equal(t, []string{"Interface I", "Class B"}, matchScopes(t, &tokensScope, ".Method ~ scope"))
+	assert.Equal(t, []string{"If "}, matchScopes(t, &tokensScope, "scope:not(.Class, .Method, .Loop, .Interface)"))
+	assert.Equal(t, []string{"Class B"}, matchScopes(t, &tokensScope, `"class" + IDENTIFIER + "{" + scope[depth=2]`))
+
+	// Match returns scopes and tokens together, along with where they are
+	selector, err := tk.ParseSelector(`scope[depth=1], "class" + *`)
+	assert.Nil(t, err)
+	matches := selector.Match(&tokensScope)
+	assert.Equal(t, 3, len(matches))
+	assert.Equal(t, "A", matches[0].Token.Text)
+	assert.Nil(t, matches[0].Scope)
+	assert.Equal(t, []int{1}, matches[0].Path)
+	assert.Equal(t, 0, matches[0].Depth)
+	assert.Equal(t, tk.KIND_SCOPE, matches[1].Token.Kind)
+	classScope, _ := tokensScope.GetScope(0)
+	assert.Same(t, classScope, matches[1].Scope)
+	assert.Equal(t, 1, matches[1].Depth)
+	assert.Equal(t, []int{3}, matches[1].Path)
+	assert.Equal(t, "B", matches[2].Token.Text)
+	assert.Equal(t, 1, matches[2].Depth)
+
+	// Depths stay the same when a scope within the text is queried, though the scope itself is not matched
+	innerMatches, err := classScope.Query("scope[depth=2]")
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(innerMatches))
+	assert.Equal(t, "getCount", innerMatches[0].Scope.GetName())
+}
+
+func Test_Selector_Python(t *testing.T) {
+	text := "class Shape:\n    def area(self):\n        return 1\n\ndef main():\n    pass\n"
+	tokensScope, _, err := pythonTokenizer.GetPythonTokenizer().Tokenize(text)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"Method area", "Function main"}, matchScopes(t, &tokensScope, ".Method, .Function"))
+	assert.Equal(t, []string{"area"}, matchTexts(t, &tokensScope, `.Class "def" + *`))
+	assert.Equal(t, []string{"1"}, matchTexts(t, &tokensScope, "#area > [category=literal]"))
+}
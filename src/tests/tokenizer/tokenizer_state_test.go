@@ -0,0 +1,117 @@
+package tokenizer_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	tz "tp/src/tokenizer"
+	tk "tp/src/tokenizer/tokens"
+)
+
+type bracketInfo struct {
+	lineNumber int
+	bracket    rune
+}
+
+func Test_CallbackState_Values(t *testing.T) {
+	tokenizer := tz.CreateDullTokenizer()
+	state := tokenizer.State()
+
+	_, found := state.Get("missing")
+	assert.False(t, found)
+
+	state.Set("count", 3)
+	value, found := state.Get("count")
+	assert.True(t, found)
+	assert.Equal(t, 3, value)
+
+	count, ok := tz.GetState[int](tokenizer, "count")
+	assert.True(t, ok)
+	assert.Equal(t, 3, count)
+
+	// A value of a different type is not returned
+	_, ok = tz.GetState[string](tokenizer, "count")
+	assert.False(t, ok)
+
+	state.Delete("count")
+	_, ok = tz.GetState[int](tokenizer, "count")
+	assert.False(t, ok)
+}
+
+func Test_CallbackState_Stacks(t *testing.T) {
+	tokenizer := tz.CreateDullTokenizer()
+	state := tokenizer.State()
+
+	_, found := state.Pop("brackets")
+	assert.False(t, found)
+	_, ok := tz.PeekState[bracketInfo](tokenizer, "brackets")
+	assert.False(t, ok)
+
+	state.Push("brackets", bracketInfo{lineNumber: 1, bracket: '('})
+	state.Push("brackets", bracketInfo{lineNumber: 2, bracket: '['})
+	assert.Equal(t, 2, state.StackSize("brackets"))
+
+	top, ok := tz.PeekState[bracketInfo](tokenizer, "brackets")
+	assert.True(t, ok)
+	assert.Equal(t, bracketInfo{lineNumber: 2, bracket: '['}, top)
+	assert.Equal(t, 2, state.StackSize("brackets"))
+
+	top, ok = tz.PopState[bracketInfo](tokenizer, "brackets")
+	assert.True(t, ok)
+	assert.Equal(t, '[', top.bracket)
+	top, ok = tz.PopState[bracketInfo](tokenizer, "brackets")
+	assert.True(t, ok)
+	assert.Equal(t, '(', top.bracket)
+	assert.Equal(t, 0, state.StackSize("brackets"))
+
+	// Stacks are separate from values
+	state.Set("brackets", 5)
+	assert.Equal(t, 0, state.StackSize("brackets"))
+}
+
+func Test_CallbackState_UsedByCallbacks(t *testing.T) {
+	language := tz.CreateDullLanguage()
+	// Every opening bracket is counted and remembered until it is closed
+	language.ConfigureScope(
+		func(tkzr *tz.Tokenizer) bool {
+			if tkzr.CurrentChar() == '(' || tkzr.CurrentChar() == '[' {
+				count, _ := tz.GetState[int](tkzr, "opened")
+				tkzr.State().Set("opened", count+1)
+				tkzr.State().Push("brackets", bracketInfo{lineNumber: tkzr.GetCurrentLineNumber(), bracket: tkzr.CurrentChar()})
+				tkzr.StartInfo = string(tkzr.CurrentChar())
+				return true
+			}
+			return false
+		},
+		func(tkzr *tz.Tokenizer) bool {
+			top, found := tz.PeekState[bracketInfo](tkzr, "brackets")
+			if !found {
+				return false
+			}
+			if (top.bracket == '(' && tkzr.CurrentChar() == ')') || (top.bracket == '[' && tkzr.CurrentChar() == ']') {
+				tkzr.State().Pop("brackets")
+				tkzr.EndInfo = string(tkzr.CurrentChar())
+				return true
+			}
+			return false
+		},
+	)
+	openedPerRun := make([]int, 0)
+	language.FinalSteps = func(tkzr *tz.Tokenizer, finalScope *tk.ScopeObj) error {
+		opened, _ := tz.GetState[int](tkzr, "opened")
+		openedPerRun = append(openedPerRun, opened)
+		assert.Equal(t, 0, tkzr.State().StackSize("brackets"))
+		return nil
+	}
+
+	tokenizer := tz.NewTokenizer(language)
+	tokensScope, _, err := tokenizer.Tokenize("a(b[c)d]e)")
+	assert.Nil(t, err)
+	// The ")" inside the "[" scope does not close it: a ( {b [ {c ) d} ] e} )
+	assert.Equal(t, 4, tokensScope.Size())
+	assert.Equal(t, 1, tokensScope.GetNumberOfScopes())
+
+	// The state starts empty for every run
+	_, _, err = tokenizer.Tokenize("(x)")
+	assert.Nil(t, err)
+	assert.Equal(t, []int{2, 1}, openedPerRun)
+}
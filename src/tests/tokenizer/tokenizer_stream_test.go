@@ -0,0 +1,209 @@
+package tokenizer_test
+
+import (
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+	javaTokenizer "tp/src/instances/langs/java"
+	pythonTokenizer "tp/src/instances/langs/python"
+	"tp/src/tests"
+	tz "tp/src/tokenizer"
+	tk "tp/src/tokenizer/tokens"
+	"tp/src/util"
+)
+
+// collectEvents
+// Reads every event out of the stream
+func collectEvents(t *testing.T, stream *tz.TokenStream) []tz.TokenEvent {
+	events := make([]tz.TokenEvent, 0)
+	for stream.Next() {
+		events = append(events, stream.Event())
+	}
+	assert.Nil(t, stream.Err())
+	return events
+}
+
+// scopeToEvents
+// Converts a scope into the events which describe it
+func scopeToEvents(scope *tk.ScopeObj) []tz.TokenEvent {
+	events := make([]tz.TokenEvent, 0)
+	for _, tkn := range scope.GetTokenList() {
+		if !tkn.ValidScopeToken() {
+			events = append(events, tz.TokenEvent{Type: tz.EVENT_TOKEN, Token: tkn})
+			continue
+		}
+		scope := tkn.GetScopeToken()
+		events = append(events, tz.TokenEvent{Type: tz.EVENT_SCOPE_OPEN, Token: scope.GetOpener(), Position: scope.GetStart()})
+		events = append(events, scopeToEvents(scope)...)
+		events = append(events, tz.TokenEvent{Type: tz.EVENT_SCOPE_CLOSE, Token: scope.GetCloser(), Position: scope.GetEnd()})
+	}
+	return events
+}
+
+func Test_TokenizeReader_MatchesTokenize(t *testing.T) {
+	files := []struct {
+		path      string
+		tokenizer func() *tz.Tokenizer
+	}{
+		{"../exampleFiles/hello.java", javaTokenizer.GetJavaTokenizer},
+		{"../exampleFiles/file.java", javaTokenizer.GetJavaTokenizer},
+		{"../exampleFiles/unicode.java", javaTokenizer.GetJavaTokenizer},
+		{"../exampleFiles/hello.py", pythonTokenizer.GetPythonTokenizer},
+		{"../exampleFiles/unicode.py", pythonTokenizer.GetPythonTokenizer},
+		{"../exampleFiles/hello.java", tz.CreateDullTokenizer},
+	}
+
+	for _, file := range files {
+		text, err := util.GetTextOfFile(file.path)
+		if err != nil {
+			util.Error(fmt.Sprintf("Failed to find file: %s", file.path), err)
+			assert.Fail(t, "No file found")
+		}
+
+		tokensScope, _, err := file.tokenizer().Tokenize(text)
+		assert.Nil(t, err)
+		expected := scopeToEvents(&tokensScope)
+
+		// Reading a single byte at a time makes sure characters and tokens split across reads are handled
+		stream, err := file.tokenizer().TokenizeReader(iotest.OneByteReader(strings.NewReader(text)))
+		assert.Nil(t, err)
+		actual := collectEvents(t, stream)
+
+		assert.Equal(t, len(expected), len(actual), file.path)
+		for i := 0; i < len(expected) && i < len(actual); i++ {
+			assert.Equal(t, expected[i].Type, actual[i].Type, file.path)
+			assert.Equal(t, expected[i].Position, actual[i].Position, file.path)
+			if expected[i].Type == tz.EVENT_TOKEN {
+				assert.Equal(t, expected[i].Token.ToString(), actual[i].Token.ToString(), file.path)
+			}
+		}
+	}
+}
+
+func Test_TokenizeReader_Events(t *testing.T) {
+	tokenizer := javaTokenizer.GetJavaTokenizer()
+	stream, err := tokenizer.TokenizeReader(strings.NewReader("a { b } c {"))
+	assert.Nil(t, err)
+	events := collectEvents(t, stream)
+
+	assert.Equal(t, 10, len(events))
+	expectedTypes := []tz.TokenEventType{
+		tz.EVENT_TOKEN, tz.EVENT_TOKEN, tz.EVENT_SCOPE_OPEN, tz.EVENT_TOKEN, tz.EVENT_SCOPE_CLOSE,
+		tz.EVENT_TOKEN, tz.EVENT_TOKEN, tz.EVENT_TOKEN, tz.EVENT_SCOPE_OPEN, tz.EVENT_SCOPE_CLOSE,
+	}
+	for i := 0; i < len(events) && i < len(expectedTypes); i++ {
+		assert.Equal(t, expectedTypes[i], events[i].Type)
+	}
+	if len(events) != 10 {
+		return
+	}
+
+	tests.ValidateToken(t, events[0].Token, 1, 0, tz.RULENAME_KEYWORD, "IDENTIFIER", "a")
+	tests.ValidateToken(t, events[1].Token, 1, 0, tz.RULENAME_SYMBOL, "LCURLY", "{")
+	assert.Equal(t, tk.Position{Line: 1, Column: 4, Offset: 3}, events[2].Position)
+	tests.ValidateToken(t, events[3].Token, 1, 0, tz.RULENAME_KEYWORD, "IDENTIFIER", "b")
+	assert.Equal(t, tk.Position{Line: 1, Column: 7, Offset: 6}, events[4].Position)
+	tests.ValidateToken(t, events[5].Token, 1, 0, tz.RULENAME_SYMBOL, "RCURLY", "}")
+	tests.ValidateToken(t, events[6].Token, 1, 0, tz.RULENAME_KEYWORD, "IDENTIFIER", "c")
+	tests.ValidateToken(t, events[7].Token, 1, 0, tz.RULENAME_SYMBOL, "LCURLY", "{")
+	assert.Equal(t, tk.Position{Line: 1, Column: 12, Offset: 11}, events[8].Position)
+	// The scope which is still open is closed at the end of the text
+	assert.Equal(t, tk.Position{Line: 1, Column: 12, Offset: 11}, events[9].Position)
+
+	assert.False(t, stream.Next())
+}
+
+// generatedReader
+// Lazily produces a large amount of java code without ever holding it all in memory
+type generatedReader struct {
+	remainingLines int
+	pending        string
+}
+
+func (gr *generatedReader) Read(p []byte) (int, error) {
+	if gr.pending == "" {
+		if gr.remainingLines == 0 {
+			return 0, io.EOF
+		}
+		gr.remainingLines--
+		gr.pending = fmt.Sprintf("int value%d = %d + 0x1F; /* comment */\n", gr.remainingLines, gr.remainingLines)
+	}
+	n := copy(p, gr.pending)
+	gr.pending = gr.pending[n:]
+	return n, nil
+}
+
+func Test_TokenizeReader_BoundedMemory(t *testing.T) {
+	tokenizer := javaTokenizer.GetJavaTokenizer()
+	// Roughly 1.5MB of text, far more than is allowed to be held in memory at once
+	numberOfLines := 40000
+	stream, err := tokenizer.TokenizeReader(&generatedReader{remainingLines: numberOfLines})
+	assert.Nil(t, err)
+
+	numberOfTokens := 0
+	maxBufferedBytes := 0
+	for stream.Next() {
+		numberOfTokens++
+		if stream.BufferedBytes() > maxBufferedBytes {
+			maxBufferedBytes = stream.BufferedBytes()
+		}
+	}
+	assert.Nil(t, stream.Err())
+
+	// int, name, =, number, +, number, ;, comment
+	assert.Equal(t, numberOfLines*8, numberOfTokens)
+	assert.Less(t, maxBufferedBytes, 256*1024)
+}
+
+func Test_TokenizeReader_TextSize(t *testing.T) {
+	text, err := io.ReadAll(&generatedReader{remainingLines: 2000})
+	assert.Nil(t, err)
+	tokenizer := javaTokenizer.GetJavaTokenizer()
+	stream, err := tokenizer.TokenizeReader(&generatedReader{remainingLines: 2000})
+	assert.Nil(t, err)
+
+	// The size is unknown until the whole text has been read, which it is not just to find the size
+	assert.True(t, stream.Next())
+	assert.Nil(t, tokenizer.Text)
+	assert.Equal(t, tz.TEXT_SIZE_UNKNOWN, tokenizer.TextSize())
+	assert.Less(t, stream.BufferedBytes(), len(text))
+
+	for stream.Next() {
+	}
+	assert.Nil(t, stream.Err())
+	assert.Equal(t, len(text), tokenizer.TextSize())
+}
+
+func Test_TokenizeReader_ReadError(t *testing.T) {
+	tokenizer := javaTokenizer.GetJavaTokenizer()
+	readErr := errors.New("connection lost")
+	reader := io.MultiReader(strings.NewReader("int x"), iotest.ErrReader(readErr))
+	stream, err := tokenizer.TokenizeReader(reader)
+	assert.Nil(t, err)
+
+	events := collectEventsIgnoringError(stream)
+	assert.Equal(t, 2, len(events))
+	assert.ErrorIs(t, stream.Err(), readErr)
+}
+
+// collectEventsIgnoringError
+// Reads every event out of the stream without checking for an error
+func collectEventsIgnoringError(stream *tz.TokenStream) []tz.TokenEvent {
+	events := make([]tz.TokenEvent, 0)
+	for stream.Next() {
+		events = append(events, stream.Event())
+	}
+	return events
+}
+
+func Test_TokenizeReader_NotConfigured(t *testing.T) {
+	tokenizer := tz.CreateDullTokenizer()
+	tokenizer.IsKeywordCharacter = nil
+	stream, err := tokenizer.TokenizeReader(strings.NewReader("text"))
+	assert.NotNil(t, err)
+	assert.Nil(t, stream)
+}
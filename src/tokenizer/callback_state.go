@@ -0,0 +1,121 @@
+package tokenizer
+
+// CallbackState
+// Defines a place for the callbacks of a language to keep their own state while tokenizing.
+// It holds any Go value, either under a key or on a named stack, so callbacks
+// never need to encode their state into strings. A new, empty state is used for every run.
+//
+// values: the values stored under a key
+//
+// stacks: the stacks of values, stored under the name of the stack
+type CallbackState struct {
+	values map[string]any
+	stacks map[string][]any
+}
+
+// newCallbackState
+// Creates an empty callback state
+func newCallbackState() CallbackState {
+	return CallbackState{
+		values: make(map[string]any),
+		stacks: make(map[string][]any),
+	}
+}
+
+// State
+// Returns the state the callbacks of this run may use
+func (tkzr *Tokenizer) State() *CallbackState {
+	if tkzr.state.values == nil {
+		tkzr.state = newCallbackState()
+	}
+	return &tkzr.state
+}
+
+// Set
+// Stores the value under the key, replacing any value already stored under it
+func (cs *CallbackState) Set(key string, value any) {
+	cs.values[key] = value
+}
+
+// Get
+// Returns the value stored under the key, and whether there was one
+func (cs *CallbackState) Get(key string) (any, bool) {
+	value, found := cs.values[key]
+	return value, found
+}
+
+// Delete
+// Removes the value stored under the key, if there is one
+func (cs *CallbackState) Delete(key string) {
+	delete(cs.values, key)
+}
+
+// Push
+// Adds the value to the top of the named stack
+func (cs *CallbackState) Push(stack string, value any) {
+	cs.stacks[stack] = append(cs.stacks[stack], value)
+}
+
+// Peek
+// Returns the value on the top of the named stack, and whether the stack had any values
+func (cs *CallbackState) Peek(stack string) (any, bool) {
+	values := cs.stacks[stack]
+	if len(values) == 0 {
+		return nil, false
+	}
+	return values[len(values)-1], true
+}
+
+// Pop
+// Removes and returns the value on the top of the named stack, and whether the stack had any values
+func (cs *CallbackState) Pop(stack string) (any, bool) {
+	values := cs.stacks[stack]
+	if len(values) == 0 {
+		return nil, false
+	}
+	value := values[len(values)-1]
+	values[len(values)-1] = nil
+	cs.stacks[stack] = values[:len(values)-1]
+	return value, true
+}
+
+// StackSize
+// Returns the number of values on the named stack
+func (cs *CallbackState) StackSize(stack string) int {
+	return len(cs.stacks[stack])
+}
+
+// GetState
+// Returns the value stored under the key as a T.
+// Returns false if there is no value stored under the key or if the value is not a T.
+func GetState[T any](tkzr *Tokenizer, key string) (T, bool) {
+	value, found := tkzr.State().Get(key)
+	return asType[T](value, found)
+}
+
+// PeekState
+// Returns the value on the top of the named stack as a T.
+// Returns false if the stack is empty or if the value is not a T.
+func PeekState[T any](tkzr *Tokenizer, stack string) (T, bool) {
+	value, found := tkzr.State().Peek(stack)
+	return asType[T](value, found)
+}
+
+// PopState
+// Removes the value on the top of the named stack and returns it as a T.
+// Returns false if the stack is empty or if the value is not a T; the value is removed either way.
+func PopState[T any](tkzr *Tokenizer, stack string) (T, bool) {
+	value, found := tkzr.State().Pop(stack)
+	return asType[T](value, found)
+}
+
+// asType
+// Converts a value from the callback state to a T
+func asType[T any](value any, found bool) (T, bool) {
+	if !found {
+		var zero T
+		return zero, false
+	}
+	typedValue, ok := value.(T)
+	return typedValue, ok
+}
@@ -17,11 +17,11 @@ import (
 // ignoreWhitespaces: determines whether whitespace tokens should be ignored, i.e., not added to final output
 //
 // ignoreNewLines: determines whether new line tokens should be ignored, i.e., not added to final output
-func (tkzr *Tokenizer) ConfigureIgnores(ignoreString bool, ignoreComments bool, ignoreWhitespaces bool, ignoreNewLines bool) {
-	tkzr.IncludeStrings = !ignoreString
-	tkzr.IncludeComments = !ignoreComments
-	tkzr.IgnoreWhitespace = ignoreWhitespaces
-	tkzr.IgnoreNewLines = ignoreNewLines
+func (lang *Language) ConfigureIgnores(ignoreString bool, ignoreComments bool, ignoreWhitespaces bool, ignoreNewLines bool) {
+	lang.IncludeStrings = !ignoreString
+	lang.IncludeComments = !ignoreComments
+	lang.IgnoreWhitespace = ignoreWhitespaces
+	lang.IgnoreNewLines = ignoreNewLines
 }
 
 // ConfigureGeneral
@@ -31,17 +31,20 @@ func (tkzr *Tokenizer) ConfigureIgnores(ignoreString bool, ignoreComments bool,
 // language: this parameter takes in a string which assigns the LanguageType variable of the tokenizer object
 //
 // symbols: this parameters will set the symbols which will be discovered by the tokenizer. It expects an array of arrays of strings,
-// which have 2 items, that being the assigned name for the symbol and the symbol itself.
+// which have 2 items, that being the symbol itself and the assigned name for the symbol. Symbols may be made up of multiple
+// characters (e.g. "==" or ">>>="), in which case the longest symbol found at an index is the one used.
 //
 // keywords: this parameter will set the keywords for the tokenizer. It expects an array of strings of all the keywords this language expects.
 //
 // isKeywordCharacterFunction: this parameter expects a function that takes a rune and returns a bool. This should return true for characters
 // which are considered valid characters for keywords in this language.
-func (tkzr *Tokenizer) ConfigureGeneral(language string, symbols [][]string, keywords []string, isKeywordCharacterFunction func(c rune) bool) {
-	tkzr.LanguageType = language
-	tkzr.Symbols = symbols
-	tkzr.Keywords = keywords
-	tkzr.IsKeywordCharacter = isKeywordCharacterFunction
+func (lang *Language) ConfigureGeneral(language string, symbols [][]string, keywords []string, isKeywordCharacterFunction func(c rune) bool) {
+	lang.LanguageType = language
+	lang.Symbols = symbols
+	lang.symbolTrie = buildSymbolTrie(symbols)
+	lang.Keywords = keywords
+	lang.kinds = buildLanguageKinds(symbols, keywords, lang.TokenRules)
+	lang.IsKeywordCharacter = isKeywordCharacterFunction
 }
 
 // ConfigureScope
@@ -54,9 +57,9 @@ func (tkzr *Tokenizer) ConfigureGeneral(language string, symbols [][]string, key
 // endFunction: the parameter expects a function whose one parameter is a pointer to a tokenizer object,
 // and it should return a boolean. It should return true if the index the tokenizer is currently on is the end of a
 // scope object
-func (tkzr *Tokenizer) ConfigureScope(startFunction func(tkzr *Tokenizer) bool, endFunction func(tkzr *Tokenizer) bool) {
-	tkzr.ScopeStartFunction = startFunction
-	tkzr.ScopeEndFunction = endFunction
+func (lang *Language) ConfigureScope(startFunction func(tkzr *Tokenizer) bool, endFunction func(tkzr *Tokenizer) bool) {
+	lang.ScopeStartFunction = startFunction
+	lang.ScopeEndFunction = endFunction
 }
 
 // ConfigureString
@@ -69,9 +72,9 @@ func (tkzr *Tokenizer) ConfigureScope(startFunction func(tkzr *Tokenizer) bool,
 // endFunction: the parameter expects a function whose one parameter is a pointer to a tokenizer object,
 // and it should return a boolean. It should return true if the index the tokenizer is currently on is the end of a
 // string (or similar object)
-func (tkzr *Tokenizer) ConfigureString(startFunction func(tkzr *Tokenizer) bool, endFunction func(tkzr *Tokenizer) bool) {
-	tkzr.StringStartFunction = startFunction
-	tkzr.StringEndFunction = endFunction
+func (lang *Language) ConfigureString(startFunction func(tkzr *Tokenizer) bool, endFunction func(tkzr *Tokenizer) bool) {
+	lang.StringStartFunction = startFunction
+	lang.StringEndFunction = endFunction
 }
 
 // ConfigureComment
@@ -84,56 +87,75 @@ func (tkzr *Tokenizer) ConfigureString(startFunction func(tkzr *Tokenizer) bool,
 // endFunction: the parameter expects a function whose one parameter is a pointer to a tokenizer object,
 // and it should return a boolean. It should return true if the index the tokenizer is currently on is the end of a
 // comment
-func (tkzr *Tokenizer) ConfigureComment(startFunction func(tkzr *Tokenizer) bool, endFunction func(tkzr *Tokenizer) bool) {
-	tkzr.CommentStartFunction = startFunction
-	tkzr.CommentEndFunction = endFunction
+func (lang *Language) ConfigureComment(startFunction func(tkzr *Tokenizer) bool, endFunction func(tkzr *Tokenizer) bool) {
+	lang.CommentStartFunction = startFunction
+	lang.CommentEndFunction = endFunction
+}
+
+// ConfigureNumbers
+// This function sets up how number literals are recognized. Numbers are found
+// wherever a token could begin (i.e. not in the middle of a keyword) and are given the
+// tk.KIND_NUMBER kind. This method is not necessary to be run; if it is never run,
+// digits are treated like any other keyword character.
+//
+// rules: the parameter expects a pointer to the rules for number literals in this language. Passing nil disables number literals
+func (lang *Language) ConfigureNumbers(rules *NumberLiteralRules) {
+	lang.NumberRules = rules
 }
 
 // IsConfigured
-// This determines whether the tokenizer is fully set up, as in, everything that needs to be configured is configured.
+// This determines whether the language is fully set up, as in, everything that needs to be configured is configured.
 //
 // If it finds that it is not configured correctly, it will return an error
-func (tkzr *Tokenizer) IsConfigured() error {
+func (lang *Language) IsConfigured() error {
+	if lang == nil {
+		return errors.New("Language not configured correctly (cannot be nil)... USE NewTokenizer with a configured Language to fix\n")
+	}
 	errorString := ""
 
 	// General Configure
-	if tkzr.LanguageType == "" {
+	if lang.LanguageType == "" {
 		errorString += fmt.Sprintf("LanguageType not configured correctly (cannot be empty string)... USE .ConfigureGeneral to fix\n")
 	}
-	if tkzr.Symbols == nil {
+	if lang.Symbols == nil {
 		errorString += fmt.Sprintf("Symbols not configured correctly (cannot be nil)... USE .ConfigureGeneral to fix\n")
 	}
-	if tkzr.Keywords == nil {
+	if lang.Keywords == nil {
 		errorString += fmt.Sprintf("Keywords not configured correctly (cannot be nil)... USE .ConfigureGeneral to fix\n")
 	}
-	if tkzr.IsKeywordCharacter == nil {
+	if lang.IsKeywordCharacter == nil {
 		errorString += fmt.Sprintf("IsKeywordsCharacter not configured correctly (cannot be nil)... USE .ConfigureGeneral to fix\n")
 	}
 
 	// Scope Configure
-	if tkzr.ScopeStartFunction == nil {
+	if lang.ScopeStartFunction == nil {
 		errorString += fmt.Sprintf("ScopeStartFunction not configured correctly (cannot be nil)... USE .ConfigureScope to fix\n")
 	}
-	if tkzr.ScopeEndFunction == nil {
+	if lang.ScopeEndFunction == nil {
 		errorString += fmt.Sprintf("ScopeEndFunction not configured correctly (cannot be nil)... USE .ConfigureScope to fix\n")
 	}
 
 	// String Configure
-	if tkzr.StringStartFunction == nil {
+	if lang.StringStartFunction == nil {
 		errorString += fmt.Sprintf("StringStartFunction not configured correctly (cannot be nil)... USE .ConfigureString to fix\n")
 	}
-	if tkzr.StringEndFunction == nil {
+	if lang.StringEndFunction == nil {
 		errorString += fmt.Sprintf("StringEndFunction not configured correctly (cannot be nil)... USE .ConfigureString to fix\n")
 	}
 
 	// Comment Configure
-	if tkzr.CommentStartFunction == nil {
+	if lang.CommentStartFunction == nil {
 		errorString += fmt.Sprintf("CommentStartFunction not configured correctly (cannot be nil)... USE .ConfigureComment to fix\n")
 	}
-	if tkzr.CommentEndFunction == nil {
+	if lang.CommentEndFunction == nil {
 		errorString += fmt.Sprintf("CommentEndFunction not configured correctly (cannot be nil)... USE .ConfigureComment to fix\n")
 	}
 
+	// Whitespace Configure
+	if lang.NumOfSpacesEquallyTab <= 0 {
+		errorString += fmt.Sprintf("NumOfSpacesEquallyTab not configured correctly (must be greater than 0)... SET .NumOfSpacesEquallyTab to fix\n")
+	}
+
 	if errorString != "" {
 		err := errors.New(errorString)
 		return err
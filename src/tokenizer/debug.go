@@ -8,7 +8,7 @@ import (
 // PrintCharIndices
 // Prints out all the characters and their indices of the text for the sake of debugging
 func (tkzr *Tokenizer) PrintCharIndices() {
-	for i := 0; i < tkzr.TextSize(); i++ {
+	for i := 0; tkzr.DetermineIfIndexInBound(i); i = tkzr.NextIndex(i) {
 		char := tkzr.GetChar(i)
 		fmt.Printf("%d\t:\t%d\n", i, int(char))
 	}
@@ -33,15 +33,14 @@ func (tkzr *Tokenizer) PrintCurrentInfo(args ...string) {
 	fmt.Println("\tFUNCTION INFO: ")
 	fmt.Printf("\t\tStartInfo: %s\tEndInfo:%s\tFunctionSharedInfo: %s\n", tkzr.StartInfo, tkzr.EndInfo, tkzr.FunctionSharedInfo)
 	fmt.Println("\tCURRENT SCOPE:")
-	fmt.Printf("\t\tSize: %d\tType: %s\n", tkzr.currentScope.Size(), tkzr.currentScope.GetType())
+	fmt.Printf("\t\tDepth: %d\tPending Events: %d\n", len(tkzr.openScopes), len(tkzr.events)-tkzr.nextEvent)
 	lastFewTokens := ""
 	numberOfPreviousTokens := 5
-	for i := tkzr.currentScope.Size() - 1; i > 0 && i > tkzr.currentScope.Size()-1-numberOfPreviousTokens; i-- {
-		tkn, err := tkzr.currentScope.At(i)
-		if err != nil {
+	for i := len(tkzr.events) - 1; i >= 0 && i > len(tkzr.events)-1-numberOfPreviousTokens; i-- {
+		if tkzr.events[i].Type != EVENT_TOKEN {
 			continue
 		}
-		lastFewTokens += tkn.ToString() + "\t"
+		lastFewTokens += tkzr.events[i].Token.ToString() + "\t"
 	}
 	fmt.Printf("\t\tLast few tokens: %s\n", lastFewTokens)
 	fmt.Println("------------------------------------------------------")
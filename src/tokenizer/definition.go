@@ -0,0 +1,468 @@
+package tokenizer
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// The formats a language definition may be written in
+const (
+	DEFINITION_FORMAT_JSON = "json"
+	DEFINITION_FORMAT_YAML = "yaml"
+)
+
+// LanguageDefinition
+// Defines a language as data, so that a language can be written in a JSON or YAML file rather than in Go.
+// A definition is turned into a configured Language with BuildLanguage, which generates the callbacks
+// a language would otherwise have to write itself.
+//
+// Name: the LanguageType of the language
+//
+// Keywords: the keywords of the language
+//
+// Symbols: the symbols of the language, each being the symbol itself followed by its name (e.g. ["(", "LParen"]),
+// just like the symbols given to ConfigureGeneral
+//
+// Identifiers: which characters may be part of a keyword or name. If it is not provided, letters, digits and underscores may be
+//
+// Numbers: how number literals are written. If it is not provided, digits are treated like any other keyword character
+//
+// Comments: the kinds of comments in the language
+//
+// Strings: the kinds of strings (and characters) in the language
+//
+// Scopes: the pairs of delimiters which open and close scopes (e.g. "{" and "}")
+//
+// IndentationScopes: how scopes are opened when they are closed by indentation rather than by a delimiter, like in Python.
+// A language may either use Scopes or IndentationScopes, not both
+//
+// Indentation: how the indentation of the language is tracked (see IndentationRules). If it is not provided,
+// indentation is only tracked for IndentationScopes, without any brackets or line continuation
+//
+// TokenRules: the regular expression rules of the language (see TokenRule)
+//
+// Options: the options of the language which would otherwise be left at their defaults
+type LanguageDefinition struct {
+	Name              string                      `json:"name" yaml:"name"`
+	Keywords          []string                    `json:"keywords,omitempty" yaml:"keywords,omitempty"`
+	Symbols           [][]string                  `json:"symbols,omitempty" yaml:"symbols,omitempty"`
+	Identifiers       *IdentifierDefinition       `json:"identifiers,omitempty" yaml:"identifiers,omitempty"`
+	Numbers           *NumberLiteralRules         `json:"numbers,omitempty" yaml:"numbers,omitempty"`
+	Comments          []CommentDefinition         `json:"comments,omitempty" yaml:"comments,omitempty"`
+	Strings           []StringDefinition          `json:"strings,omitempty" yaml:"strings,omitempty"`
+	Scopes            []ScopeDefinition           `json:"scopes,omitempty" yaml:"scopes,omitempty"`
+	IndentationScopes *IndentationScopeDefinition `json:"indentationScopes,omitempty" yaml:"indentationScopes,omitempty"`
+	Indentation       *IndentationRules           `json:"indentation,omitempty" yaml:"indentation,omitempty"`
+	TokenRules        []TokenRule                 `json:"tokenRules,omitempty" yaml:"tokenRules,omitempty"`
+	Options           LanguageDefinitionOptions   `json:"options,omitempty" yaml:"options,omitempty"`
+}
+
+// IdentifierDefinition
+// Defines which characters may be part of a keyword or name
+//
+// Letters: whether unicode letters may be
+//
+// Digits: whether unicode digits may be
+//
+// Extra: any other characters which may be (e.g. "_$")
+type IdentifierDefinition struct {
+	Letters bool   `json:"letters" yaml:"letters"`
+	Digits  bool   `json:"digits" yaml:"digits"`
+	Extra   string `json:"extra,omitempty" yaml:"extra,omitempty"`
+}
+
+// CommentDefinition
+// Defines a kind of comment
+//
+// Start: the text which starts the comment (e.g. "//" or "/*")
+//
+// End: the text which ends the comment (e.g. "*/"). If it is empty or a newline, the comment ends with its line
+//
+// Nested: whether comments of this kind may be placed within each other, in which case the comment only ends
+// once every comment started within it has ended. Line comments cannot be nested
+type CommentDefinition struct {
+	Start  string `json:"start" yaml:"start"`
+	End    string `json:"end,omitempty" yaml:"end,omitempty"`
+	Nested bool   `json:"nested,omitempty" yaml:"nested,omitempty"`
+}
+
+// StringDefinition
+// Defines a kind of string
+//
+// Start: the text which starts the string (e.g. "\"" or "\"\"\"")
+//
+// End: the text which ends the string
+//
+// Escape: the character which escapes the character after it, so an escaped End does not end the string (e.g. "\\").
+// If it is empty, nothing can be escaped
+//
+// Multiline: whether the string may span multiple lines. Strings which cannot are ended with their line in recovery mode
+//
+// Prefixes: the texts which may come right before the Start as part of the string (e.g. the "f" or "rb" of Python strings).
+// Prefixes are matched case-insensitively, and only at the start of a word. If it is empty, the string has no prefix;
+// otherwise the string always has one of them, which may be "" for the string without a prefix
+//
+// Interpolation: the expressions which may be placed within the string. If it is nil, the string is a single token
+type StringDefinition struct {
+	Start         string                   `json:"start" yaml:"start"`
+	End           string                   `json:"end" yaml:"end"`
+	Escape        string                   `json:"escape,omitempty" yaml:"escape,omitempty"`
+	Multiline     bool                     `json:"multiline,omitempty" yaml:"multiline,omitempty"`
+	Prefixes      []string                 `json:"prefixes,omitempty" yaml:"prefixes,omitempty"`
+	Interpolation *InterpolationDefinition `json:"interpolation,omitempty" yaml:"interpolation,omitempty"`
+}
+
+// InterpolationDefinition
+// Defines the expressions placed within a string (e.g. the ${name} of `Hello ${name}`).
+// The string is split around each expression, and the tokens of the expression are placed in a scope
+// opened by the Start and closed by the End (see Tokenizer.Interpolate).
+//
+// Start: the text which begins an expression (e.g. "${"). An escaped Start does not begin an expression
+//
+// End: the text which ends an expression (e.g. "}"). It only ends the expression once every scope opened within it is closed
+//
+// Doubled: whether a doubled Start is literal text rather than the beginning of an expression (e.g. the "{{" of Python f-strings)
+//
+// Scopes: the scopes within an expression. If it is empty, the language's scopes are used, except
+// that a language with indentation scopes has no scopes within an expression
+type InterpolationDefinition struct {
+	Start   string            `json:"start" yaml:"start"`
+	End     string            `json:"end" yaml:"end"`
+	Doubled bool              `json:"doubled,omitempty" yaml:"doubled,omitempty"`
+	Scopes  []ScopeDefinition `json:"scopes,omitempty" yaml:"scopes,omitempty"`
+}
+
+// ScopeDefinition
+// Defines a pair of delimiters which open and close a scope.
+// A scope is only closed by the End of the pair which opened it.
+//
+// Start: the text which opens the scope (e.g. "{")
+//
+// End: the text which closes the scope (e.g. "}")
+type ScopeDefinition struct {
+	Start string `json:"start" yaml:"start"`
+	End   string `json:"end" yaml:"end"`
+}
+
+// IndentationScopeDefinition
+// Defines scopes which are closed by indentation. A scope is opened by the Opener and is closed
+// at the first later logical line which is indented no more than the logical line which opened it.
+//
+// Opener: the text which opens a scope (e.g. ":"). Within the brackets of the Indentation, it does not
+//
+// Exceptions: texts beginning with the Opener which do not open a scope (e.g. ":=")
+type IndentationScopeDefinition struct {
+	Opener     string   `json:"opener" yaml:"opener"`
+	Exceptions []string `json:"exceptions,omitempty" yaml:"exceptions,omitempty"`
+}
+
+// LanguageDefinitionOptions
+// Defines the options of a language. Options which are not provided keep the defaults of GenerateDefaultLanguageObject.
+//
+// TabSize: the number of spaces equal to a tab
+//
+// IncludeStrings: whether string tokens are added to the output
+//
+// IncludeComments: whether comment tokens are added to the output
+//
+// IgnoreWhitespace: whether whitespace tokens are left out of the output
+//
+// IgnoreNewLines: whether newline tokens are left out of the output
+//
+// UnicodeLineBreaks: whether the line separator (U+2028) and paragraph separator (U+2029) end lines
+type LanguageDefinitionOptions struct {
+	TabSize           int   `json:"tabSize,omitempty" yaml:"tabSize,omitempty"`
+	IncludeStrings    *bool `json:"includeStrings,omitempty" yaml:"includeStrings,omitempty"`
+	IncludeComments   *bool `json:"includeComments,omitempty" yaml:"includeComments,omitempty"`
+	IgnoreWhitespace  *bool `json:"ignoreWhitespace,omitempty" yaml:"ignoreWhitespace,omitempty"`
+	IgnoreNewLines    *bool `json:"ignoreNewLines,omitempty" yaml:"ignoreNewLines,omitempty"`
+	UnicodeLineBreaks bool  `json:"unicodeLineBreaks,omitempty" yaml:"unicodeLineBreaks,omitempty"`
+}
+
+// ParseLanguageDefinition
+// Reads a language definition written in the provided format (DEFINITION_FORMAT_JSON or DEFINITION_FORMAT_YAML).
+// Fields which are not part of a definition are reported as errors, so misspelled fields are not silently ignored.
+// The definition is validated, so any error in it is found before it is built.
+func ParseLanguageDefinition(data []byte, format string) (*LanguageDefinition, error) {
+	definition := &LanguageDefinition{}
+	switch format {
+	case DEFINITION_FORMAT_JSON:
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(definition); err != nil {
+			return nil, fmt.Errorf("could not parse JSON language definition: %w", err)
+		}
+	case DEFINITION_FORMAT_YAML:
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(definition); err != nil {
+			return nil, fmt.Errorf("could not parse YAML language definition: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unknown language definition format %q (expected %q or %q)", format, DEFINITION_FORMAT_JSON, DEFINITION_FORMAT_YAML)
+	}
+
+	if err := definition.Validate(); err != nil {
+		return nil, err
+	}
+	return definition, nil
+}
+
+// LoadLanguageDefinition
+// Reads a language definition from a file. The format is determined by the extension of the file
+// (.json for JSON, .yaml or .yml for YAML).
+func LoadLanguageDefinition(path string) (*LanguageDefinition, error) {
+	var format string
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		format = DEFINITION_FORMAT_JSON
+	case ".yaml", ".yml":
+		format = DEFINITION_FORMAT_YAML
+	default:
+		return nil, fmt.Errorf("cannot determine the format of language definition %q (expected a .json, .yaml or .yml file)", path)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	definition, err := ParseLanguageDefinition(data, format)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return definition, nil
+}
+
+// LoadTokenizer
+// Reads a language definition from a file (see LoadLanguageDefinition), and creates a tokenizer for the language it defines
+func LoadTokenizer(path string) (*Tokenizer, error) {
+	definition, err := LoadLanguageDefinition(path)
+	if err != nil {
+		return nil, err
+	}
+	lang, err := definition.BuildLanguage()
+	if err != nil {
+		return nil, err
+	}
+	return NewTokenizer(lang), nil
+}
+
+// Validate
+// Determines whether the definition can be built into a language.
+// If it cannot, an error describing the first problem found is returned.
+func (def *LanguageDefinition) Validate() error {
+	if def.Name == "" {
+		return errors.New("language definition has no name")
+	}
+	invalid := func(format string, args ...any) error {
+		return fmt.Errorf("language definition %q: %s", def.Name, fmt.Sprintf(format, args...))
+	}
+
+	for i, keyword := range def.Keywords {
+		if keyword == "" {
+			return invalid("keyword %d is empty", i)
+		}
+	}
+	for i, symbol := range def.Symbols {
+		if len(symbol) != 2 {
+			return invalid("symbol %d should be the symbol followed by its name, found %d values", i, len(symbol))
+		}
+		if symbol[0] == "" || symbol[1] == "" {
+			return invalid("symbol %d has an empty symbol or name", i)
+		}
+	}
+	if def.Numbers != nil {
+		for prefix, digits := range def.Numbers.RadixPrefixes {
+			if prefix == "" || digits == "" {
+				return invalid("number radix prefixes cannot have an empty prefix or empty digits")
+			}
+		}
+	}
+	for i, comment := range def.Comments {
+		if comment.Start == "" {
+			return invalid("comment %d has no start", i)
+		}
+		if comment.Nested && comment.isLineComment() {
+			return invalid("comment %d is nested but ends with its line", i)
+		}
+	}
+	for i, str := range def.Strings {
+		if str.Start == "" || str.End == "" {
+			return invalid("string %d needs both a start and an end", i)
+		}
+		if str.Escape != "" && utf8.RuneCountInString(str.Escape) != 1 {
+			return invalid("string %d has escape %q, which should be a single character", i, str.Escape)
+		}
+		if str.Interpolation != nil {
+			if str.Interpolation.Start == "" || str.Interpolation.End == "" {
+				return invalid("string %d has an interpolation which needs both a start and an end", i)
+			}
+			for j, scope := range str.Interpolation.Scopes {
+				if scope.Start == "" || scope.End == "" {
+					return invalid("string %d has interpolation scope %d, which needs both a start and an end", i, j)
+				}
+			}
+		}
+	}
+	for i, scope := range def.Scopes {
+		if scope.Start == "" || scope.End == "" {
+			return invalid("scope %d needs both a start and an end", i)
+		}
+	}
+	if def.IndentationScopes != nil {
+		if len(def.Scopes) > 0 {
+			return invalid("scopes cannot be both delimited and indentation based")
+		}
+		if def.IndentationScopes.Opener == "" {
+			return invalid("indentation scopes have no opener")
+		}
+	}
+	if def.Indentation != nil {
+		for i, bracket := range def.Indentation.Brackets {
+			if bracket[0] == "" || bracket[1] == "" {
+				return invalid("indentation bracket %d needs both an opening and a closing text", i)
+			}
+		}
+		if def.Indentation.TabSize < 0 {
+			return invalid("indentation tab size cannot be negative")
+		}
+	}
+	ruleNames := make(map[string]bool, len(def.TokenRules))
+	for _, rule := range def.TokenRules {
+		if _, err := compileTokenRule(rule); err != nil {
+			return invalid("%v", err)
+		}
+		if ruleNames[rule.Name] {
+			return invalid("token rule %q is defined more than once", rule.Name)
+		}
+		ruleNames[rule.Name] = true
+	}
+	if def.Options.TabSize < 0 {
+		return invalid("tab size cannot be negative")
+	}
+	return nil
+}
+
+// BuildLanguage
+// Creates a configured language from the definition, generating the callbacks for its comments, strings and scopes
+func (def *LanguageDefinition) BuildLanguage() (*Language, error) {
+	if err := def.Validate(); err != nil {
+		return nil, err
+	}
+
+	lang := GenerateDefaultLanguageObject()
+	symbols := def.Symbols
+	if symbols == nil {
+		symbols = [][]string{}
+	}
+	keywords := def.Keywords
+	if keywords == nil {
+		keywords = []string{}
+	}
+	lang.ConfigureGeneral(def.Name, symbols, keywords, def.Identifiers.isKeywordCharacterFunction())
+	if def.Numbers != nil {
+		numbers := *def.Numbers
+		lang.ConfigureNumbers(&numbers)
+	}
+
+	for _, rule := range def.TokenRules {
+		if err := lang.ConfigureTokenRule(rule); err != nil {
+			return nil, err
+		}
+	}
+
+	rules := newDefinitionRules(def)
+	lang.ConfigureComment(rules.commentStart, rules.commentEnd)
+	lang.ConfigureString(rules.stringStart, rules.stringEnd)
+	if def.IndentationScopes != nil {
+		lang.ScopesEndWithText = true
+		lang.ConfigureScope(rules.indentationScopeStart, rules.indentationScopeEnd)
+	} else {
+		lang.ConfigureScope(rules.scopeStart, rules.scopeEnd)
+	}
+	if def.Indentation != nil {
+		lang.ConfigureIndentation(*def.Indentation)
+	} else if def.IndentationScopes != nil {
+		lang.ConfigureIndentation(IndentationRules{})
+	}
+	for i := range rules.strings {
+		if interpolation := rules.strings[i].Interpolation; interpolation != nil {
+			lang.ConfigureMode(rules.stringModes[i], rules.interpolationMode(interpolation, def.IndentationScopes != nil))
+		}
+	}
+	for _, comment := range def.Comments {
+		if comment.Nested {
+			lang.NestedComments = append(lang.NestedComments, comment.Start)
+		}
+	}
+	for _, str := range def.Strings {
+		if str.Multiline {
+			continue
+		}
+		for _, prefix := range str.prefixes() {
+			lang.SingleLineStrings = append(lang.SingleLineStrings, prefix+str.Start)
+		}
+	}
+
+	options := def.Options
+	if options.TabSize > 0 {
+		lang.NumOfSpacesEquallyTab = options.TabSize
+	}
+	if options.IncludeStrings != nil {
+		lang.IncludeStrings = *options.IncludeStrings
+	}
+	if options.IncludeComments != nil {
+		lang.IncludeComments = *options.IncludeComments
+	}
+	if options.IgnoreWhitespace != nil {
+		lang.IgnoreWhitespace = *options.IgnoreWhitespace
+	}
+	if options.IgnoreNewLines != nil {
+		lang.IgnoreNewLines = *options.IgnoreNewLines
+	}
+	lang.UnicodeLineBreaks = options.UnicodeLineBreaks
+
+	if err := lang.IsConfigured(); err != nil {
+		return nil, err
+	}
+	return &lang, nil
+}
+
+// isKeywordCharacterFunction
+// Returns the function which determines whether a character may be part of a keyword.
+// With no definition, letters, digits and underscores may be.
+func (id *IdentifierDefinition) isKeywordCharacterFunction() func(c rune) bool {
+	if id == nil {
+		return func(c rune) bool {
+			return unicode.IsLetter(c) || unicode.IsDigit(c) || c == '_'
+		}
+	}
+	letters, digits, extra := id.Letters, id.Digits, id.Extra
+	return func(c rune) bool {
+		return (letters && unicode.IsLetter(c)) || (digits && unicode.IsDigit(c)) || strings.ContainsRune(extra, c)
+	}
+}
+
+// prefixes
+// Returns the prefixes the string may have, which is only "" for a string without prefixes
+func (str *StringDefinition) prefixes() []string {
+	if len(str.Prefixes) == 0 {
+		return []string{""}
+	}
+	return str.Prefixes
+}
+
+// isLineComment
+// Returns true if the comment ends with its line
+func (comment *CommentDefinition) isLineComment() bool {
+	return comment.End == "" || comment.End == "\n"
+}
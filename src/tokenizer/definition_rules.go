@@ -0,0 +1,414 @@
+package tokenizer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"tp/src/util"
+	"unicode"
+	"unicode/utf8"
+)
+
+// The keys and stacks the callbacks generated from a language definition use within the callback state
+const (
+	definitionCommentKey     = "definition.comment"
+	definitionCommentLineKey = "definition.commentLine"
+	definitionStringStack    = "definition.strings"
+	definitionScopeStack     = "definition.scopes"
+
+	definitionInterpolationScopeStack = "definition.interpolationScopes"
+)
+
+// definitionRules
+// Holds the parts of a language definition which its generated callbacks use.
+// Delimiters are ordered longest first, so that the longest delimiter found at an index
+// is the one used (e.g. "\"\"\"" rather than "\""). It is never modified once created,
+// so languages built from the same definition may be used from many goroutines at once.
+//
+// stringModes: the name of the mode the expressions interpolated into each string are tokenized in
+//
+// scopeStack: the stack of the callback state the open scopes are kept on
+//
+// commentStarts, stringStarts, scopeStarts, scopeEnds, openerStarts: the characters each kind of delimiter begins with,
+// which let the callbacks pass over an index without comparing the text there with every delimiter
+type definitionRules struct {
+	comments          []CommentDefinition
+	strings           []StringDefinition
+	stringModes       []string
+	scopes            []ScopeDefinition
+	scopeStack        string
+	indentationScopes IndentationScopeDefinition
+
+	commentStarts delimiterChars
+	stringStarts  delimiterChars
+	scopeStarts   delimiterChars
+	scopeEnds     delimiterChars
+	openerStarts  delimiterChars
+}
+
+// delimiterChars
+// The set of characters a group of delimiters begins with
+//
+// other: whether a delimiter begins with a character outside of ASCII (or is empty), which every such character is assumed to begin
+type delimiterChars struct {
+	ascii [utf8.RuneSelf]bool
+	other bool
+}
+
+// add
+// Adds the character the delimiter begins with. If foldCase is true, the delimiter is matched case-insensitively,
+// so every case of the character is added.
+func (chars *delimiterChars) add(delimiter string, foldCase bool) {
+	first, _ := utf8.DecodeRuneInString(delimiter)
+	if delimiter == "" || first >= utf8.RuneSelf {
+		chars.other = true
+		return
+	}
+	chars.ascii[first] = true
+	if !foldCase {
+		return
+	}
+	for folded := unicode.SimpleFold(first); folded != first; folded = unicode.SimpleFold(folded) {
+		if folded >= utf8.RuneSelf {
+			chars.other = true
+		} else {
+			chars.ascii[folded] = true
+		}
+	}
+}
+
+// contains
+// Returns true if a delimiter may begin with the character
+func (chars *delimiterChars) contains(char rune) bool {
+	if char >= 0 && char < utf8.RuneSelf {
+		return chars.ascii[char]
+	}
+	return chars.other
+}
+
+// definitionScope
+// Defines where an indentation based scope was opened, which determines where it is closed
+//
+// indentation: the indentation of the logical line the scope was opened on
+type definitionScope struct {
+	indentation int
+}
+
+// newDefinitionRules
+// Copies the delimiters out of the definition, ordering them longest first
+func newDefinitionRules(def *LanguageDefinition) *definitionRules {
+	rules := &definitionRules{
+		comments: append([]CommentDefinition(nil), def.Comments...),
+		strings:  append([]StringDefinition(nil), def.Strings...),
+		scopes:   append([]ScopeDefinition(nil), def.Scopes...),
+
+		scopeStack: definitionScopeStack,
+	}
+	if def.IndentationScopes != nil {
+		rules.indentationScopes = *def.IndentationScopes
+	}
+	sort.SliceStable(rules.comments, func(i, j int) bool {
+		return len(rules.comments[i].Start) > len(rules.comments[j].Start)
+	})
+	sort.SliceStable(rules.strings, func(i, j int) bool {
+		return len(rules.strings[i].Start) > len(rules.strings[j].Start)
+	})
+	sortScopes(rules.scopes)
+	rules.stringModes = make([]string, len(rules.strings))
+	for i := range rules.strings {
+		rules.stringModes[i] = fmt.Sprintf("definition.interpolation.%d", i)
+	}
+	rules.collectDelimiterChars()
+	return rules
+}
+
+// collectDelimiterChars
+// Collects the characters each kind of delimiter begins with
+func (rules *definitionRules) collectDelimiterChars() {
+	for _, comment := range rules.comments {
+		rules.commentStarts.add(comment.Start, false)
+	}
+	for i := range rules.strings {
+		str := &rules.strings[i]
+		for _, prefix := range str.prefixes() {
+			rules.stringStarts.add(prefix+str.Start, true)
+		}
+	}
+	for _, scope := range rules.scopes {
+		rules.scopeStarts.add(scope.Start, false)
+		rules.scopeEnds.add(scope.End, false)
+	}
+	rules.openerStarts.add(rules.indentationScopes.Opener, false)
+}
+
+// sortScopes
+// Orders the scope pairs longest start first
+func sortScopes(scopes []ScopeDefinition) {
+	sort.SliceStable(scopes, func(i, j int) bool {
+		return len(scopes[i].Start) > len(scopes[j].Start)
+	})
+}
+
+// interpolationMode
+// Creates the mode the expressions interpolated into a string are tokenized in, which ends at the interpolation's End
+func (rules *definitionRules) interpolationMode(interpolation *InterpolationDefinition, indentationScopes bool) LexerMode {
+	end := interpolation.End
+	mode := LexerMode{
+		EndFunction: func(tkzr *Tokenizer) bool {
+			if !tkzr.hasPrefixAt(tkzr.Index(), end) {
+				return false
+			}
+			tkzr.EndInfo = end
+			return true
+		},
+	}
+	if len(interpolation.Scopes) > 0 {
+		scopes := &definitionRules{scopes: append([]ScopeDefinition(nil), interpolation.Scopes...), scopeStack: definitionInterpolationScopeStack}
+		sortScopes(scopes.scopes)
+		scopes.collectDelimiterChars()
+		mode.ScopeStartFunction = scopes.scopeStart
+		mode.ScopeEndFunction = scopes.scopeEnd
+	} else if indentationScopes {
+		noScope := func(tkzr *Tokenizer) bool { return false }
+		mode.ScopeStartFunction = noScope
+		mode.ScopeEndFunction = noScope
+	}
+	return mode
+}
+
+// skipDelimiter
+// Moves the index to the last character of the delimiter which begins at the current index,
+// so the contents of what the delimiter starts begin right after it
+func skipDelimiter(tkzr *Tokenizer, delimiter string) {
+	for i := 1; i < utf8.RuneCountInString(delimiter); i++ {
+		tkzr.IncrementIndex()
+	}
+}
+
+// matchesDelimiter
+// Returns true if the delimiter begins at the index. Delimiters which begin or end with keyword characters
+// (e.g. "begin") are words, so they only match when they are not part of a longer keyword (e.g. "beginning").
+func (tkzr *Tokenizer) matchesDelimiter(index int, delimiter string) bool {
+	if !tkzr.hasPrefixAt(index, delimiter) {
+		return false
+	}
+	first, _ := utf8.DecodeRuneInString(delimiter)
+	if tkzr.IsKeywordCharacter(first) && index > 0 && tkzr.IsKeywordCharacter(tkzr.GetPreviousChar(index)) {
+		return false
+	}
+	last, _ := utf8.DecodeLastRuneInString(delimiter)
+	end := index + len(delimiter)
+	if tkzr.IsKeywordCharacter(last) && tkzr.DetermineIfIndexInBound(end) && tkzr.IsKeywordCharacter(tkzr.GetChar(end)) {
+		return false
+	}
+	return true
+}
+
+// commentStart
+// Starts a comment if one of the definition's comments begins at the current index
+func (rules *definitionRules) commentStart(tkzr *Tokenizer) bool {
+	if !rules.commentStarts.contains(tkzr.CurrentChar()) {
+		return false
+	}
+	for i := range rules.comments {
+		comment := &rules.comments[i]
+		if !tkzr.matchesDelimiter(tkzr.Index(), comment.Start) {
+			continue
+		}
+
+		tkzr.StartInfo = comment.Start
+		tkzr.EndInfo = comment.End
+		if comment.isLineComment() {
+			tkzr.EndInfo = "\n"
+			tkzr.State().Set(definitionCommentLineKey, tkzr.GetCurrentLineNumber())
+		}
+		tkzr.State().Set(definitionCommentKey, comment)
+		skipDelimiter(tkzr, comment.Start)
+		return true
+	}
+	return false
+}
+
+// commentEnd
+// Determines whether the comment which was started ends at the current index.
+// Comments nested within a nested comment are dealt with by the tokenizer (see Language.NestedComments).
+func (rules *definitionRules) commentEnd(tkzr *Tokenizer) bool {
+	comment, found := GetState[*CommentDefinition](tkzr, definitionCommentKey)
+	if !found {
+		return false
+	}
+	if comment.isLineComment() {
+		lineNumber, _ := GetState[int](tkzr, definitionCommentLineKey)
+		return tkzr.GetCurrentLineNumber() != lineNumber
+	}
+
+	return tkzr.hasPrefixAt(tkzr.Index(), comment.End)
+}
+
+// stringStart
+// Starts a string if one of the definition's strings begins at the current index, using the longest start found
+// (including its prefix). The string is pushed onto a stack, as strings may begin within expressions interpolated
+// into other strings; any string left on the stack by a string which was never ended is removed first.
+func (rules *definitionRules) stringStart(tkzr *Tokenizer) bool {
+	if !rules.stringStarts.contains(tkzr.CurrentChar()) {
+		return false
+	}
+	index := tkzr.Index()
+	match, matchLength := -1, 0
+	for i := range rules.strings {
+		if length := tkzr.matchStringStart(index, &rules.strings[i]); length > matchLength {
+			match, matchLength = i, length
+		}
+	}
+	if match == -1 {
+		return false
+	}
+
+	tkzr.StartInfo = tkzr.textSlice(index, index+matchLength)
+	tkzr.EndInfo = rules.strings[match].End
+	for tkzr.State().StackSize(definitionStringStack) > tkzr.InterpolationDepth() {
+		tkzr.State().Pop(definitionStringStack)
+	}
+	tkzr.State().Push(definitionStringStack, match)
+	skipDelimiter(tkzr, tkzr.StartInfo)
+	return true
+}
+
+// matchStringStart
+// Returns the length of the longest start of the string (including its prefix) which begins at the index, or 0 if none do
+func (tkzr *Tokenizer) matchStringStart(index int, str *StringDefinition) int {
+	longest := 0
+	for _, prefix := range str.prefixes() {
+		text := tkzr.textSlice(index, index+len(prefix))
+		if !strings.EqualFold(text, prefix) || len(text)+len(str.Start) <= longest {
+			continue
+		}
+		if tkzr.matchesDelimiter(index, text+str.Start) {
+			longest = len(text) + len(str.Start)
+		}
+	}
+	return longest
+}
+
+// stringEnd
+// Determines whether the string which was started ends at the current index, which it does not if its end is escaped.
+// If an expression is interpolated into the string at the current index, the expression is started instead.
+func (rules *definitionRules) stringEnd(tkzr *Tokenizer) bool {
+	match, found := PeekState[int](tkzr, definitionStringStack)
+	if !found {
+		return false
+	}
+	str := &rules.strings[match]
+	index := tkzr.Index()
+	if str.Escape != "" {
+		escape, _ := utf8.DecodeRuneInString(str.Escape)
+		if tkzr.IsEscaped(index, escape) {
+			return false
+		}
+	}
+	if tkzr.hasPrefixAt(index, str.End) {
+		tkzr.State().Pop(definitionStringStack)
+		return true
+	}
+
+	interpolation := str.Interpolation
+	if interpolation != nil && tkzr.hasPrefixAt(index, interpolation.Start) &&
+		(!interpolation.Doubled || !tkzr.IsDoubled(index, interpolation.Start)) {
+		return tkzr.Interpolate(interpolation.Start, rules.stringModes[match])
+	}
+	return false
+}
+
+// scopeStart
+// Opens a scope if the start of one of the definition's scope pairs begins at the current index
+func (rules *definitionRules) scopeStart(tkzr *Tokenizer) bool {
+	if !rules.scopeStarts.contains(tkzr.CurrentChar()) {
+		return false
+	}
+	for i := range rules.scopes {
+		scope := &rules.scopes[i]
+		if !tkzr.matchesDelimiter(tkzr.Index(), scope.Start) {
+			continue
+		}
+
+		tkzr.StartInfo = scope.Start
+		tkzr.State().Push(rules.scopeStack, scope)
+		skipDelimiter(tkzr, scope.Start)
+		return true
+	}
+	return false
+}
+
+// scopeEnd
+// Closes a scope if the end of the pair which opened the innermost open scope begins at the current index.
+// The end of any other pair does not close a scope, and is treated as a symbol instead. When no scope is open,
+// the end of any pair closes a scope, so that the tokenizer reports it as unbalanced.
+func (rules *definitionRules) scopeEnd(tkzr *Tokenizer) bool {
+	if !rules.scopeEnds.contains(tkzr.CurrentChar()) {
+		return false
+	}
+	openScope, open := PeekState[*ScopeDefinition](tkzr, rules.scopeStack)
+	for i := range rules.scopes {
+		scope := &rules.scopes[i]
+		if !tkzr.matchesDelimiter(tkzr.Index(), scope.End) {
+			continue
+		}
+		if open && openScope.End != scope.End {
+			continue
+		}
+
+		tkzr.EndInfo = scope.End
+		tkzr.State().Pop(rules.scopeStack)
+		return true
+	}
+	return false
+}
+
+// indentationScopeStart
+// Opens a scope if the opener begins at the current index, unless one of the exceptions does
+func (rules *definitionRules) indentationScopeStart(tkzr *Tokenizer) bool {
+	index := tkzr.Index()
+	if !rules.openerStarts.contains(tkzr.CurrentChar()) || tkzr.InBrackets() || !tkzr.matchesDelimiter(index, rules.indentationScopes.Opener) {
+		return false
+	}
+	for _, exception := range rules.indentationScopes.Exceptions {
+		if tkzr.hasPrefixAt(index, exception) {
+			return false
+		}
+	}
+
+	tkzr.StartInfo = rules.indentationScopes.Opener
+	tkzr.State().Push(definitionScopeStack, definitionScope{indentation: tkzr.LogicalIndentation()})
+	skipDelimiter(tkzr, rules.indentationScopes.Opener)
+	return true
+}
+
+// indentationScopeEnd
+// Closes the innermost open scope if a logical line indented no more than the logical line which opened it begins at the current index.
+// Blank lines and lines with only a comment do not close scopes. The index is not moved, so that every scope which closes at the same token is closed.
+func (rules *definitionRules) indentationScopeEnd(tkzr *Tokenizer) bool {
+	scope, found := PeekState[definitionScope](tkzr, definitionScopeStack)
+	if !found {
+		return false
+	}
+
+	char := tkzr.CurrentChar()
+	if char == '\n' || util.IsWhitespaceCharacter(char) {
+		return false
+	}
+	if rules.commentStarts.contains(char) {
+		for _, comment := range rules.comments {
+			if tkzr.matchesDelimiter(tkzr.Index(), comment.Start) {
+				return false
+			}
+		}
+	}
+
+	if tkzr.StartsLogicalLine() && tkzr.LineIndentation() <= scope.indentation {
+		tkzr.EndInfo = ""
+		tkzr.State().Pop(definitionScopeStack)
+		tkzr.SkipIncrement()
+		return true
+	}
+	return false
+}
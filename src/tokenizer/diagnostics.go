@@ -0,0 +1,111 @@
+package tokenizer
+
+import (
+	"fmt"
+	tk "tp/src/tokenizer/tokens"
+)
+
+// Severity
+// Defines how serious a diagnostic is
+type Severity int
+
+const (
+	SEVERITY_ERROR   Severity = iota // The text (or a callback) is malformed, so the tokens around it may be wrong
+	SEVERITY_WARNING                 // Something is likely a mistake, but the tokens are unaffected
+)
+
+// Diagnostic codes
+const (
+	DIAGNOSTIC_UNTERMINATED_STRING    = "UNTERMINATED_STRING"    // A string was never ended before the end of the text
+	DIAGNOSTIC_UNTERMINATED_COMMENT   = "UNTERMINATED_COMMENT"   // A comment was never ended before the end of the text
+	DIAGNOSTIC_UNBALANCED_SCOPE_CLOSE = "UNBALANCED_SCOPE_CLOSE" // A scope was ended when no scope was open
+	DIAGNOSTIC_UNCLOSED_SCOPE         = "UNCLOSED_SCOPE"         // A scope was still open at the end of the text
+	DIAGNOSTIC_CALLBACK_PANIC         = "CALLBACK_PANIC"         // A callback of the language panicked
+	DIAGNOSTIC_CALLBACK_OUT_OF_BOUNDS = "CALLBACK_OUT_OF_BOUNDS" // A callback of the language read a character outside the text
+	DIAGNOSTIC_CALLBACK_NO_PROGRESS   = "CALLBACK_NO_PROGRESS"   // The callbacks of the language kept the tokenizer from moving forward
+	DIAGNOSTIC_READ_ERROR             = "READ_ERROR"             // The text could not be fully read
+	DIAGNOSTIC_INCONSISTENT_DEDENT    = "INCONSISTENT_DEDENT"    // A line was indented less than the line before it, but not as little as any enclosing block
+	DIAGNOSTIC_INCONSISTENT_TABS      = "INCONSISTENT_TABS"      // The indentation of a line only matches the lines before it for some tab sizes
+)
+
+// Diagnostic
+// Defines a problem found while tokenizing, along with where it was found.
+//
+// Severity: how serious the problem is
+//
+// Code: one of the DIAGNOSTIC_ constants, which identifies what kind of problem this is
+//
+// Message: a description of the problem meant to be read by people
+//
+// Start: where the problem begins
+//
+// End: where the problem ends (exclusive)
+//
+// Repair: how the problem was repaired in recovery mode. This is empty if nothing was repaired
+type Diagnostic struct {
+	Severity Severity
+	Code     string
+	Message  string
+	Start    tk.Position
+	End      tk.Position
+	Repair   string
+}
+
+// ToString
+// Returns the severity as a string
+func (s Severity) ToString() string {
+	switch s {
+	case SEVERITY_ERROR:
+		return "ERROR"
+	case SEVERITY_WARNING:
+		return "WARNING"
+	}
+	return fmt.Sprintf("SEVERITY(%d)", int(s))
+}
+
+// ToString
+// Returns the diagnostic as a string
+func (d Diagnostic) ToString() string {
+	diagnosticString := fmt.Sprintf("%s %s [%s - %s]: %s", d.Severity.ToString(), d.Code, d.Start.ToString(), d.End.ToString(), d.Message)
+	if d.Repair != "" {
+		diagnosticString += fmt.Sprintf(" (repaired: %s)", d.Repair)
+	}
+	return diagnosticString
+}
+
+// Diagnostics
+// Returns the diagnostics found during the most recent run of the tokenizer
+func (tkzr *Tokenizer) Diagnostics() []Diagnostic {
+	return tkzr.diagnostics
+}
+
+// addDiagnostic
+// Records a problem found while tokenizing
+func (tkzr *Tokenizer) addDiagnostic(severity Severity, code string, message string, start tk.Position, end tk.Position) {
+	tkzr.diagnostics = append(tkzr.diagnostics, Diagnostic{
+		Severity: severity,
+		Code:     code,
+		Message:  message,
+		Start:    start,
+		End:      end,
+	})
+}
+
+// addRepairedDiagnostic
+// Records an error found while tokenizing which is repaired in recovery mode.
+// The repair is only recorded when the tokenizer is in recovery mode.
+func (tkzr *Tokenizer) addRepairedDiagnostic(code string, message string, start tk.Position, end tk.Position, repair string) {
+	tkzr.addDiagnostic(SEVERITY_ERROR, code, message, start, end)
+	if tkzr.RecoveryMode {
+		tkzr.diagnostics[len(tkzr.diagnostics)-1].Repair = repair
+	}
+}
+
+// unterminatedDiagnosticCode
+// Returns the code of the diagnostic for a token of the kind which is never ended
+func unterminatedDiagnosticCode(kind tk.Kind) string {
+	if kind == tk.KIND_COMMENT {
+		return DIAGNOSTIC_UNTERMINATED_COMMENT
+	}
+	return DIAGNOSTIC_UNTERMINATED_STRING
+}
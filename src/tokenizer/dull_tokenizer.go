@@ -11,14 +11,22 @@ import "unicode"
 //
 // This returns a pointer to a newly created tokenizer object
 func CreateDullTokenizer() *Tokenizer {
-	tkzr := GenerateDefaultTokenizerObject()
-	tkzr.ConfigureGeneral("dull", make([][]string, 0), make([]string, 0),
+	return NewTokenizer(CreateDullLanguage())
+}
+
+// CreateDullLanguage
+// Creates the language used by the 'dull' tokenizer.
+//
+// This returns a pointer to a newly created language object
+func CreateDullLanguage() *Language {
+	lang := GenerateDefaultLanguageObject()
+	lang.ConfigureGeneral("dull", make([][]string, 0), make([]string, 0),
 		// Function To Determine if a letter can be part of a keyword in this language
 		func(c rune) bool {
 			return unicode.IsLetter(c) || unicode.IsDigit(c) || c == '_'
 		},
 	)
-	tkzr.ConfigureComment(
+	lang.ConfigureComment(
 		// Comment Start
 		func(tkzr *Tokenizer) bool {
 			tkzr.StartInfo = "("
@@ -30,7 +38,7 @@ func CreateDullTokenizer() *Tokenizer {
 			return true
 		},
 	)
-	tkzr.ConfigureScope(
+	lang.ConfigureScope(
 		// Scope Start
 		func(tkzr *Tokenizer) bool {
 			tkzr.StartInfo = "("
@@ -42,7 +50,7 @@ func CreateDullTokenizer() *Tokenizer {
 			return false
 		},
 	)
-	tkzr.ConfigureString(
+	lang.ConfigureString(
 		// String Start
 		func(tkzr *Tokenizer) bool {
 			tkzr.StartInfo = "("
@@ -54,5 +62,5 @@ func CreateDullTokenizer() *Tokenizer {
 			return true
 		},
 	)
-	return &tkzr
+	return &lang
 }
@@ -0,0 +1,250 @@
+package tokenizer
+
+import (
+	"io"
+	"strings"
+	tk "tp/src/tokenizer/tokens"
+	"tp/src/util"
+	"unicode/utf8"
+)
+
+// Encoding names
+const (
+	ENCODING_UTF8         = util.ENCODING_UTF8
+	ENCODING_UTF16LE      = util.ENCODING_UTF16LE
+	ENCODING_UTF16BE      = util.ENCODING_UTF16BE
+	ENCODING_LATIN_1      = "ISO-8859-1"
+	ENCODING_WINDOWS_1252 = "windows-1252"
+)
+
+// SingleByteEncoding
+// Defines an encoding in which every byte is a character of its own, and the bytes below 0x80 are ASCII
+// (e.g. ISO-8859-1 or windows-1252). Text which is neither UTF-8 nor begins with a byte-order mark is decoded with one.
+//
+// Name: the name of the encoding, which is recorded on the ScopeObj. ENCODING_LATIN_1 if empty
+//
+// Characters: the characters of the bytes 0x80 to 0xFF. A zero stands for the character with the same value as the byte,
+// so the zero value of a SingleByteEncoding is ISO-8859-1
+type SingleByteEncoding struct {
+	Name       string
+	Characters [128]rune
+}
+
+// windows1252Characters
+// The characters of the bytes 0x80 to 0x9F in windows-1252, which are the only ones which differ from ISO-8859-1.
+// The bytes windows-1252 leaves undefined are zero, so they keep their ISO-8859-1 characters.
+var windows1252Characters = [32]rune{
+	0x20AC, 0, 0x201A, 0x0192, 0x201E, 0x2026, 0x2020, 0x2021, 0x02C6, 0x2030, 0x0160, 0x2039, 0x0152, 0, 0x017D, 0,
+	0, 0x2018, 0x2019, 0x201C, 0x201D, 0x2022, 0x2013, 0x2014, 0x02DC, 0x2122, 0x0161, 0x203A, 0x0153, 0, 0x017E, 0x0178,
+}
+
+// Latin1Encoding
+// Returns the ISO-8859-1 encoding, which is used when Tokenizer.FallbackEncoding is nil
+func Latin1Encoding() SingleByteEncoding {
+	return SingleByteEncoding{Name: ENCODING_LATIN_1}
+}
+
+// Windows1252Encoding
+// Returns the windows-1252 encoding, which legacy Windows editors commonly save text in
+func Windows1252Encoding() SingleByteEncoding {
+	encoding := SingleByteEncoding{Name: ENCODING_WINDOWS_1252}
+	copy(encoding.Characters[:], windows1252Characters[:])
+	return encoding
+}
+
+// name
+// Returns the name of the encoding
+func (encoding *SingleByteEncoding) name() string {
+	if encoding.Name == "" {
+		return ENCODING_LATIN_1
+	}
+	return encoding.Name
+}
+
+// decode
+// Returns the text the bytes stand for in this encoding
+func (encoding *SingleByteEncoding) decode(data string) string {
+	var builder strings.Builder
+	builder.Grow(len(data))
+	for i := 0; i < len(data); i++ {
+		if b := data[i]; b < utf8.RuneSelf {
+			builder.WriteByte(b)
+		} else if char := encoding.Characters[b-utf8.RuneSelf]; char != 0 {
+			builder.WriteRune(char)
+		} else {
+			builder.WriteRune(rune(b))
+		}
+	}
+	return builder.String()
+}
+
+// DecodeText
+// Converts the bytes of a file into the UTF-8 text the tokenizer works with, returning the text along with the encoding it was found in.
+// A byte-order mark decides the encoding (UTF-8, UTF-16LE or UTF-16BE) and is removed from the text. Without one, text which is mostly
+// ASCII characters encoded in UTF-16 is UTF-16 (see util.DetectEncoding), valid UTF-8 is UTF-8, and anything else is decoded
+// with the fallback encoding.
+//
+// fallback: the encoding of text which is neither UTF-8 nor UTF-16. ISO-8859-1 if nil
+//
+// Bytes which cannot be decoded (e.g. half of a UTF-16 surrogate pair) become the replacement character (U+FFFD).
+func DecodeText(data []byte, fallback *SingleByteEncoding) (string, tk.Encoding) {
+	return decodeString(string(data), fallback)
+}
+
+// decodeString
+// Decodes text like DecodeText does. Text which is already UTF-8 is returned without being copied.
+func decodeString(data string, fallback *SingleByteEncoding) (string, tk.Encoding) {
+	if fallback == nil {
+		latin1 := Latin1Encoding()
+		fallback = &latin1
+	}
+	text, name, byteOrderMark := util.DecodeText(data, fallback.decode)
+	if name == "" {
+		name = fallback.name()
+	}
+	return text, tk.Encoding{Name: name, ByteOrderMark: byteOrderMark}
+}
+
+// decodingReader
+// Reads the UTF-8 text the bytes read from a reader stand for. The encoding is determined like DecodeText does,
+// but only from the first bytes read, so text which only stops being valid UTF-8 further on is read as it is.
+//
+// buffer: holds the bytes being read, which are decoded into a new string, so it is used for every read
+//
+// pending: the bytes at the start of the next read which do not make up a whole character yet (e.g. half of a UTF-16 surrogate pair),
+// or which are not yet enough to determine the encoding with
+//
+// decoded: the decoded text which has not been read yet
+type decodingReader struct {
+	reader   io.Reader
+	fallback *SingleByteEncoding
+	encoding tk.Encoding
+	detected bool
+	buffer   []byte
+	pending  []byte
+	decoded  string
+	err      error
+}
+
+// newDecodingReader
+// Returns a reader of the text the reader's bytes stand for
+func newDecodingReader(reader io.Reader, fallback *SingleByteEncoding) *decodingReader {
+	if fallback == nil {
+		latin1 := Latin1Encoding()
+		fallback = &latin1
+	}
+	return &decodingReader{reader: reader, fallback: fallback}
+}
+
+// Read
+// Reads the decoded text, returning the reader's error once all of the text before it has been read.
+// Once the text is found to be UTF-8, the rest of it is read straight from the reader.
+func (dr *decodingReader) Read(p []byte) (int, error) {
+	for dr.decoded == "" {
+		if dr.err != nil {
+			return 0, dr.err
+		}
+		if dr.detected && dr.encoding.Name == ENCODING_UTF8 {
+			return dr.reader.Read(p)
+		}
+		if dr.readMore() == 0 {
+			return 0, nil
+		}
+	}
+	n := copy(p, dr.decoded)
+	dr.decoded = dr.decoded[n:]
+	return n, nil
+}
+
+// readMore
+// Reads and decodes the next bytes, returning the number of bytes read.
+// Until the encoding is known, bytes are read until there are enough to determine it.
+func (dr *decodingReader) readMore() int {
+	if dr.buffer == nil {
+		dr.buffer = make([]byte, streamChunkSize)
+	}
+	data := dr.buffer
+	n := copy(data, dr.pending)
+	total := 0
+	for n < len(data) && dr.err == nil {
+		read, err := dr.reader.Read(data[n:])
+		n += read
+		total += read
+		dr.err = err
+		if dr.detected || n >= util.ENCODING_SNIFF_LENGTH || read == 0 {
+			break
+		}
+	}
+	data = data[:n]
+	dr.pending = nil
+	if !dr.detected {
+		if n < util.ENCODING_SNIFF_LENGTH && dr.err == nil {
+			dr.pending = data
+			return total
+		}
+		data = dr.detect(data)
+	}
+
+	switch dr.encoding.Name {
+	case ENCODING_UTF8:
+		dr.decoded = string(data)
+	case ENCODING_UTF16LE, ENCODING_UTF16BE:
+		whole := len(data)
+		if dr.err == nil {
+			// A character is only decoded once all of its bytes have been read
+			whole -= whole % 2
+			if whole >= 2 && utf16IsHighSurrogate(data[whole-2:whole], dr.encoding.Name == ENCODING_UTF16BE) {
+				whole -= 2
+			}
+			dr.pending = data[whole:]
+		}
+		dr.decoded = util.DecodeUTF16(string(data[:whole]), dr.encoding.Name == ENCODING_UTF16BE)
+	default:
+		dr.decoded = dr.fallback.decode(string(data))
+	}
+	return total
+}
+
+// detect
+// Determines the encoding from the first bytes read, returning the bytes without their byte-order mark
+func (dr *decodingReader) detect(data []byte) []byte {
+	dr.detected = true
+	head := data
+	if len(head) > util.ENCODING_SNIFF_LENGTH {
+		head = head[:util.ENCODING_SNIFF_LENGTH]
+	}
+	name, byteOrderMarkLength := util.DetectEncoding(string(head))
+	if name != "" {
+		dr.encoding = tk.Encoding{Name: name, ByteOrderMark: byteOrderMarkLength > 0}
+		return data[byteOrderMarkLength:]
+	}
+
+	// A character may be cut off at the end of what has been read so far
+	sample := data
+	if dr.err == nil {
+		for cut := 1; cut < utf8.UTFMax && cut <= len(sample); cut++ {
+			if utf8.RuneStart(sample[len(sample)-cut]) {
+				if !utf8.FullRune(sample[len(sample)-cut:]) {
+					sample = sample[:len(sample)-cut]
+				}
+				break
+			}
+		}
+	}
+	if utf8.Valid(sample) {
+		dr.encoding = tk.Encoding{Name: ENCODING_UTF8}
+	} else {
+		dr.encoding = tk.Encoding{Name: dr.fallback.name()}
+	}
+	return data
+}
+
+// utf16IsHighSurrogate
+// Returns true if the pair of bytes is the first half of a UTF-16 surrogate pair
+func utf16IsHighSurrogate(unit []byte, bigEndian bool) bool {
+	high := unit[1]
+	if bigEndian {
+		high = unit[0]
+	}
+	return high >= 0xD8 && high <= 0xDB
+}
@@ -13,4 +13,8 @@ const (
 	SYMBOLIC_NAME_UNKNOWN_SYMBOL = "UNKNOWN"
 	SYMBOLIC_NAME_COMMENT        = "COMMENT"
 	SYMBOLIC_NAME_STRING         = "STRING"
+	SYMBOLIC_NAME_NUMBER         = "NUMBER"
+	SYMBOLIC_NAME_ERROR          = "ERROR"
+	SYMBOLIC_NAME_INDENT         = "INDENT"
+	SYMBOLIC_NAME_DEDENT         = "DEDENT"
 )
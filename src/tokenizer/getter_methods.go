@@ -1,22 +1,55 @@
 package tokenizer
 
 import (
-	"errors"
 	"fmt"
-	"tp/src/util"
+	"unicode/utf8"
 )
 
 // GetChar
 // Gets a character from the text as long as the provided
 // integer index is within the bounds of the Text. If
-// it out of bounds, it panics.
+// it out of bounds, 0 is returned and a diagnostic is recorded,
+// since the tokenizer itself never reads outside the text.
+//
+// The index is a byte offset into the text, and the returned rune is the
+// full (decoded) character which begins at that offset. Invalid UTF-8 is
+// returned as utf8.RuneError one byte at a time.
 func (tkzr *Tokenizer) GetChar(index int) rune {
 	if !tkzr.DetermineIfIndexInBound(index) {
-		err := errors.New(fmt.Sprintf("index %d is out of bounds of text size %d", index, tkzr.TextSize()))
-		util.Error(err.Error(), err)
-		panic(err)
+		position := tkzr.PositionOf(tkzr.currentIndex)
+		tkzr.addDiagnostic(SEVERITY_WARNING, DIAGNOSTIC_CALLBACK_OUT_OF_BOUNDS, fmt.Sprintf("a callback read index %d, which is outside the text", index), position, position)
+		return 0
 	}
-	return rune((*tkzr.Text)[index])
+	if tkzr.source.needsFill(index + utf8.UTFMax - 1) {
+		tkzr.source.fill(index+utf8.UTFMax-1, tkzr.keepOffset())
+	}
+	char, _ := tkzr.source.decode(index)
+	return char
+}
+
+// GetCharWidth
+// Returns the number of bytes taken up by the character which begins at
+// the provided index. Indices outside the text are treated as having a
+// width of 1 so that callers stepping through the text always make progress.
+func (tkzr *Tokenizer) GetCharWidth(index int) int {
+	if !tkzr.DetermineIfIndexInBound(index) {
+		return 1
+	}
+	if tkzr.source.needsFill(index + utf8.UTFMax - 1) {
+		tkzr.source.fill(index+utf8.UTFMax-1, tkzr.keepOffset())
+	}
+	_, width := tkzr.source.decode(index)
+	return width
+}
+
+// GetPreviousChar
+// Returns the character which ends right before the provided index.
+// If there is no character before the index, 0 is returned.
+func (tkzr *Tokenizer) GetPreviousChar(index int) rune {
+	if index > 0 && index > tkzr.source.end() {
+		tkzr.DetermineIfIndexInBound(index - 1)
+	}
+	return tkzr.source.decodeLast(index)
 }
 
 // GetCurrentTabLevel
@@ -37,3 +70,65 @@ func (tkzr *Tokenizer) GetCurrentLineNumber() int {
 func (tkzr *Tokenizer) CurrentChar() rune {
 	return tkzr.GetChar(tkzr.currentIndex)
 }
+
+// PreviousChar
+// Returns the char right before the one the tokenizer is currently at.
+// If the tokenizer is at the start of the text, 0 is returned.
+func (tkzr *Tokenizer) PreviousChar() rune {
+	return tkzr.GetPreviousChar(tkzr.currentIndex)
+}
+
+// IsEscaped
+// Returns true if the character at the provided index is escaped, meaning it comes right after an
+// odd number of escape characters (e.g. the quote in "\"" is escaped, while the quote in "\\" is not)
+func (tkzr *Tokenizer) IsEscaped(index int, escape rune) bool {
+	var buffer [utf8.UTFMax]byte
+	return tkzr.countDelimitersBefore(index, string(buffer[:utf8.EncodeRune(buffer[:], escape)]))%2 == 1
+}
+
+// IsDoubled
+// Returns true if the delimiter at the provided index is part of a doubled delimiter, which some languages use to write the
+// delimiter as literal text (e.g. the "{{" within a Python f-string). Delimiters in a row pair up from the first one,
+// so only the last of an odd number of delimiters in a row is not doubled.
+func (tkzr *Tokenizer) IsDoubled(index int, delimiter string) bool {
+	if delimiter == "" || !tkzr.hasPrefixAt(index, delimiter) {
+		return false
+	}
+	if tkzr.hasPrefixAt(index+len(delimiter), delimiter) {
+		return true
+	}
+	return tkzr.countDelimitersBefore(index, delimiter)%2 == 1
+}
+
+// delimiterRun
+// Holds the number of delimiters in a row which were last found right before an index (see countDelimitersBefore)
+type delimiterRun struct {
+	delimiter string
+	index     int
+	count     int
+}
+
+// countDelimitersBefore
+// Returns the number of delimiters in a row which come right before the index.
+// Callbacks check every character of a string this way (e.g. to find escaped quotes), so counting stops at the index
+// the last count was for, rather than every delimiter in a row being counted again for each of them.
+func (tkzr *Tokenizer) countDelimitersBefore(index int, delimiter string) int {
+	if delimiter == "" {
+		return 0
+	}
+	run := &tkzr.delimiterRun
+	count := 0
+	for position := index; ; position -= len(delimiter) {
+		if run.delimiter == delimiter && run.index == position {
+			count += run.count
+			break
+		}
+		previous := position - len(delimiter)
+		if previous < 0 || !tkzr.DetermineIfIndexInBound(previous) || !tkzr.hasPrefixAt(previous, delimiter) {
+			break
+		}
+		count++
+	}
+	*run = delimiterRun{delimiter: delimiter, index: index, count: count}
+	return count
+}
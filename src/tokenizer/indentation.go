@@ -0,0 +1,280 @@
+package tokenizer
+
+import (
+	tk "tp/src/tokenizer/tokens"
+	"tp/src/util"
+)
+
+// DEFAULT_INDENTATION_TAB_SIZE
+// The tab size used to measure indentation when IndentationRules does not provide one
+const DEFAULT_INDENTATION_TAB_SIZE = 8
+
+// IndentationRules
+// Defines how the indentation of a language is tracked, for languages whose blocks are made by indentation (e.g. Python).
+// The first line of every logical line is measured, and the columns of the blocks it is within are kept on a stack.
+// A line indented more than the top of the stack begins a block (an indent), while a line indented less ends every block
+// indented more than it (a dedent). A dedent to a column no block began at is reported as a diagnostic.
+// Lines within brackets, lines after a line continuation and lines with only whitespace and comments are not the first
+// line of a logical line, so their indentation is ignored.
+//
+// EmitTokens: whether empty KIND_INDENT and KIND_DEDENT tokens are added where blocks begin and end.
+// They are placed right before the first token of the line, and at the end of the text for blocks still open
+//
+// Brackets: the pairs of texts which open and close brackets (e.g. {"(", ")"})
+//
+// LineContinuation: the text which continues a logical line onto the next line when it is the last token of a line (e.g. "\\")
+//
+// TabSize: the number of columns between tab stops. DEFAULT_INDENTATION_TAB_SIZE if 0 or less
+type IndentationRules struct {
+	EmitTokens       bool        `json:"emitTokens,omitempty" yaml:"emitTokens,omitempty"`
+	Brackets         [][2]string `json:"brackets,omitempty" yaml:"brackets,omitempty"`
+	LineContinuation string      `json:"lineContinuation,omitempty" yaml:"lineContinuation,omitempty"`
+	TabSize          int         `json:"tabSize,omitempty" yaml:"tabSize,omitempty"`
+}
+
+// indentation
+// Defines how far a line is indented
+//
+// column: the column the indentation ends at (starting at 0), where a tab moves to the next tab stop
+//
+// narrowColumn: the column the indentation ends at if tabs were as wide as a space. If two lines are ordered
+// differently by their narrow columns than by their columns, which line is indented more depends on the tab size
+type indentation struct {
+	column       int
+	narrowColumn int
+}
+
+// indentationTracker
+// Holds the indentation state of a single run
+//
+// levels: the indentation of each block the current line is within, beginning with the unindented level
+//
+// logical: the indentation of the first line of the current logical line
+//
+// line: the line most recently measured
+//
+// lineStart: the index the line most recently measured begins at
+//
+// lineIndentation: the indentation of the line most recently measured
+//
+// lineWidth: the number of characters the indentation of the line most recently measured is made of
+//
+// lastLine: the line the last token which was not trivia ended on
+//
+// brackets: the number of brackets which are open
+//
+// continued: whether the last token which was not trivia was a line continuation
+type indentationTracker struct {
+	levels          []indentation
+	logical         indentation
+	line            int
+	lineStart       int
+	lineIndentation indentation
+	lineWidth       int
+	lastLine        int
+	brackets        int
+	continued       bool
+}
+
+// ConfigureIndentation
+// This function sets up the tracking of indentation (see IndentationRules).
+// This method is not necessary to be run; without it, indentation is not tracked.
+//
+// rules: how the indentation of the language is tracked
+func (lang *Language) ConfigureIndentation(rules IndentationRules) {
+	rules.Brackets = append([][2]string(nil), rules.Brackets...)
+	lang.IndentationRules = &rules
+}
+
+// tabSize
+// Returns the number of columns between tab stops
+func (rules *IndentationRules) tabSize() int {
+	if rules.TabSize <= 0 {
+		return DEFAULT_INDENTATION_TAB_SIZE
+	}
+	return rules.TabSize
+}
+
+// measureIndentation
+// Returns how far the provided whitespace, which begins a line, indents the line
+func measureIndentation(whitespace string, tabSize int) indentation {
+	measured := indentation{}
+	for _, char := range whitespace {
+		if char == '\t' {
+			measured.column += tabSize - measured.column%tabSize
+		} else {
+			measured.column++
+		}
+		measured.narrowColumn++
+	}
+	return measured
+}
+
+// initIndentation
+// Starts tracking indentation if the language has indentation rules, measuring the first line of the text
+func (tkzr *Tokenizer) initIndentation() {
+	tkzr.indentation = nil
+	if tkzr.IndentationRules == nil {
+		return
+	}
+	tkzr.indentation = &indentationTracker{levels: []indentation{{}}}
+
+	index := 0
+	for tkzr.DetermineIfIndexInBound(index) && util.IsWhitespaceCharacter(tkzr.GetChar(index)) {
+		index = tkzr.NextIndex(index)
+	}
+	tkzr.measureLine(0, tkzr.textSlice(0, index))
+}
+
+// measureLine
+// Records the indentation of the current line
+//
+// lineStart: the index the line begins at
+//
+// whitespace: the whitespace the line begins with
+func (tkzr *Tokenizer) measureLine(lineStart int, whitespace string) {
+	tracker := tkzr.indentation
+	if tracker == nil {
+		return
+	}
+	tracker.line = tkzr.currentLineNumber
+	tracker.lineStart = lineStart
+	tracker.lineIndentation = measureIndentation(whitespace, tkzr.IndentationRules.tabSize())
+	tracker.lineWidth = len(whitespace)
+}
+
+// LineIndentation
+// Returns the column the indentation of the current line ends at (starting at 0), where a tab moves to the next tab stop.
+// Returns 0 if the language has no indentation rules (see ConfigureIndentation).
+func (tkzr *Tokenizer) LineIndentation() int {
+	if tkzr.indentation == nil {
+		return 0
+	}
+	return tkzr.indentation.lineIndentation.column
+}
+
+// LogicalIndentation
+// Returns the column the indentation of the first line of the current logical line ends at,
+// which differs from LineIndentation within brackets and after line continuations.
+// Returns 0 if the language has no indentation rules (see ConfigureIndentation).
+func (tkzr *Tokenizer) LogicalIndentation() int {
+	if tkzr.indentation == nil {
+		return 0
+	}
+	if tkzr.onNewLogicalLine(tkzr.currentLineNumber) {
+		return tkzr.indentation.lineIndentation.column
+	}
+	return tkzr.indentation.logical.column
+}
+
+// StartsLogicalLine
+// Returns true if a token beginning at the current index would be the first token of a logical line,
+// i.e., nothing but whitespace and comments comes before it on its line, and the line is not within
+// brackets or after a line continuation. Always returns false if the language has no indentation rules.
+func (tkzr *Tokenizer) StartsLogicalLine() bool {
+	return tkzr.indentation != nil && !tkzr.hasPotentialKeyword() && tkzr.onNewLogicalLine(tkzr.currentLineNumber)
+}
+
+// InBrackets
+// Returns true if the current index is within brackets (see IndentationRules.Brackets).
+// Always returns false if the language has no indentation rules.
+func (tkzr *Tokenizer) InBrackets() bool {
+	return tkzr.indentation != nil && tkzr.indentation.brackets > 0
+}
+
+// onNewLogicalLine
+// Returns true if no token which is not trivia has been added on the provided line,
+// and the line is not within brackets or after a line continuation
+func (tkzr *Tokenizer) onNewLogicalLine(line int) bool {
+	tracker := tkzr.indentation
+	return line > tracker.lastLine && tracker.brackets == 0 && !tracker.continued
+}
+
+// trackIndentation
+// Updates the indentation state with a token which is about to be added. If the token is the first of a logical line,
+// its line is compared with the blocks it may be within, adding the indents and dedents found before the token.
+func (tkzr *Tokenizer) trackIndentation(token *tk.Token) {
+	tracker := tkzr.indentation
+	if token.Kind.IsTrivia() || token.Kind == tk.KIND_INDENT || token.Kind == tk.KIND_DEDENT {
+		return
+	}
+
+	if tkzr.onNewLogicalLine(token.Start.Line) {
+		tkzr.changeIndentation(token)
+	}
+	tracker.lastLine = token.End.Line
+	rules := tkzr.IndentationRules
+	tracker.continued = rules.LineContinuation != "" && token.Text == rules.LineContinuation
+	if token.Kind.IsLiteral() {
+		return
+	}
+	for _, bracket := range rules.Brackets {
+		if token.Text == bracket[0] {
+			tracker.brackets++
+		} else if token.Text == bracket[1] && tracker.brackets > 0 {
+			tracker.brackets--
+		}
+	}
+}
+
+// changeIndentation
+// Compares the indentation of the line the token begins, which is the first token of a logical line, with the blocks
+// it may be within. Blocks are begun and ended accordingly, and the line becomes the current logical line.
+func (tkzr *Tokenizer) changeIndentation(token *tk.Token) {
+	tracker := tkzr.indentation
+	current := tracker.lineIndentation
+	start := tk.Position{Line: token.Start.Line, Column: 1, Offset: tracker.lineStart}
+	end := tk.Position{Line: token.Start.Line, Column: 1 + tracker.lineWidth, Offset: tracker.lineStart + tracker.lineWidth}
+	if tracker.line != token.Start.Line {
+		// The line was never measured, so the token is assumed to be right after the indentation
+		current = indentation{column: token.Start.Column - 1, narrowColumn: token.Start.Column - 1}
+		start, end = token.Start, token.Start
+	}
+	tracker.logical = current
+
+	top := tracker.levels[len(tracker.levels)-1]
+	consistent := true
+	if current.column > top.column {
+		consistent = current.narrowColumn > top.narrowColumn
+		tracker.levels = append(tracker.levels, current)
+		tkzr.emitIndentationToken(tk.KIND_INDENT, token.Start, token.TabNumber)
+	} else {
+		for current.column < top.column {
+			tracker.levels = tracker.levels[:len(tracker.levels)-1]
+			tkzr.emitIndentationToken(tk.KIND_DEDENT, token.Start, token.TabNumber)
+			top = tracker.levels[len(tracker.levels)-1]
+		}
+		if current.column != top.column {
+			tkzr.addDiagnostic(SEVERITY_ERROR, DIAGNOSTIC_INCONSISTENT_DEDENT, "this line is not indented as much as any block it may be within", start, end)
+			return
+		}
+		consistent = current.narrowColumn == top.narrowColumn
+	}
+	if !consistent {
+		tkzr.addDiagnostic(SEVERITY_WARNING, DIAGNOSTIC_INCONSISTENT_TABS, "this line mixes tabs and spaces, so how it is indented depends on the tab size", start, end)
+	}
+}
+
+// finishIndentation
+// Ends every block which is still open at the end of the text
+func (tkzr *Tokenizer) finishIndentation(endOfText tk.Position) {
+	if tkzr.indentation == nil {
+		return
+	}
+	for len(tkzr.indentation.levels) > 1 {
+		tkzr.indentation.levels = tkzr.indentation.levels[:len(tkzr.indentation.levels)-1]
+		tkzr.emitIndentationToken(tk.KIND_DEDENT, endOfText, 0)
+	}
+}
+
+// emitIndentationToken
+// Adds an empty indent or dedent token at the provided position, if the language's indentation rules emit them
+func (tkzr *Tokenizer) emitIndentationToken(kind tk.Kind, position tk.Position, tabLevel int) {
+	if !tkzr.IndentationRules.EmitTokens {
+		return
+	}
+	newToken := tk.CreateUnidentifiedToken("", position.Line, tabLevel)
+	tkzr.setTokenKind(&newToken, kind)
+	newToken.SetSpan(position, position)
+	tkzr.emitToken(&newToken)
+}
@@ -7,6 +7,8 @@ import (
 
 // Index
 // Returns the index that the tokenizer is currently at.
+// The index is a byte offset into the text, which always
+// points at the beginning of a (possibly multi-byte) character.
 func (tkzr *Tokenizer) Index() int {
 	return tkzr.currentIndex
 }
@@ -20,8 +22,44 @@ func (tkzr *Tokenizer) IndexInBound() bool {
 // DetermineIfIndexInBound
 // Determines whether a provided integer index is within the
 // bounds of the text (returns false if not).
+//
+// When the text is being read from a reader, this reads more of the text
+// as needed to determine whether the index is within it.
 func (tkzr *Tokenizer) DetermineIfIndexInBound(index int) bool {
-	return index < len(*tkzr.Text)
+	if tkzr.source == nil || index < tkzr.source.base {
+		return false
+	}
+	if tkzr.source.needsFill(index) {
+		tkzr.source.fill(index, tkzr.keepOffset())
+	}
+	return index < tkzr.source.end()
+}
+
+// keepOffset
+// Returns the earliest index the tokenizer may still need to look at.
+// Text before this index can be discarded when streaming.
+func (tkzr *Tokenizer) keepOffset() int {
+	keep := tkzr.currentIndex
+	if tkzr.hasPotentialKeyword() && tkzr.potentialKeywordStart < keep {
+		keep = tkzr.potentialKeywordStart
+	}
+	if tkzr.functionStartIndex < keep {
+		keep = tkzr.functionStartIndex
+	}
+	if tkzr.positionCache.Offset < keep {
+		keep = tkzr.positionCache.Offset
+	}
+	if tkzr.LosslessMode && tkzr.losslessEnd.Offset < keep {
+		keep = tkzr.losslessEnd.Offset
+	}
+	return keep - streamLookbehind
+}
+
+// NextIndex
+// Returns the index (byte offset) of the character which
+// comes after the character that begins at the provided index.
+func (tkzr *Tokenizer) NextIndex(index int) int {
+	return index + tkzr.GetCharWidth(index)
 }
 
 // SkipIncrement
@@ -31,18 +69,42 @@ func (tkzr *Tokenizer) SkipIncrement() {
 }
 
 // IncrementIndex
-// This will move the index forward 1 (add one to it) and
+// This will move the index forward one character and
 // deal with any new line characters. This ensures that
 // the current line count is correct.
 func (tkzr *Tokenizer) IncrementIndex() {
-	tkzr.currentIndex++
+	tkzr.currentIndex = tkzr.NextIndex(tkzr.currentIndex)
 	tkzr.dealWithNewline()
 	for tkzr.IndexInBound() && tkzr.CurrentChar() == '\n' {
-		tkzr.currentIndex++
+		tkzr.currentIndex = tkzr.NextIndex(tkzr.currentIndex)
 		tkzr.dealWithNewline()
 	}
 }
 
+// PositionOf
+// Returns the position (line, column and byte offset) of the provided index.
+// Indices beyond the end of the text are treated as the end of the text.
+//
+// Positions are found by walking forward from the last position requested,
+// so asking for positions in order (as the tokenizer does) is cheap.
+func (tkzr *Tokenizer) PositionOf(index int) tk.Position {
+	if index < tkzr.positionCache.Offset {
+		tkzr.positionCache = tk.Position{Line: 1, Column: 1, Offset: 0}
+	}
+	position := tkzr.positionCache
+	for position.Offset < index && tkzr.DetermineIfIndexInBound(position.Offset) {
+		if tkzr.GetChar(position.Offset) == '\n' {
+			position.Line++
+			position.Column = 1
+		} else {
+			position.Column++
+		}
+		position.Offset = tkzr.NextIndex(position.Offset)
+	}
+	tkzr.positionCache = position
+	return position
+}
+
 // dealWithNewline
 // This checks whether the current char is a newline.
 // If it is a newline, this will automatically update the current line counter and
@@ -57,23 +119,27 @@ func (tkzr *Tokenizer) dealWithNewline() {
 		// Adds newline token, if applicable
 		if !tkzr.IgnoreNewLines && !tkzr.tempIgnoreChangesFromIncrement {
 			newToken := tk.CreateUnidentifiedToken("\n", tkzr.currentLineNumber, tkzr.currentTabLevel)
-			newToken.SetValues(RULENAME_OTHER, SYMBOLIC_NAME_NEWLINE)
-			tkzr.currentScope.Push(&newToken)
+			tkzr.setTokenKind(&newToken, tk.KIND_NEWLINE)
+			tkzr.setTokenSpan(&newToken, tkzr.currentIndex, tkzr.NextIndex(tkzr.currentIndex))
+			tkzr.emitToken(&newToken)
 		}
 
 		// Increments line number to keep track of line
 		tkzr.currentLineNumber++
 
 		// Gets the tab level for this line
+		whitespaceStart := tkzr.NextIndex(tkzr.currentIndex)
 		gatheredWhitespace := tkzr.gatherWhitespace(!tkzr.tempIgnoreChangesFromIncrement)
 		numOfTabs := util.DetermineNumberOfTabs(gatheredWhitespace, tkzr.NumOfSpacesEquallyTab, true)
 		tkzr.currentTabLevel = numOfTabs
+		tkzr.measureLine(whitespaceStart, gatheredWhitespace)
 
 		// Adds whitespace token, if applicable
 		if !tkzr.IgnoreWhitespace && !tkzr.tempIgnoreChangesFromIncrement {
 			newToken := tk.CreateUnidentifiedToken(gatheredWhitespace, tkzr.currentLineNumber, tkzr.currentTabLevel)
-			newToken.SetValues(RULENAME_OTHER, SYMBOLIC_NAME_WHITESPACE)
-			tkzr.currentScope.Push(&newToken)
+			tkzr.setTokenKind(&newToken, tk.KIND_WHITESPACE)
+			tkzr.setTokenSpan(&newToken, whitespaceStart, whitespaceStart+len(gatheredWhitespace))
+			tkzr.emitToken(&newToken)
 		}
 	}
 }
@@ -1,60 +1,174 @@
 package tokenizer
 
-// GenerateDefaultTokenizerObject
-// This creates a tokenizer object with most
+import (
+	"context"
+	tk "tp/src/tokenizer/tokens"
+)
+
+// GenerateDefaultLanguageObject
+// This creates a language object with all
 // variables initialized with their default values
-func GenerateDefaultTokenizerObject() Tokenizer {
-	return Tokenizer{
+func GenerateDefaultLanguageObject() Language {
+	return Language{
 		LanguageType: "",
 		Symbols:      nil,
 		Keywords:     nil,
+		symbolTrie:   nil,
 
-		tempIgnoreChangesFromIncrement: false,
-		Text:                           nil,
-		currentTabLevel:                0,
-		currentLineNumber:              0,
-		potentialKeyword:               "",
-		StartInfo:                      "",
-		EndInfo:                        "",
-		FunctionSharedInfo:             "",
-		currentIndex:                   0,
-		skipIncrement:                  false,
-
-		currentScope:       nil,
 		ScopeStartFunction: nil,
 		ScopeEndFunction:   nil,
+		ScopesEndWithText:  false,
 
 		StringStartFunction: nil,
 		StringEndFunction:   nil,
 		IncludeStrings:      true,
+		SingleLineStrings:   nil,
 
 		CommentStartFunction: nil,
 		CommentEndFunction:   nil,
 		IncludeComments:      true,
+		NestedComments:       nil,
 
 		IsKeywordCharacter: nil,
 
-		spaceSizeString:       "",
+		NumberRules: nil,
+
+		IndentationRules: nil,
+
 		NumOfSpacesEquallyTab: 4,
 		IgnoreWhitespace:      true,
 		IgnoreNewLines:        true,
+		UnicodeLineBreaks:     false,
+
+		FinalSteps: nil,
+	}
+}
+
+// GenerateDefaultTokenizerObject
+// This creates a tokenizer object with most
+// variables initialized with their default values.
+// The tokenizer is given its own default language, which still needs to be configured.
+func GenerateDefaultTokenizerObject() Tokenizer {
+	language := GenerateDefaultLanguageObject()
+	return *NewTokenizer(&language)
+}
+
+// NewTokenizer
+// Creates a tokenizer which tokenizes text using the provided language.
+// The tokenizer holds its own copy of the language (see Language.Copy), so configuring the tokenizer
+// (e.g. with ConfigureIgnores) affects neither the language nor any other tokenizer created from it.
+func NewTokenizer(language *Language) *Tokenizer {
+	if language != nil {
+		language = language.Copy()
+	}
+	return &Tokenizer{
+		Language: language,
+
+		RecoveryMode: false,
+		LosslessMode: false,
+
+		FallbackEncoding: nil,
+
+		Limits: Limits{},
+
+		tempIgnoreChangesFromIncrement: false,
+		Text:                           nil,
+		source:                         nil,
+		symbolMatcher:                  nil,
+		kinds:                          nil,
+		ruleMatchers:                   nil,
+		ruleLineStart:                  0,
+		ruleLineEnd:                    -1,
+		defaultCallbacks:               nil,
+		callbacks:                      nil,
+		modes:                          nil,
+		pendingInterpolation:           nil,
+		indentation:                    nil,
+		spaceSizeString:                "",
+		currentTabLevel:                0,
+		currentLineNumber:              0,
+		potentialKeywordStart:          0,
+		potentialKeywordEnd:            0,
+		functionStartIndex:             0,
+		positionCache:                  tk.Position{Line: 1, Column: 1, Offset: 0},
+		StartInfo:                      "",
+		EndInfo:                        "",
+		FunctionSharedInfo:             "",
+		state:                          newCallbackState(),
+		currentIndex:                   0,
+		skipIncrement:                  false,
+		openScopes:                     nil,
+		diagnostics:                    nil,
+		stepsWithoutProgress:           0,
+		events:                         nil,
+		nextEvent:                      0,
+		finished:                       false,
+		losslessEnd:                    tk.Position{Line: 1, Column: 1, Offset: 0},
+		losslessTabLevel:               0,
+		ctx:                            nil,
+		stepsUntilContextCheck:         0,
+		stopError:                      nil,
+		stopPosition:                   tk.Position{Line: 1, Column: 1, Offset: 0},
+		tokenCount:                     0,
+		queuedDepth:                    0,
+		delimiterRun:                   delimiterRun{},
 	}
 }
 
 // initTempVariables
 // This initializes various temporary variables
 // needed for the tokenizer to function.
-func (tkzr *Tokenizer) initTempVariables(text *string) {
+//
+// ctx: the context which stops the run once it is cancelled
+//
+// text: the full text being tokenized, or nil if it is being read from a reader
+//
+// source: where the tokenizer gets its text from
+func (tkzr *Tokenizer) initTempVariables(ctx context.Context, text *string, source *textSource) {
 	tkzr.tempIgnoreChangesFromIncrement = false
 	tkzr.initSpaceSizeString()
-	tkzr.potentialKeyword = ""
+	tkzr.initSymbolMatcher()
+	tkzr.kinds = tkzr.Language.kindTable()
+	tkzr.ruleMatchers = tkzr.Language.tokenRuleTable()
+	tkzr.ruleLineStart = 0
+	tkzr.ruleLineEnd = -1
+	defaultCallbacks := tkzr.Language.resolveMode(nil)
+	tkzr.defaultCallbacks = &defaultCallbacks
+	tkzr.callbacks = tkzr.defaultCallbacks
+	tkzr.modes = nil
+	tkzr.pendingInterpolation = nil
+	tkzr.potentialKeywordStart = 0
+	tkzr.potentialKeywordEnd = 0
+	tkzr.functionStartIndex = 0
+	tkzr.positionCache = tk.Position{Line: 1, Column: 1, Offset: 0}
 	tkzr.currentTabLevel = 0
 	tkzr.currentIndex = 0
 	tkzr.currentLineNumber = 1
 	tkzr.Text = text
+	tkzr.source = source
+	source.unicodeLineBreaks = tkzr.UnicodeLineBreaks
+	source.limitInput(tkzr.Limits.MaxInputBytes)
+	tkzr.skipIncrement = false
+	tkzr.openScopes = make([]tk.Position, 0)
+	tkzr.diagnostics = make([]Diagnostic, 0)
+	tkzr.stepsWithoutProgress = 0
+	tkzr.events = make([]TokenEvent, 0)
+	tkzr.nextEvent = 0
+	tkzr.finished = false
+	tkzr.losslessEnd = tk.Position{Line: 1, Column: 1, Offset: 0}
+	tkzr.losslessTabLevel = 0
+	tkzr.ctx = ctx
+	tkzr.stepsUntilContextCheck = 0
+	tkzr.stopError = nil
+	tkzr.stopPosition = tk.Position{Line: 1, Column: 1, Offset: 0}
+	tkzr.tokenCount = 0
+	tkzr.queuedDepth = 0
+	tkzr.delimiterRun = delimiterRun{}
 	tkzr.StartInfo = ""
 	tkzr.EndInfo = ""
 	tkzr.FunctionSharedInfo = ""
+	tkzr.state = newCallbackState()
+	tkzr.initIndentation()
 }
 
 // initSpaceSizeString
@@ -66,3 +180,13 @@ func (tkzr *Tokenizer) initSpaceSizeString() {
 		tkzr.spaceSizeString += " "
 	}
 }
+
+// initSymbolMatcher
+// This picks the trie used to find symbols. The language's trie is used when it has one;
+// otherwise one is built for this run, so the shared language is never modified.
+func (tkzr *Tokenizer) initSymbolMatcher() {
+	tkzr.symbolMatcher = tkzr.Language.symbolTrie
+	if tkzr.symbolMatcher == nil {
+		tkzr.symbolMatcher = buildSymbolTrie(tkzr.Symbols)
+	}
+}
@@ -0,0 +1,115 @@
+package tokenizer
+
+import (
+	"strings"
+	tk "tp/src/tokenizer/tokens"
+)
+
+// languageKinds
+// Defines the kinds registered for a language.
+//
+// registry: the built-in kinds along with the kinds of the language's keywords and symbols
+//
+// keywords: the kind of each keyword, in the same order as the language's Keywords
+//
+// symbols: the kind of each symbol, in the same order as the language's Symbols
+//
+// rules: the kind of each token rule, in the same order as the language's TokenRules
+//
+// keywordLookup: the kind of each keyword, keyed by the keyword's text
+//
+// symbolLookup: the kind of each symbol, keyed by the symbol's text
+type languageKinds struct {
+	registry      *tk.KindRegistry
+	keywords      []tk.Kind
+	symbols       []tk.Kind
+	rules         []tk.Kind
+	keywordLookup map[string]tk.Kind
+	symbolLookup  map[string]tk.Kind
+}
+
+// buildLanguageKinds
+// Registers the built-in kinds, followed by a kind for every keyword, then every symbol and then every token rule.
+// Keywords and symbols with the same symbolic name share a kind, as do token rules with the same names and category.
+// If the same text is listed more than once, the last listing is what the text is identified as.
+func buildLanguageKinds(symbols [][]string, keywords []string, rules []TokenRule) *languageKinds {
+	kinds := &languageKinds{
+		registry:      tk.NewKindRegistry(),
+		keywords:      make([]tk.Kind, len(keywords)),
+		symbols:       make([]tk.Kind, len(symbols)),
+		rules:         make([]tk.Kind, len(rules)),
+		keywordLookup: make(map[string]tk.Kind, len(keywords)),
+		symbolLookup:  make(map[string]tk.Kind, len(symbols)),
+	}
+
+	kinds.registry.Register(tk.KIND_IDENTIFIER, RULENAME_KEYWORD, SYMBOLIC_NAME_NON_KEYWORD)
+	kinds.registry.Register(tk.KIND_UNKNOWN, RULENAME_SYMBOL, SYMBOLIC_NAME_UNKNOWN_SYMBOL)
+	kinds.registry.Register(tk.KIND_ERROR, RULENAME_OTHER, SYMBOLIC_NAME_ERROR)
+	kinds.registry.Register(tk.KIND_INDENT, RULENAME_OTHER, SYMBOLIC_NAME_INDENT)
+	kinds.registry.Register(tk.KIND_DEDENT, RULENAME_OTHER, SYMBOLIC_NAME_DEDENT)
+	kinds.registry.Register(tk.KIND_STRING, RULENAME_OTHER, SYMBOLIC_NAME_STRING)
+	kinds.registry.Register(tk.KIND_NUMBER, RULENAME_OTHER, SYMBOLIC_NAME_NUMBER)
+	kinds.registry.Register(tk.KIND_WHITESPACE, RULENAME_OTHER, SYMBOLIC_NAME_WHITESPACE)
+	kinds.registry.Register(tk.KIND_NEWLINE, RULENAME_OTHER, SYMBOLIC_NAME_NEWLINE)
+	kinds.registry.Register(tk.KIND_COMMENT, RULENAME_OTHER, SYMBOLIC_NAME_COMMENT)
+
+	for i, keyword := range keywords {
+		kinds.keywords[i] = kinds.registry.RegisterNext(tk.CATEGORY_KEYWORD, RULENAME_KEYWORD, strings.ToUpper(keyword))
+		kinds.keywordLookup[keyword] = kinds.keywords[i]
+	}
+	for i, symbol := range symbols {
+		kinds.symbols[i] = kinds.registry.RegisterNext(tk.CATEGORY_OPERATOR, RULENAME_SYMBOL, strings.ToUpper(symbol[1]))
+		kinds.symbolLookup[symbol[0]] = kinds.symbols[i]
+	}
+	for i, rule := range rules {
+		kinds.rules[i] = kinds.registry.RegisterNext(rule.Category, rule.RuleName, rule.SymbolicName)
+	}
+
+	return kinds
+}
+
+// kindTable
+// Returns the kinds registered for the language. If the language's keywords, symbols or token rules were changed
+// without calling ConfigureGeneral or ConfigureTokenRule, the kinds are registered again without modifying the language.
+func (lang *Language) kindTable() *languageKinds {
+	kinds := lang.kinds
+	if kinds == nil || len(kinds.keywords) != len(lang.Keywords) || len(kinds.symbols) != len(lang.Symbols) ||
+		len(kinds.rules) != len(lang.TokenRules) {
+		kinds = buildLanguageKinds(lang.Symbols, lang.Keywords, lang.TokenRules)
+	}
+	return kinds
+}
+
+// Kinds
+// Returns the registry of every kind a token of this language may be
+func (lang *Language) Kinds() *tk.KindRegistry {
+	return lang.kindTable().registry
+}
+
+// KeywordKind
+// Returns the kind of the provided keyword.
+// Returns false if the text is not one of the language's keywords.
+func (lang *Language) KeywordKind(keyword string) (tk.Kind, bool) {
+	kind, found := lang.kindTable().keywordLookup[keyword]
+	if !found {
+		return tk.KIND_IDENTIFIER, false
+	}
+	return kind, true
+}
+
+// SymbolKind
+// Returns the kind of the provided symbol (e.g. "(").
+// Returns false if the text is not one of the language's symbols.
+func (lang *Language) SymbolKind(symbol string) (tk.Kind, bool) {
+	kind, found := lang.kindTable().symbolLookup[symbol]
+	if !found {
+		return tk.KIND_UNKNOWN, false
+	}
+	return kind, true
+}
+
+// setTokenKind
+// Identifies the token as the provided kind, using the names registered for it in this run
+func (tkzr *Tokenizer) setTokenKind(token *tk.Token, kind tk.Kind) {
+	token.SetKind(kind, tkzr.kinds.registry)
+}
@@ -0,0 +1,69 @@
+package tokenizer
+
+import tk "tp/src/tokenizer/tokens"
+
+// Language
+// Defines how the text of a language is split up into tokens.
+// A Language is only read while tokenizing, so once it is configured, one Language can be
+// shared by any number of Tokenizers, including Tokenizers running on different goroutines.
+// Use NewTokenizer to create a Tokenizer for a Language.
+type Language struct {
+	LanguageType string
+	Symbols      [][]string
+	Keywords     []string
+	symbolTrie   *symbolTrie
+	kinds        *languageKinds
+
+	// Scope Info
+	ScopeStartFunction func(tkzr *Tokenizer) bool
+	ScopeEndFunction   func(tkzr *Tokenizer) bool
+	ScopesEndWithText  bool // Whether scopes still open at the end of the text are expected (e.g. indentation based scopes)
+	// Note: These scope functions are intended to find MOST scopes... not all scopes
+	ScopeHeaders *ScopeHeaderRules // How the headers of scopes are found and classified, or nil if they are not (see ConfigureScopeHeaders)
+
+	// String Info
+	StringStartFunction func(tkzr *Tokenizer) bool
+	StringEndFunction   func(tkzr *Tokenizer) bool
+	IncludeStrings      bool
+	SingleLineStrings   []string // The StartInfo of strings which cannot span multiple lines, used to resynchronize in recovery mode
+
+	// Comment Info
+	CommentStartFunction func(tkzr *Tokenizer) bool
+	CommentEndFunction   func(tkzr *Tokenizer) bool
+	IncludeComments      bool
+	NestedComments       []string // The StartInfo of comments which may contain comments like themselves, all of which must end before they do
+
+	// Keyword Info
+	IsKeywordCharacter func(c rune) bool
+
+	// Number Info
+	NumberRules *NumberLiteralRules
+
+	// Token Rule Info
+	TokenRules        []TokenRule // Ordered by priority (see ConfigureTokenRule)
+	tokenRuleMatchers []*tokenRuleMatcher
+
+	// Mode Info
+	Modes map[string]*LexerMode // The modes callbacks may switch to (see ConfigureMode)
+
+	// Indentation Info
+	IndentationRules *IndentationRules // How indentation is tracked, or nil if it is not (see ConfigureIndentation)
+
+	// Whitespace Info
+	NumOfSpacesEquallyTab int
+	IgnoreWhitespace      bool
+	IgnoreNewLines        bool
+	UnicodeLineBreaks     bool // Whether the line separator (U+2028) and paragraph separator (U+2029) end lines, like "\n", "\r\n" and "\r" do
+
+	// Final Steps
+	FinalSteps func(tkzr *Tokenizer, finalScope *tk.ScopeObj) error
+}
+
+// Copy
+// Returns a copy of the language which can be configured without affecting the original.
+// The copy is shallow, which is enough since the Configure methods replace the values of the fields they set
+// rather than modifying them. Slices and maps the language holds (e.g. Symbols) should not be modified in place.
+func (lang *Language) Copy() *Language {
+	languageCopy := *lang
+	return &languageCopy
+}
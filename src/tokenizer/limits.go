@@ -0,0 +1,124 @@
+package tokenizer
+
+import (
+	"fmt"
+	tk "tp/src/tokenizer/tokens"
+)
+
+// Limit names
+const (
+	LIMIT_INPUT_BYTES  = "MaxInputBytes"  // The text was longer than Limits.MaxInputBytes
+	LIMIT_TOKENS       = "MaxTokens"      // The text had more tokens than Limits.MaxTokens
+	LIMIT_SCOPE_DEPTH  = "MaxScopeDepth"  // A scope was opened within more scopes than Limits.MaxScopeDepth
+	LIMIT_TOKEN_LENGTH = "MaxTokenLength" // A token was longer than Limits.MaxTokenLength
+)
+
+// contextCheckInterval
+// The number of steps (or characters of a single token) between checks of whether the context was cancelled
+const contextCheckInterval = 256
+
+// Limits
+// Bounds how much work a single run of the tokenizer may do, so a pathological text (e.g. a huge minified line
+// or a string which is never ended) cannot hold on to a worker for long. A limit of 0 (or less) is not checked.
+// Once a limit is reached, tokenizing stops with a *LimitError, and the result holds what was found before the limit was reached.
+//
+// MaxInputBytes: the number of bytes of text which are tokenized. Text beyond it is never read
+//
+// MaxTokens: the number of tokens which may be found, not counting scopes
+//
+// MaxScopeDepth: the number of scopes a scope may be opened within
+//
+// MaxTokenLength: the number of bytes a single token may span
+type Limits struct {
+	MaxInputBytes  int
+	MaxTokens      int
+	MaxScopeDepth  int
+	MaxTokenLength int
+}
+
+// LimitError
+// The error returned when tokenizing stopped because one of the Limits was reached
+//
+// Limit: one of the LIMIT_ constants, which identifies the limit which was reached
+//
+// Max: the value of the limit
+//
+// Position: where tokenizing stopped
+type LimitError struct {
+	Limit    string
+	Max      int
+	Position tk.Position
+}
+
+// Error
+// Returns the error as a string
+func (err *LimitError) Error() string {
+	return fmt.Sprintf("tokenizing stopped at %s: %s of %d was reached", err.Position.ToString(), err.Limit, err.Max)
+}
+
+// stop
+// Stops the run at the provided position. Nothing found afterwards is added, and the scopes which are
+// still open are closed at the position (see finishStopped). Only the first reason to stop is kept.
+func (tkzr *Tokenizer) stop(err error, position tk.Position) {
+	if tkzr.stopError != nil {
+		return
+	}
+	tkzr.stopError = err
+	tkzr.stopPosition = position
+}
+
+// stopAtLimit
+// Stops the run because the named limit was reached at the provided position
+func (tkzr *Tokenizer) stopAtLimit(limit string, max int, position tk.Position) {
+	tkzr.stop(&LimitError{Limit: limit, Max: max, Position: position}, position)
+}
+
+// interrupted
+// Returns true if the run has been stopped, checking whether the context was cancelled every so often
+func (tkzr *Tokenizer) interrupted() bool {
+	if tkzr.stopError != nil {
+		return true
+	}
+	tkzr.stepsUntilContextCheck--
+	if tkzr.stepsUntilContextCheck > 0 {
+		return false
+	}
+	tkzr.stepsUntilContextCheck = contextCheckInterval
+	if err := tkzr.ctx.Err(); err != nil {
+		position := tkzr.PositionOf(tkzr.currentIndex)
+		tkzr.stop(fmt.Errorf("tokenizing stopped at %s: %w", position.ToString(), err), position)
+		return true
+	}
+	return false
+}
+
+// tokenTooLong
+// Returns true, stopping the run, if the token which began at the start index and reaches the end index is longer than MaxTokenLength
+func (tkzr *Tokenizer) tokenTooLong(startIndex int, endIndex int) bool {
+	maxLength := tkzr.Limits.MaxTokenLength
+	if maxLength <= 0 || endIndex-startIndex <= maxLength {
+		return false
+	}
+	tkzr.stopAtLimit(LIMIT_TOKEN_LENGTH, maxLength, tkzr.PositionOf(startIndex))
+	return true
+}
+
+// finishStopped
+// Ends a run which was stopped, closing every scope which is still open where it stopped
+func (tkzr *Tokenizer) finishStopped() {
+	for ; tkzr.queuedDepth > 0; tkzr.queuedDepth-- {
+		tkzr.events = append(tkzr.events, TokenEvent{Type: EVENT_SCOPE_CLOSE, Position: tkzr.stopPosition})
+	}
+	tkzr.finished = true
+}
+
+// finishTruncated
+// Ends a run whose text was cut short by MaxInputBytes. Since the rest of the text was never read,
+// the scopes which are still open are closed without being reported.
+func (tkzr *Tokenizer) finishTruncated() {
+	tkzr.addPotentialKeyword()
+	endOfText := tkzr.PositionOf(tkzr.source.end())
+	tkzr.fillGap(endOfText.Offset, endOfText.Line, tkzr.currentTabLevel)
+	tkzr.stopAtLimit(LIMIT_INPUT_BYTES, tkzr.Limits.MaxInputBytes, endOfText)
+	tkzr.finishStopped()
+}
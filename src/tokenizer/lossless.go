@@ -0,0 +1,123 @@
+package tokenizer
+
+import (
+	tk "tp/src/tokenizer/tokens"
+	"unicode"
+	"unicode/utf8"
+)
+
+// emitLosslessToken
+// Queues a token while in lossless mode. The token's text is replaced by the exact text it spans,
+// and any text between the previous token and this one is queued first as whitespace and newline tokens.
+// Whitespace and newline tokens created by the tokenizer itself are dropped, since that text is
+// always recreated exactly from the gaps between tokens. Indent and dedent tokens span no text, so they are kept as they are.
+func (tkzr *Tokenizer) emitLosslessToken(token *tk.Token) {
+	indentationToken := token.Kind == tk.KIND_INDENT || token.Kind == tk.KIND_DEDENT
+	if !indentationToken && (token.End.Offset <= token.Start.Offset || isTriviaText(token.Text)) {
+		return
+	}
+	if token.Start.Offset < tkzr.losslessEnd.Offset {
+		// Should never happen, but no text may belong to two tokens
+		return
+	}
+
+	tkzr.fillGap(token.Start.Offset, token.Start.Line, token.TabNumber)
+	if text := tkzr.source.slice(token.Start.Offset, token.End.Offset); len(text) == token.End.Offset-token.Start.Offset {
+		token.Text = text
+	}
+	tkzr.queueToken(token)
+	tkzr.losslessEnd = token.End
+	tkzr.losslessTabLevel = token.TabNumber
+}
+
+// fillGap
+// Queues the text between the end of the previous token and the provided index as whitespace
+// and newline tokens. Any other characters found in the gap are queued as unknown symbols.
+//
+// nextLine: the line of whatever comes after the gap
+//
+// nextTabLevel: the tab level of whatever comes after the gap, which is given to the tokens on its line
+func (tkzr *Tokenizer) fillGap(index int, nextLine int, nextTabLevel int) {
+	if !tkzr.LosslessMode || index <= tkzr.losslessEnd.Offset {
+		return
+	}
+
+	gap := tkzr.source.slice(tkzr.losslessEnd.Offset, index)
+	for len(gap) > 0 {
+		length, kind := nextTriviaLength(gap, tkzr.UnicodeLineBreaks)
+		text := gap[:length]
+		gap = gap[length:]
+
+		tabLevel := nextTabLevel
+		if tkzr.losslessEnd.Line != nextLine {
+			tabLevel = tkzr.losslessTabLevel
+		}
+		start := tkzr.losslessEnd
+		end := advancePosition(start, text, tkzr.UnicodeLineBreaks)
+
+		newToken := tk.CreateUnidentifiedToken(text, start.Line, tabLevel)
+		tkzr.setTokenKind(&newToken, kind)
+		newToken.SetSpan(start, end)
+		tkzr.queueToken(&newToken)
+
+		tkzr.losslessEnd = end
+		tkzr.losslessTabLevel = tabLevel
+	}
+}
+
+// nextTriviaLength
+// Returns the length (in bytes) and kind of the token at the start of the gap.
+// A line break (see lineBreakLength) is a token of its own, other whitespace is grouped together,
+// and any other characters are grouped together as unknown symbols.
+func nextTriviaLength(gap string, unicodeLineBreaks bool) (int, tk.Kind) {
+	if length := lineBreakLength(gap, unicodeLineBreaks); length > 0 {
+		return length, tk.KIND_NEWLINE
+	}
+
+	whitespace := isTriviaSpace(gap)
+	length := 0
+	for length < len(gap) && lineBreakLength(gap[length:], unicodeLineBreaks) == 0 && isTriviaSpace(gap[length:]) == whitespace {
+		_, width := utf8.DecodeRuneInString(gap[length:])
+		length += width
+	}
+	if whitespace {
+		return length, tk.KIND_WHITESPACE
+	}
+	return length, tk.KIND_UNKNOWN
+}
+
+// isTriviaSpace
+// Returns true if the text begins with a whitespace character
+func isTriviaSpace(text string) bool {
+	char, _ := utf8.DecodeRuneInString(text)
+	return unicode.IsSpace(char)
+}
+
+// isTriviaText
+// Returns true if the text is made up of only whitespace and newlines
+func isTriviaText(text string) bool {
+	for _, char := range text {
+		if !unicode.IsSpace(char) {
+			return false
+		}
+	}
+	return true
+}
+
+// advancePosition
+// Returns the position right after the text, given the position the text begins at
+func advancePosition(position tk.Position, text string, unicodeLineBreaks bool) tk.Position {
+	for index := 0; index < len(text); {
+		if length := lineBreakLength(text[index:], unicodeLineBreaks); length > 0 {
+			position.Line++
+			position.Column = 1
+			index += length
+			continue
+		}
+		_, width := utf8.DecodeRuneInString(text[index:])
+		position.Column++
+		index += width
+	}
+	position.Offset += len(text)
+	return position
+}
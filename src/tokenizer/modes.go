@@ -0,0 +1,276 @@
+package tokenizer
+
+import tk "tp/src/tokenizer/tokens"
+
+// MODE_DEFAULT
+// The name of the mode the tokenizer starts in, which uses the callbacks of the language itself
+const MODE_DEFAULT = ""
+
+// LexerMode
+// Defines a set of callbacks the tokenizer switches to while the mode is on the top of the mode stack,
+// for languages whose rules depend on what the text is within (e.g. the expressions interpolated into
+// template strings). Any callback which is nil is the language's own callback.
+//
+// EndFunction: returns true if the mode ends at the current index, in which case the mode is popped off the stack.
+// The EndInfo it sets is added as a token (e.g. the "}" ending an interpolated expression, which must set one). Without an EndInfo,
+// the character at the current index is dealt with again by the mode underneath. It is only checked once every scope opened
+// within the mode has been closed. If it is nil, the mode only ends when a callback calls PopMode
+type LexerMode struct {
+	StringStartFunction  func(tkzr *Tokenizer) bool
+	StringEndFunction    func(tkzr *Tokenizer) bool
+	CommentStartFunction func(tkzr *Tokenizer) bool
+	CommentEndFunction   func(tkzr *Tokenizer) bool
+	ScopeStartFunction   func(tkzr *Tokenizer) bool
+	ScopeEndFunction     func(tkzr *Tokenizer) bool
+	EndFunction          func(tkzr *Tokenizer) bool
+}
+
+// modeFrame
+// Defines a mode which was pushed onto the mode stack
+//
+// name: the name of the mode
+//
+// callbacks: the callbacks used while the mode is on the top of the stack, with the language's callbacks in place of any nil ones
+//
+// openScopes: the number of scopes which were open right after the mode was pushed
+//
+// interpolation: whether the mode tokenizes an expression interpolated into a string,
+// in which case the string continues once the mode ends
+//
+// delimiter: the text which began the interpolated expression
+//
+// stringEndFunction: the end function of the string the expression was interpolated into
+//
+// stringEndInfo: the EndInfo of the string the expression was interpolated into
+type modeFrame struct {
+	name              string
+	callbacks         LexerMode
+	openScopes        int
+	interpolation     bool
+	delimiter         string
+	stringEndFunction func(tkzr *Tokenizer) bool
+	stringEndInfo     string
+}
+
+// ConfigureMode
+// This function adds a mode the tokenizer may switch to with PushMode or Interpolate (see LexerMode).
+// This method is not necessary to be run; without any modes, the language's callbacks are always used.
+//
+// name: the name the mode is pushed by. MODE_DEFAULT cannot be used, as it is the language itself
+//
+// mode: the callbacks of the mode
+func (lang *Language) ConfigureMode(name string, mode LexerMode) {
+	if name == MODE_DEFAULT {
+		return
+	}
+	// A new map is made, so copies of the language are not affected
+	modes := make(map[string]*LexerMode, len(lang.Modes)+1)
+	for existingName, existingMode := range lang.Modes {
+		modes[existingName] = existingMode
+	}
+	modes[name] = &mode
+	lang.Modes = modes
+}
+
+// resolveMode
+// Returns the callbacks of the mode, using the language's callbacks in place of any nil ones
+func (lang *Language) resolveMode(mode *LexerMode) LexerMode {
+	resolved := LexerMode{}
+	if mode != nil {
+		resolved = *mode
+	}
+	if resolved.StringStartFunction == nil {
+		resolved.StringStartFunction = lang.StringStartFunction
+	}
+	if resolved.StringEndFunction == nil {
+		resolved.StringEndFunction = lang.StringEndFunction
+	}
+	if resolved.CommentStartFunction == nil {
+		resolved.CommentStartFunction = lang.CommentStartFunction
+	}
+	if resolved.CommentEndFunction == nil {
+		resolved.CommentEndFunction = lang.CommentEndFunction
+	}
+	if resolved.ScopeStartFunction == nil {
+		resolved.ScopeStartFunction = lang.ScopeStartFunction
+	}
+	if resolved.ScopeEndFunction == nil {
+		resolved.ScopeEndFunction = lang.ScopeEndFunction
+	}
+	return resolved
+}
+
+// Mode
+// Returns the name of the mode on the top of the mode stack, or MODE_DEFAULT if no mode has been pushed
+func (tkzr *Tokenizer) Mode() string {
+	if len(tkzr.modes) == 0 {
+		return MODE_DEFAULT
+	}
+	return tkzr.modes[len(tkzr.modes)-1].name
+}
+
+// InterpolationDepth
+// Returns the number of interpolated expressions the current index is within (see Interpolate)
+func (tkzr *Tokenizer) InterpolationDepth() int {
+	depth := 0
+	for _, frame := range tkzr.modes {
+		if frame.interpolation {
+			depth++
+		}
+	}
+	return depth
+}
+
+// isInterpolationScope
+// Returns true if the scope which was the last of the provided number of open scopes to be opened is an interpolated expression
+func (tkzr *Tokenizer) isInterpolationScope(openScopes int) bool {
+	for _, frame := range tkzr.modes {
+		if frame.interpolation && frame.openScopes == openScopes {
+			return true
+		}
+	}
+	return false
+}
+
+// PushMode
+// Switches to the named mode, which stays in use until it ends or is popped.
+// Returns false if the language has no mode with this name.
+func (tkzr *Tokenizer) PushMode(name string) bool {
+	mode, found := tkzr.Modes[name]
+	if !found {
+		return false
+	}
+	tkzr.pushModeFrame(&modeFrame{name: name, callbacks: tkzr.resolveMode(mode)})
+	return true
+}
+
+// PopMode
+// Switches back to the mode which was in use before the current mode was pushed.
+// Returns false if no mode has been pushed.
+//
+// A mode started by Interpolate should be ended by its EndFunction rather than popped,
+// since popping it leaves the string it was interpolated into unfinished.
+func (tkzr *Tokenizer) PopMode() bool {
+	if len(tkzr.modes) == 0 {
+		return false
+	}
+	tkzr.modes[len(tkzr.modes)-1] = nil
+	tkzr.modes = tkzr.modes[:len(tkzr.modes)-1]
+	tkzr.callbacks = tkzr.defaultCallbacks
+	if len(tkzr.modes) > 0 {
+		tkzr.callbacks = &tkzr.modes[len(tkzr.modes)-1].callbacks
+	}
+	return true
+}
+
+// pushModeFrame
+// Pushes the mode onto the mode stack and switches to its callbacks
+func (tkzr *Tokenizer) pushModeFrame(frame *modeFrame) {
+	frame.openScopes = len(tkzr.openScopes)
+	tkzr.modes = append(tkzr.modes, frame)
+	tkzr.callbacks = &frame.callbacks
+}
+
+// Interpolate
+// This should be called by a string's end function when an interpolated expression begins at the current index
+// (e.g. the "${" of `total: ${price * count}`), in which case the end function should return what this returns.
+//
+// The string is split at the index: the text before it becomes a string token, the delimiter becomes a token which opens a
+// scope, and the expression is tokenized in the named mode, with its tokens placed in that scope. Once the mode's EndFunction
+// finds the end of the expression, the scope is closed and the rest of the string continues as another string token.
+//
+// delimiter: the text which begins the expression (e.g. "${")
+//
+// mode: the name of the mode the expression is tokenized in
+//
+// Returns false, leaving the string alone, if the language has no mode with this name.
+func (tkzr *Tokenizer) Interpolate(delimiter string, mode string) bool {
+	lexerMode, found := tkzr.Modes[mode]
+	if !found || delimiter == "" {
+		return false
+	}
+	tkzr.pendingInterpolation = &modeFrame{name: mode, callbacks: tkzr.resolveMode(lexerMode), interpolation: true, delimiter: delimiter}
+	return true
+}
+
+// addString
+// Creates the string (or the part of a string) which begins at the current index using the provided end function.
+// If an interpolated expression begins within the string, the expression's scope is opened and its mode is pushed.
+func (tkzr *Tokenizer) addString(endFunction func(tkzr *Tokenizer) bool) {
+	resultingToken := tkzr.applyFunctionUntilFailureTokenCreation(endFunction, tk.KIND_STRING)
+	// A string may be split right at its start or between two interpolated expressions, leaving nothing in between
+	empty := resultingToken.Start.Offset == resultingToken.End.Offset
+	if (tkzr.IncludeStrings || tkzr.LosslessMode || resultingToken.Kind == tk.KIND_ERROR) && !empty {
+		tkzr.emitToken(resultingToken)
+	} else if tkzr.indentation != nil && !empty {
+		// Strings left out of the output still begin and continue logical lines
+		tkzr.trackIndentation(resultingToken)
+	}
+	if tkzr.pendingInterpolation != nil {
+		tkzr.beginInterpolation(endFunction)
+	}
+	tkzr.applyAfterFunction()
+}
+
+// beginInterpolation
+// Adds the delimiter of the interpolated expression which begins at the current index, opens the expression's scope
+// and pushes its mode. The EndInfo becomes the delimiter, so the index is moved to its last character afterwards.
+func (tkzr *Tokenizer) beginInterpolation(endFunction func(tkzr *Tokenizer) bool) {
+	frame := tkzr.pendingInterpolation
+	tkzr.pendingInterpolation = nil
+	frame.stringEndFunction = endFunction
+	frame.stringEndInfo = tkzr.EndInfo
+
+	tkzr.functionStartIndex = tkzr.currentIndex
+	opener := tkzr.createTokenType(frame.delimiter, tkzr.currentIndex)
+	tkzr.emitToken(opener)
+	tkzr.emitScopeOpen(opener)
+	tkzr.pushModeFrame(frame)
+
+	tkzr.StartInfo = ""
+	tkzr.EndInfo = frame.delimiter
+}
+
+// applyModeEnd
+// Checks whether the mode on the top of the mode stack ends at the current index. If it does, the mode is popped
+// and its EndInfo is added as a token. If the mode tokenized an interpolated expression, the expression's
+// scope is closed and the rest of the string it was interpolated into is tokenized.
+func (tkzr *Tokenizer) applyModeEnd() bool {
+	if len(tkzr.modes) == 0 {
+		return false
+	}
+	frame := tkzr.modes[len(tkzr.modes)-1]
+	if frame.callbacks.EndFunction == nil || len(tkzr.openScopes) > frame.openScopes || !frame.callbacks.EndFunction(tkzr) {
+		return false
+	}
+
+	tkzr.applyBeforeFunction()
+	tkzr.PopMode()
+	// The position is found before the token, since positions are cheapest to find in order
+	start := tkzr.PositionOf(tkzr.functionStartIndex)
+	var closer *tk.Token
+	if tkzr.EndInfo != "" {
+		closer = tkzr.createTokenType(tkzr.EndInfo, tkzr.functionStartIndex)
+	}
+	// The expression's scope may have already been closed by a scope end within it
+	if frame.interpolation && len(tkzr.openScopes) == frame.openScopes {
+		tkzr.emitScopeClose(start, closer)
+	}
+	if closer != nil {
+		tkzr.emitToken(closer)
+	} else if !frame.interpolation {
+		// Without an EndInfo, the character is dealt with again by the mode underneath
+		tkzr.SkipIncrement()
+	}
+	tkzr.applyAfterFunction()
+
+	if frame.interpolation {
+		// The rest of the string begins right after the EndInfo
+		tkzr.functionStartIndex = tkzr.NextIndex(tkzr.currentIndex)
+		tkzr.StartInfo = ""
+		tkzr.EndInfo = frame.stringEndInfo
+		tkzr.tempIgnoreChangesFromIncrement = true
+		tkzr.addString(frame.stringEndFunction)
+	}
+	return true
+}
@@ -0,0 +1,167 @@
+package tokenizer
+
+import (
+	"strings"
+	tk "tp/src/tokenizer/tokens"
+	"unicode/utf8"
+)
+
+// NumberLiteralRules
+// Defines how number literals are written in a language, so that the tokenizer
+// can tell numbers (tk.KIND_NUMBER) apart from names (tk.KIND_IDENTIFIER).
+//
+// RadixPrefixes: maps a prefix (e.g. "0x") to the characters which are valid digits after that prefix (e.g. "0123456789abcdefABCDEF").
+// Prefixes are matched case-insensitively.
+//
+// AllowUnderscores: whether underscores may be placed between digits (e.g. 1_000)
+//
+// AllowDecimalPoint: whether decimal numbers may have a fractional part (e.g. 3.14)
+//
+// AllowLeadingDecimalPoint: whether decimal numbers may begin with the decimal point (e.g. .5)
+//
+// ExponentCharacters: the characters which begin the exponent of a decimal number (e.g. "eE" for 3.14e-2). Empty if exponents are not allowed
+//
+// Suffixes: the suffixes which may follow a number to define its type (e.g. "L" or "f")
+//
+// ImaginarySuffixes: the suffixes which mark a number as imaginary (e.g. "j")
+type NumberLiteralRules struct {
+	RadixPrefixes            map[string]string `json:"radixPrefixes,omitempty" yaml:"radixPrefixes,omitempty"`
+	AllowUnderscores         bool              `json:"allowUnderscores,omitempty" yaml:"allowUnderscores,omitempty"`
+	AllowDecimalPoint        bool              `json:"allowDecimalPoint,omitempty" yaml:"allowDecimalPoint,omitempty"`
+	AllowLeadingDecimalPoint bool              `json:"allowLeadingDecimalPoint,omitempty" yaml:"allowLeadingDecimalPoint,omitempty"`
+	ExponentCharacters       string            `json:"exponentCharacters,omitempty" yaml:"exponentCharacters,omitempty"`
+	Suffixes                 []string          `json:"suffixes,omitempty" yaml:"suffixes,omitempty"`
+	ImaginarySuffixes        []string          `json:"imaginarySuffixes,omitempty" yaml:"imaginarySuffixes,omitempty"`
+}
+
+const decimalDigits = "0123456789"
+
+// matchNumber
+// Determines whether a number literal begins at the provided index.
+// If one does, the index right after the number literal is returned (exclusive).
+// If there is not a number literal at the index, -1 is returned.
+func (tkzr *Tokenizer) matchNumber(index int) int {
+	rules := tkzr.NumberRules
+	if rules == nil || !tkzr.DetermineIfIndexInBound(index) {
+		return -1
+	}
+
+	char := tkzr.GetChar(index)
+	startsWithPoint := char == '.' && rules.AllowDecimalPoint && rules.AllowLeadingDecimalPoint &&
+		tkzr.isDigitAt(tkzr.NextIndex(index), decimalDigits)
+	if !startsWithPoint && !strings.ContainsRune(decimalDigits, char) {
+		return -1
+	}
+
+	// Radix prefixed numbers (hex, binary, octal, ...), using the longest prefix found
+	longestPrefix := ""
+	for prefix, digits := range rules.RadixPrefixes {
+		prefixEnd := index + len(prefix)
+		if len(prefix) <= len(longestPrefix) {
+			continue
+		}
+		if strings.EqualFold(tkzr.textSlice(index, prefixEnd), prefix) && tkzr.isDigitAt(prefixEnd, digits) {
+			longestPrefix = prefix
+		}
+	}
+	if longestPrefix != "" {
+		end := tkzr.matchDigits(index+len(longestPrefix), rules.RadixPrefixes[longestPrefix])
+		return tkzr.matchNumberSuffix(end)
+	}
+
+	// Decimal numbers
+	end := index
+	if !startsWithPoint {
+		end = tkzr.matchDigits(index, decimalDigits)
+	}
+	if rules.AllowDecimalPoint && tkzr.DetermineIfIndexInBound(end) && tkzr.GetChar(end) == '.' {
+		afterPoint := tkzr.NextIndex(end)
+		if tkzr.isDigitAt(afterPoint, decimalDigits) {
+			end = tkzr.matchDigits(afterPoint, decimalDigits)
+		} else if !startsWithPoint && !tkzr.DetermineIfIndexInBound(afterPoint) {
+			end = afterPoint
+		} else if !startsWithPoint && !tkzr.IsKeywordCharacter(tkzr.GetChar(afterPoint)) && tkzr.GetChar(afterPoint) != '.' {
+			end = afterPoint // e.g. "1." in "x = 1.;"
+		} else if !startsWithPoint && strings.ContainsRune(rules.ExponentCharacters, tkzr.GetChar(afterPoint)) {
+			end = afterPoint // e.g. "1.e5"
+		}
+	}
+	if rules.ExponentCharacters != "" && tkzr.DetermineIfIndexInBound(end) && strings.ContainsRune(rules.ExponentCharacters, tkzr.GetChar(end)) {
+		exponentIndex := tkzr.NextIndex(end)
+		if tkzr.DetermineIfIndexInBound(exponentIndex) && (tkzr.GetChar(exponentIndex) == '+' || tkzr.GetChar(exponentIndex) == '-') {
+			exponentIndex = tkzr.NextIndex(exponentIndex)
+		}
+		if tkzr.isDigitAt(exponentIndex, decimalDigits) {
+			end = tkzr.matchDigits(exponentIndex, decimalDigits)
+		}
+	}
+	return tkzr.matchNumberSuffix(end)
+}
+
+// matchDigits
+// Starting at the provided index, this moves past all characters found in digits
+// (and underscores between digits, if allowed) and returns the index after them.
+func (tkzr *Tokenizer) matchDigits(index int, digits string) int {
+	for tkzr.DetermineIfIndexInBound(index) {
+		char := tkzr.GetChar(index)
+		if strings.ContainsRune(digits, char) {
+			index = tkzr.NextIndex(index)
+		} else if char == '_' && tkzr.NumberRules.AllowUnderscores && tkzr.isDigitOrUnderscoreAt(tkzr.NextIndex(index), digits) {
+			index = tkzr.NextIndex(index)
+		} else {
+			break
+		}
+	}
+	return index
+}
+
+// matchNumberSuffix
+// Given the index right after the digits of a number, this moves past the longest
+// type or imaginary suffix found there (if any) and returns the index after the whole number.
+// A suffix is only used if it is not followed by more keyword characters.
+func (tkzr *Tokenizer) matchNumberSuffix(end int) int {
+	longestSuffix := 0
+	for _, suffixList := range [][]string{tkzr.NumberRules.Suffixes, tkzr.NumberRules.ImaginarySuffixes} {
+		for _, suffix := range suffixList {
+			suffixEnd := end + len(suffix)
+			if suffix == "" || !tkzr.hasPrefixAt(end, suffix) {
+				continue
+			}
+			if tkzr.DetermineIfIndexInBound(suffixEnd) && tkzr.IsKeywordCharacter(tkzr.GetChar(suffixEnd)) {
+				continue
+			}
+			if len(suffix) > longestSuffix {
+				longestSuffix = len(suffix)
+			}
+		}
+	}
+	return end + longestSuffix
+}
+
+// isDigitAt
+// Returns true if the character at the provided index is one of the provided digits
+func (tkzr *Tokenizer) isDigitAt(index int, digits string) bool {
+	return tkzr.DetermineIfIndexInBound(index) && strings.ContainsRune(digits, tkzr.GetChar(index))
+}
+
+// isDigitOrUnderscoreAt
+// Returns true if the character at the provided index is one of the provided digits or an underscore
+func (tkzr *Tokenizer) isDigitOrUnderscoreAt(index int, digits string) bool {
+	return tkzr.isDigitAt(index, digits) || (tkzr.DetermineIfIndexInBound(index) && tkzr.GetChar(index) == '_')
+}
+
+// addMatchedToken
+// Given the index right after a token which begins at the current index (e.g. a number literal),
+// this adds the token to the current scope as a token of the provided kind and moves the index
+// to the last character of the token, so the next increment moves past it entirely.
+// The token must not span multiple lines.
+func (tkzr *Tokenizer) addMatchedToken(end int, kind tk.Kind) {
+	tokenText := tkzr.textSlice(tkzr.currentIndex, end)
+	newToken := tk.CreateUnidentifiedToken(tokenText, tkzr.currentLineNumber, tkzr.currentTabLevel)
+	tkzr.setTokenKind(&newToken, kind)
+	tkzr.setTokenSpan(&newToken, tkzr.currentIndex, end)
+	tkzr.emitToken(&newToken)
+
+	_, lastCharWidth := utf8.DecodeLastRuneInString(tokenText)
+	tkzr.currentIndex = end - lastCharWidth
+}
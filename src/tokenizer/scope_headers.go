@@ -0,0 +1,246 @@
+package tokenizer
+
+import tk "tp/src/tokenizer/tokens"
+
+// Scope types
+const (
+	SCOPE_TYPE_FILE      = "File"      // The scope holding the whole text
+	SCOPE_TYPE_CLASS     = "Class"     // The body of a class, including anonymous classes
+	SCOPE_TYPE_INTERFACE = "Interface" // The body of an interface
+	SCOPE_TYPE_ENUM      = "Enum"      // The body of an enum
+	SCOPE_TYPE_METHOD    = "Method"    // The body of a method, i.e. a function which is declared within a class
+	SCOPE_TYPE_FUNCTION  = "Function"  // The body of a function which is not declared within a class
+	SCOPE_TYPE_LAMBDA    = "Lambda"    // The body of a lambda
+	SCOPE_TYPE_IF        = "If"        // The body of an if statement, including else if branches (e.g. elif)
+	SCOPE_TYPE_ELSE      = "Else"      // The body of an else branch
+	SCOPE_TYPE_LOOP      = "Loop"      // The body of a loop (e.g. for, while or do)
+	SCOPE_TYPE_TRY       = "Try"       // The body of a try statement
+	SCOPE_TYPE_CATCH     = "Catch"     // The body of a catch (or except) clause
+	SCOPE_TYPE_FINALLY   = "Finally"   // The body of a finally clause
+	SCOPE_TYPE_SWITCH    = "Switch"    // The body of a switch (or match) statement
+	SCOPE_TYPE_CASE      = "Case"      // The body of a single case of a switch (or match) statement
+	SCOPE_TYPE_WITH      = "With"      // The body of a with statement
+	SCOPE_TYPE_BLOCK     = "Block"     // A block which is not part of any other statement (e.g. a block within a method)
+)
+
+// ScopeClassifier
+// Labels a scope by its header (see ScopeHeaderRules). Returns the type of the scope (one of the SCOPE_TYPE_ constants,
+// or a type of the language's own) along with the name of what it is the body of, which is empty for scopes without a name
+// (e.g. an if statement). found is false if the classifier does not know what the scope is.
+//
+// The scope has its opener and parent set, but none of its own tokens, since it has only just been opened.
+type ScopeClassifier func(header []*tk.Token, scope *tk.ScopeObj) (scopeType string, name string, found bool)
+
+// ScopeHeaderRules
+// Defines how the header of a scope is found and classified. The header of a scope is made up of the tokens before its opener
+// which belong to the same statement (e.g. "public void main ( String [ ] args )" before the "{" of a Java method),
+// not counting trivia. It begins after the end of the previous statement, after the previous scope, or at the start
+// of the scope it is within, whichever is closest.
+//
+// StatementEnds: the texts of the tokens which end a statement (e.g. ";"). Within brackets, they do not (e.g. the ";"s of a for loop)
+//
+// Brackets: the pairs of texts which open and close brackets (e.g. {"(", ")"}). An opener within brackets (e.g. of an argument)
+// has a header which begins after the bracket it is within
+//
+// StatementsEndWithLines: whether statements also end with their logical lines, like in Python. A line within brackets or
+// ending with the LineContinuation of the language's IndentationRules does not end a statement
+//
+// Classifiers: the classifiers scopes are labeled with, tried in order. A scope none of them know keeps the UNKNOWN_SCOPE_STRING type
+type ScopeHeaderRules struct {
+	StatementEnds          []string
+	Brackets               [][2]string
+	StatementsEndWithLines bool
+	Classifiers            []ScopeClassifier
+}
+
+// ScopeTypeRule
+// Labels the scopes whose header has a keyword (see KeywordScopeClassifier)
+//
+// Keyword: the text of the token which marks the scope's type (e.g. "class")
+//
+// Type: the type of the scope
+//
+// Named: whether the token right after the keyword is the name of the scope (e.g. the "A" of "class A"),
+// if it is an identifier
+type ScopeTypeRule struct {
+	Keyword string
+	Type    string
+	Named   bool
+}
+
+// ConfigureScopeHeaders
+// This function sets up how the headers of scopes are found and how scopes are classified by them (see ScopeHeaderRules).
+// This method is not necessary to be run; without it, scopes have no headers, and only the file-level scope has a type.
+//
+// rules: how the headers of scopes are found and classified
+func (lang *Language) ConfigureScopeHeaders(rules ScopeHeaderRules) {
+	rules.StatementEnds = append([]string(nil), rules.StatementEnds...)
+	rules.Brackets = append([][2]string(nil), rules.Brackets...)
+	rules.Classifiers = append([]ScopeClassifier(nil), rules.Classifiers...)
+	lang.ScopeHeaders = &rules
+}
+
+// KeywordScopeClassifier
+// Returns a classifier which labels a scope by the first of the rules whose keyword is in the scope's header,
+// so rules listed earlier take priority (e.g. "if" is listed before "else" for the header "else if (a)")
+func KeywordScopeClassifier(rules []ScopeTypeRule) ScopeClassifier {
+	rules = append([]ScopeTypeRule(nil), rules...)
+	return func(header []*tk.Token, scope *tk.ScopeObj) (string, string, bool) {
+		for _, rule := range rules {
+			for i, token := range header {
+				if token.Text != rule.Keyword {
+					continue
+				}
+				name := ""
+				if rule.Named && i+1 < len(header) && header[i+1].Kind.IsIdentifier() {
+					name = header[i+1].Text
+				}
+				return rule.Type, name, true
+			}
+		}
+		return "", "", false
+	}
+}
+
+// CallableName
+// Returns the name right before the parenthesized list the header ends with (e.g. the "main" of "void main ( String [ ] args )"),
+// which is the name of a method or function whose parameters are listed. found is false if the header does not end that way.
+func CallableName(header []*tk.Token) (name string, found bool) {
+	if len(header) == 0 || header[len(header)-1].Text != ")" {
+		return "", false
+	}
+	depth := 0
+	for i := len(header) - 1; i >= 0; i-- {
+		switch header[i].Text {
+		case ")":
+			depth++
+		case "(":
+			depth--
+		}
+		if depth != 0 {
+			continue
+		}
+		if i > 0 && header[i-1].Kind.IsIdentifier() {
+			return header[i-1].Text, true
+		}
+		return "", false
+	}
+	return "", false
+}
+
+// classifyScope
+// Finds the header of a scope which was just opened within its parent, and labels the scope with it
+func (tkzr *Tokenizer) classifyScope(scope *tk.ScopeObj) {
+	rules := tkzr.ScopeHeaders
+	if rules == nil {
+		return
+	}
+	header := tkzr.scopeHeader(scope.GetScopeParent(), scope.GetOpener())
+	scope.SetHeader(header)
+	for _, classifier := range rules.Classifiers {
+		if scopeType, name, found := classifier(header, scope); found {
+			scope.SetType(scopeType)
+			scope.SetName(name)
+			return
+		}
+	}
+}
+
+// scopeHeader
+// Returns the header of the scope opened by the opener, which is made up of the tokens at the end of the parent scope
+// (see ScopeHeaderRules)
+func (tkzr *Tokenizer) scopeHeader(parent *tk.ScopeObj, opener *tk.Token) []*tk.Token {
+	rules := tkzr.ScopeHeaders
+	continuation := ""
+	if tkzr.IndentationRules != nil {
+		continuation = tkzr.IndentationRules.LineContinuation
+	}
+
+	tokens := parent.GetTokenList()
+	// The scope's own token has already been added to the parent, right after its opener
+	end := len(tokens) - 1
+	if end > 0 && tokens[end-1] == opener {
+		end--
+	}
+	// The header begins at the first token which is not trivia, so the trivia before it (e.g. a doc comment) is left out
+	start := end
+	depth := 0
+	for i := end; i > 0; i-- {
+		token := tokens[i-1]
+		if token.Kind.IsTrivia() {
+			continue
+		}
+		if token.ValidScopeToken() || token.Kind == tk.KIND_INDENT || token.Kind == tk.KIND_DEDENT {
+			break
+		}
+		// The closer of the previous scope ends its statement (e.g. the "}" of "} else {")
+		if i >= 2 && tokens[i-2].ValidScopeToken() && tokens[i-2].GetScopeToken().GetCloser() == token {
+			break
+		}
+		if depth == 0 && rules.StatementsEndWithLines && start < end && token.End.Line < tokens[start].Start.Line && token.Text != continuation {
+			break
+		}
+		if depth == 0 && containsString(rules.StatementEnds, token.Text) {
+			break
+		}
+		if isBracket(rules.Brackets, token.Text, 1) {
+			depth++
+		} else if isBracket(rules.Brackets, token.Text, 0) {
+			if depth == 0 {
+				break
+			}
+			depth--
+		}
+		start = i - 1
+	}
+
+	// Without any trivia within it, the header is part of the parent's tokens, and is not copied
+	header := tokens[start:end:end]
+	for i, token := range header {
+		if isHeaderTrivia(token, continuation) {
+			return filterHeader(header[i:], header[:i], continuation)
+		}
+	}
+	return header
+}
+
+// isHeaderTrivia
+// Returns true if the token is within the tokens of a header without being part of it
+func isHeaderTrivia(token *tk.Token, continuation string) bool {
+	return token.Kind.IsTrivia() || (continuation != "" && token.Text == continuation)
+}
+
+// filterHeader
+// Returns the header made up of the tokens which were already kept followed by the rest of the tokens, without trivia
+func filterHeader(tokens []*tk.Token, kept []*tk.Token, continuation string) []*tk.Token {
+	header := make([]*tk.Token, len(kept), len(kept)+len(tokens))
+	copy(header, kept)
+	for _, token := range tokens {
+		if !isHeaderTrivia(token, continuation) {
+			header = append(header, token)
+		}
+	}
+	return header
+}
+
+// isBracket
+// Returns true if the text opens (side 0) or closes (side 1) one of the brackets
+func isBracket(brackets [][2]string, text string, side int) bool {
+	for _, bracket := range brackets {
+		if bracket[side] == text {
+			return true
+		}
+	}
+	return false
+}
+
+// containsString
+// Returns true if the text is one of the texts
+func containsString(texts []string, text string) bool {
+	for _, str := range texts {
+		if str == text {
+			return true
+		}
+	}
+	return false
+}
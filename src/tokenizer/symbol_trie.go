@@ -0,0 +1,64 @@
+package tokenizer
+
+// symbolTrie
+// Defines a trie of all the symbols a tokenizer can identify.
+// Each node is keyed by a single character, so walking the trie
+// along the text finds every symbol which begins at a given index.
+//
+// children: the nodes for each character which can follow this one
+//
+// isSymbol: whether the characters leading to this node make up a full symbol
+type symbolTrie struct {
+	children map[rune]*symbolTrie
+	isSymbol bool
+}
+
+// buildSymbolTrie
+// Creates a trie from the symbols array (the same format given to ConfigureGeneral)
+func buildSymbolTrie(symbols [][]string) *symbolTrie {
+	root := &symbolTrie{children: make(map[rune]*symbolTrie)}
+	for _, symbol := range symbols {
+		if len(symbol) > 0 && symbol[0] != "" {
+			root.insert(symbol[0])
+		}
+	}
+	return root
+}
+
+// insert
+// Adds a symbol to the trie
+func (trie *symbolTrie) insert(symbol string) {
+	node := trie
+	for _, char := range symbol {
+		child, found := node.children[char]
+		if !found {
+			child = &symbolTrie{children: make(map[rune]*symbolTrie)}
+			node.children[char] = child
+		}
+		node = child
+	}
+	node.isSymbol = true
+}
+
+// matchSymbol
+// Finds the longest symbol which begins at the provided index and returns it.
+// If no symbol (of any length) begins at the index, an empty string is returned.
+func (tkzr *Tokenizer) matchSymbol(index int) string {
+	if tkzr.symbolMatcher == nil {
+		tkzr.initSymbolMatcher()
+	}
+	node := tkzr.symbolMatcher
+	longestEnd := index
+	for currentIndex := index; tkzr.DetermineIfIndexInBound(currentIndex); {
+		child, found := node.children[tkzr.GetChar(currentIndex)]
+		if !found {
+			break
+		}
+		node = child
+		currentIndex = tkzr.NextIndex(currentIndex)
+		if node.isSymbol {
+			longestEnd = currentIndex
+		}
+	}
+	return tkzr.textSlice(index, longestEnd)
+}
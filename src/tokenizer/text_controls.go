@@ -3,33 +3,60 @@ package tokenizer
 import (
 	"errors"
 	"tp/src/util"
+	"unicode/utf8"
 )
 
+// TEXT_SIZE_UNKNOWN
+// The size TextSize returns while the text is being read from a reader, before the whole text has been read
+const TEXT_SIZE_UNKNOWN = -1
+
 // TextSize
-// This returns the size of the text
-// This results in an error and a panic
-// if the text is currently nil
+// This returns the size of the text in bytes
+// This returns 0 if there is currently no text
+//
+// When the text is being read from a reader, the size is only known once the whole
+// text has been read, so TEXT_SIZE_UNKNOWN is returned until then. The rest of
+// the text is never read just to find its size.
 func (tkzr *Tokenizer) TextSize() int {
-	if tkzr.Text == nil {
-		err := errors.New("text is nil. Cannot get text size")
-		util.Error(err.Error(), err)
-		panic(err)
+	if tkzr.source == nil {
+		return 0
+	}
+	if !tkzr.source.eof {
+		return TEXT_SIZE_UNKNOWN
 	}
-	return len(*tkzr.Text)
+	return tkzr.source.end()
 }
 
 // TextRange
 // This will return a substring from the text. This substring
 // will be the contents between the 'begin' and 'end' integer parameters.
 // The being parameter is inclusive while the end parameter is exclusive.
+// Both indices are byte offsets, like the tokenizer's index.
 // This will return an error if the indices are out of range.
 func (tkzr *Tokenizer) TextRange(begin int, end int) (string, error) {
-	if begin >= 0 && begin < tkzr.TextSize() && end > 0 && end < tkzr.TextSize() && begin != end {
-		return (*tkzr.Text)[begin:end], nil
+	if begin >= 0 && end > begin && tkzr.DetermineIfIndexInBound(begin) && tkzr.DetermineIfIndexInBound(end-1) {
+		return tkzr.source.slice(begin, end), nil
 	}
 	return "", errors.New("TextRange bounds were out of bounds or otherwise invalid")
 }
 
+// textSlice
+// Returns the text between the begin (inclusive) and end (exclusive) indices.
+// Unlike TextRange, the end may be the end of the text, and indices outside
+// the text are limited to the text rather than resulting in an error.
+func (tkzr *Tokenizer) textSlice(begin int, end int) string {
+	if end > begin {
+		tkzr.DetermineIfIndexInBound(end - 1)
+	}
+	return tkzr.source.slice(begin, end)
+}
+
+// hasPrefixAt
+// Returns true if the text starting at the provided index begins with the prefix
+func (tkzr *Tokenizer) hasPrefixAt(index int, prefix string) bool {
+	return tkzr.textSlice(index, index+len(prefix)) == prefix
+}
+
 // gatherWhitespace
 // This start at the current index (+ 1) and get the whitespace found there.
 // It will begin looking for the whitespace at this index and stop whenever
@@ -40,20 +67,33 @@ func (tkzr *Tokenizer) TextRange(begin int, end int) (string, error) {
 //
 // It will return a string of this whitespace.
 func (tkzr *Tokenizer) gatherWhitespace(updateCurrentIndex bool) string {
-	gatheredWhitespace := ""
-	index := tkzr.currentIndex + 1
+	whitespaceStart := tkzr.NextIndex(tkzr.currentIndex)
+	index := whitespaceStart
+	lastIndex := tkzr.currentIndex
 	var char rune
 	for tkzr.DetermineIfIndexInBound(index) {
 		char = tkzr.GetChar(index)
-		if util.IsWhitespaceCharacter(char) {
-			gatheredWhitespace += string(char)
-		} else {
+		if !util.IsWhitespaceCharacter(char) {
 			break
 		}
-		index++
+		lastIndex = index
+		index = tkzr.NextIndex(index)
 	}
+	gatheredWhitespace := tkzr.textSlice(whitespaceStart, index)
 	if updateCurrentIndex && gatheredWhitespace != "" {
-		tkzr.currentIndex = index - 1 // TODO: maybe we don't need to do this!
+		tkzr.currentIndex = lastIndex // TODO: maybe we don't need to do this!
 	}
 	return gatheredWhitespace
 }
+
+// EndInfoFirstChar
+// Returns the first character of EndInfo, or 0 if EndInfo is empty.
+// This should be used instead of indexing EndInfo directly, since
+// EndInfo may begin with a multi-byte character.
+func (tkzr *Tokenizer) EndInfoFirstChar() rune {
+	if tkzr.EndInfo == "" {
+		return 0
+	}
+	char, _ := utf8.DecodeRuneInString(tkzr.EndInfo)
+	return char
+}
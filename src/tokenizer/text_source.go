@@ -0,0 +1,258 @@
+package tokenizer
+
+import (
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// streamChunkSize
+// The minimum number of bytes read from a reader at a time
+const streamChunkSize = 32 * 1024
+
+// streamLookbehind
+// The number of bytes kept in memory before the earliest index the tokenizer still
+// needs, so that callbacks can always look back at the previous character(s)
+const streamLookbehind = 64
+
+// textSource
+// Defines where the tokenizer gets its text from. The text is either a string given
+// all at once, or it is read from an io.Reader in chunks. When reading from a reader,
+// only a window of the text is kept in memory; text the tokenizer is done with is
+// discarded as more of the text is read.
+//
+// window: the portion of the text currently held in memory
+//
+// base: the index (byte offset) of the first byte of the window within the whole text
+//
+// reader: where more of the text is read from. This is nil if the text was given as a string
+//
+// eof: whether the whole text has been read
+//
+// readError: the first error (other than io.EOF) which occurred when reading from the reader
+//
+// unicodeLineBreaks: whether U+2028 and U+2029 are line breaks (see lineBreakLength)
+//
+// maxBytes: the number of bytes of the text which may be held, or 0 if it is not limited (see Limits.MaxInputBytes)
+//
+// truncated: whether the text was cut short because it is longer than maxBytes
+type textSource struct {
+	window            string
+	base              int
+	reader            io.Reader
+	eof               bool
+	readError         error
+	buffer            []byte
+	unicodeLineBreaks bool
+	maxBytes          int
+	truncated         bool
+}
+
+// newStringSource
+// Creates a text source which holds the entirety of the provided text
+func newStringSource(text string) *textSource {
+	return &textSource{
+		window: text,
+		base:   0,
+		reader: nil,
+		eof:    true,
+	}
+}
+
+// newReaderSource
+// Creates a text source which reads its text from the provided reader as needed
+func newReaderSource(reader io.Reader) *textSource {
+	return &textSource{
+		window: "",
+		base:   0,
+		reader: reader,
+		eof:    false,
+	}
+}
+
+// end
+// Returns the index (exclusive) of the last byte currently held in memory
+func (src *textSource) end() int {
+	return src.base + len(src.window)
+}
+
+// fill
+// Reads from the reader until the provided index is held in memory or the whole text has been read.
+// Any text before the keepFrom index is no longer needed and may be discarded.
+func (src *textSource) fill(index int, keepFrom int) {
+	emptyReads := 0
+	for index >= src.end() && !src.eof {
+		if drop := keepFrom - src.base; drop > 0 {
+			if drop > len(src.window) {
+				drop = len(src.window)
+			}
+			src.window = src.window[drop:]
+			src.base += drop
+		}
+
+		// Reads at least as much as is already held, so very long tokens do not result in quadratic copying
+		readSize := streamChunkSize
+		if len(src.window) > readSize {
+			readSize = len(src.window)
+		}
+		if cap(src.buffer) < readSize {
+			src.buffer = make([]byte, readSize)
+		}
+
+		// Fills the whole buffer before adding it to the window, since readers
+		// which return little at a time would otherwise result in the window being copied for every read
+		n := 0
+		for n < readSize && !src.eof {
+			read, err := src.reader.Read(src.buffer[n:readSize])
+			n += read
+			if err == io.EOF {
+				src.eof = true
+			} else if err != nil {
+				src.readError = err
+				src.eof = true
+			} else if read == 0 {
+				emptyReads++
+				if emptyReads >= 100 {
+					src.readError = io.ErrNoProgress
+					src.eof = true
+				}
+			}
+		}
+		src.window += string(src.buffer[:n])
+		src.truncate()
+	}
+}
+
+// slice
+// Returns the text between the begin (inclusive) and end (exclusive) indices,
+// limited to what is currently held in memory.
+func (src *textSource) slice(begin int, end int) string {
+	if begin < src.base {
+		begin = src.base
+	}
+	if end > src.end() {
+		end = src.end()
+	}
+	if begin >= end {
+		return ""
+	}
+	return src.window[begin-src.base : end-src.base]
+}
+
+// limitInput
+// Keeps the source from holding more than the provided number of bytes, which is not limited if it is 0 or less.
+// The text is cut at the start of the character the limit falls within.
+func (src *textSource) limitInput(maxBytes int) {
+	src.maxBytes = maxBytes
+	src.truncate()
+}
+
+// truncate
+// Removes the text beyond the source's limit, which is then the end of the text
+func (src *textSource) truncate() {
+	if src.maxBytes <= 0 || src.end() <= src.maxBytes {
+		return
+	}
+	end := src.maxBytes - src.base
+	for end > 0 && end < len(src.window) && !utf8.RuneStart(src.window[end]) {
+		end--
+	}
+	src.window = src.window[:end]
+	src.eof = true
+	src.truncated = true
+}
+
+// needsFill
+// Returns true if more of the text must be read before the index is held in memory
+func (src *textSource) needsFill(index int) bool {
+	return index >= src.end() && !src.eof
+}
+
+// decode
+// Returns the character which begins at the index along with its width in bytes.
+// Every line break is decoded as a single '\n', whatever it is written as (see lineBreakLength),
+// so "\r\n" is one character two bytes wide. The index must be held in memory.
+func (src *textSource) decode(index int) (rune, int) {
+	if b := src.window[index-src.base]; b < utf8.RuneSelf {
+		if b == '\r' {
+			return '\n', lineBreakLength(src.window[index-src.base:], false)
+		}
+		return rune(b), 1
+	}
+	char, width := utf8.DecodeRuneInString(src.window[index-src.base:])
+	if src.unicodeLineBreaks && isUnicodeLineBreak(char) {
+		return '\n', width
+	}
+	return char, width
+}
+
+// streaming
+// Returns true if the text is being read from a reader rather than given all at once
+func (src *textSource) streaming() bool {
+	return src.reader != nil
+}
+
+// decodeLast
+// Returns the character which ends right before the index.
+// If that character is no longer (or not yet) held in memory, 0 is returned.
+func (src *textSource) decodeLast(index int) rune {
+	if index <= src.base || index > src.end() {
+		return 0
+	}
+	char, _ := utf8.DecodeLastRuneInString(src.window[:index-src.base])
+	if char == '\r' || (src.unicodeLineBreaks && isUnicodeLineBreak(char)) {
+		return '\n'
+	}
+	return char
+}
+
+// lineBreakLength
+// Returns the length (in bytes) of the line break the text begins with, or 0 if it does not begin with one.
+// A line break is "\n", "\r\n" or "\r", as well as the line separator (U+2028) and paragraph separator (U+2029)
+// if unicodeLineBreaks is true.
+func lineBreakLength(text string, unicodeLineBreaks bool) int {
+	if text == "" {
+		return 0
+	}
+	switch text[0] {
+	case '\n':
+		return 1
+	case '\r':
+		if len(text) > 1 && text[1] == '\n' {
+			return 2
+		}
+		return 1
+	}
+	if unicodeLineBreaks && text[0] >= utf8.RuneSelf {
+		if char, width := utf8.DecodeRuneInString(text); isUnicodeLineBreak(char) {
+			return width
+		}
+	}
+	return 0
+}
+
+// indexLineBreak
+// Returns the index (byte offset) of the first line break within the text, or the length of the text if it has none.
+// Only the text before the first newline is searched for the other line breaks, and characters are only decoded
+// when Unicode line breaks are recognized.
+func indexLineBreak(text string, unicodeLineBreaks bool) int {
+	end := strings.IndexByte(text, '\n')
+	if end < 0 {
+		end = len(text)
+	}
+	if index := strings.IndexByte(text[:end], '\r'); index >= 0 {
+		end = index
+	}
+	if unicodeLineBreaks {
+		if index := strings.IndexAny(text[:end], "\u2028\u2029"); index >= 0 {
+			end = index
+		}
+	}
+	return end
+}
+
+// isUnicodeLineBreak
+// Returns true if the character is the line separator (U+2028) or the paragraph separator (U+2029)
+func isUnicodeLineBreak(char rune) bool {
+	return char == '\u2028' || char == '\u2029'
+}
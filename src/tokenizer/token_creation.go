@@ -1,136 +1,392 @@
 package tokenizer
 
 import (
+	"fmt"
 	"strings"
 	tk "tp/src/tokenizer/tokens"
+	"tp/src/util"
+	"unicode/utf8"
 )
 
 // addPotentialKeyword
 // This will add the potential keyword to the current scope as a keyword token.
-// If potentialKeyword is an empty string, this method does nothing
+// If there is no potential keyword, this method does nothing
 func (tkzr *Tokenizer) addPotentialKeyword() {
-	if tkzr.potentialKeyword != "" {
-		tkzr.currentScope.Push(tkzr.createKeywordToken(tkzr.potentialKeyword))
-		tkzr.potentialKeyword = ""
+	if tkzr.hasPotentialKeyword() {
+		keyword := tkzr.textSlice(tkzr.potentialKeywordStart, tkzr.potentialKeywordEnd)
+		tkzr.emitToken(tkzr.createKeywordToken(keyword, tkzr.potentialKeywordStart))
+		tkzr.potentialKeywordEnd = tkzr.potentialKeywordStart
 	}
 }
 
+// hasPotentialKeyword
+// Returns true if keyword characters have been found which are not yet part of a token
+func (tkzr *Tokenizer) hasPotentialKeyword() bool {
+	return tkzr.potentialKeywordEnd > tkzr.potentialKeywordStart
+}
+
+// extendPotentialKeyword
+// Adds the character at the current index to the potential keyword.
+// The keyword's text is only taken from the text once the keyword is over.
+func (tkzr *Tokenizer) extendPotentialKeyword() {
+	if !tkzr.hasPotentialKeyword() {
+		tkzr.potentialKeywordStart = tkzr.currentIndex
+	}
+	tkzr.potentialKeywordEnd = tkzr.NextIndex(tkzr.currentIndex)
+}
+
 // addSymbol
 // This method accepts a single character rune as a parameter
 // and will identify and add the char to the current scope as
 // a symbol token
 func (tkzr *Tokenizer) addSymbol(char rune) {
-	newSymbolToken := tkzr.createSymbolToken(string(char))
-
-	if newSymbolToken.SymbolicName == SYMBOLIC_NAME_WHITESPACE {
-		if !tkzr.IgnoreWhitespace {
-			tkzr.currentScope.Push(newSymbolToken)
-		}
-	} else if newSymbolToken.SymbolicName == SYMBOLIC_NAME_NEWLINE {
+	if char == '\n' {
 		tkzr.dealWithNewline()
-	} else {
-		tkzr.currentScope.Push(newSymbolToken)
+	} else if !util.IsWhitespaceCharacter(char) || !tkzr.IgnoreWhitespace {
+		symbol := tkzr.textSlice(tkzr.currentIndex, tkzr.NextIndex(tkzr.currentIndex))
+		tkzr.emitToken(tkzr.createSymbolToken(symbol, tkzr.currentIndex))
 	}
 }
 
+// setTokenSpan
+// Sets the start and end positions of a token given
+// the indices (byte offsets) it begins and ends at.
+// The end index is exclusive.
+func (tkzr *Tokenizer) setTokenSpan(token *tk.Token, startIndex int, endIndex int) {
+	token.SetSpan(tkzr.PositionOf(startIndex), tkzr.PositionOf(endIndex))
+}
+
+// addMultiCharacterSymbol
+// This method accepts a symbol made up of multiple characters which begins at the current index
+// (such as "==" or ">>>="). It will add the symbol to the current scope as a symbol token and move the
+// index to the last character of the symbol, so the next increment moves past the symbol entirely.
+func (tkzr *Tokenizer) addMultiCharacterSymbol(symbol string) {
+	tkzr.emitToken(tkzr.createSymbolToken(symbol, tkzr.currentIndex))
+	_, lastCharWidth := utf8.DecodeLastRuneInString(symbol)
+	tkzr.currentIndex += len(symbol) - lastCharWidth
+}
+
 // createKeywordToken
-// This will take a keyword string, identify its type,
+// This will take a keyword string and the index it begins at, identify its type,
 // create a keyword token, and return a pointer to the newly created token
-func (tkzr *Tokenizer) createKeywordToken(keywordString string) *tk.Token {
+func (tkzr *Tokenizer) createKeywordToken(keywordString string, startIndex int) *tk.Token {
 	newToken := tk.CreateUnidentifiedToken(keywordString, tkzr.currentLineNumber, tkzr.currentTabLevel)
-	newToken.SetValues(RULENAME_KEYWORD, tkzr.identifyKeyword(keywordString))
+	tkzr.setTokenKind(&newToken, tkzr.identifyKeyword(keywordString))
+	tkzr.setTokenSpan(&newToken, startIndex, startIndex+len(keywordString))
 	return &newToken
 }
 
 // createSymbolToken
-// This will take a symbol string, identify its type,
+// This will take a symbol string and the index it begins at, identify its type,
 // create a symbol token, and return a pointer to the newly created token
-func (tkzr *Tokenizer) createSymbolToken(symbolString string) *tk.Token {
+func (tkzr *Tokenizer) createSymbolToken(symbolString string, startIndex int) *tk.Token {
 	newToken := tk.CreateUnidentifiedToken(symbolString, tkzr.currentLineNumber, tkzr.currentTabLevel)
-	newToken.SetValues(RULENAME_SYMBOL, tkzr.identifySymbol(symbolString))
-	if newToken.SymbolicName == SYMBOLIC_NAME_WHITESPACE || newToken.SymbolicName == SYMBOLIC_NAME_NEWLINE {
-		newToken.RuleName = RULENAME_OTHER
-	}
+	tkzr.setTokenKind(&newToken, tkzr.identifySymbol(symbolString))
+	tkzr.setTokenSpan(&newToken, startIndex, startIndex+len(symbolString))
 	return &newToken
 }
 
 // identifyKeyword
-// This takes a keyword as a string, and it will go through the keywords array and
-// see if this is an identifiable keyword. If it is not an identifiable keyword,
-// it will be labeled as SYMBOLIC_NAME_NON_KEYWORD. The label is returned as a string.
-func (tkzr *Tokenizer) identifyKeyword(keywordString string) string {
-	symbolicName := ""
-	for i := 0; i < len(tkzr.Keywords); i++ {
-		if keywordString == tkzr.Keywords[i] {
-			symbolicName = strings.ToUpper(tkzr.Keywords[i])
-		}
+// This takes a keyword as a string, and it will look it up in the keywords
+// to see if this is an identifiable keyword. If it is, the kind registered for the keyword
+// is returned. If it is not an identifiable keyword, tk.KIND_IDENTIFIER is returned.
+func (tkzr *Tokenizer) identifyKeyword(keywordString string) tk.Kind {
+	if kind, found := tkzr.kinds.keywordLookup[keywordString]; found {
+		return kind
 	}
-	if symbolicName == "" {
-		symbolicName = SYMBOLIC_NAME_NON_KEYWORD
-	}
-	return symbolicName
+	return tk.KIND_IDENTIFIER
 }
 
 // identifySymbol
-// This takes a symbol as a parameter and goes through the Symbols array
-// to see if this symbol is present. If the symbol is found, its identified name
-// if returned. If the symbol cannot be identified, it is returned as
-// SYMBOLIC_NAME_UNKNOWN_SYMBOL.
-func (tkzr *Tokenizer) identifySymbol(symbol string) string {
-	symbolicName := ""
-	for i := 0; i < len(tkzr.Symbols); i++ {
-		if symbol == tkzr.Symbols[i][0] {
-			symbolicName = strings.ToUpper(tkzr.Symbols[i][1])
-		}
-	}
+// This takes a symbol as a parameter and looks it up in the symbols
+// to see if this symbol is present. If the symbol is found, the kind registered
+// for it is returned. If the symbol cannot be identified, tk.KIND_UNKNOWN is returned.
+func (tkzr *Tokenizer) identifySymbol(symbol string) tk.Kind {
 	if symbol == " " {
-		symbolicName = SYMBOLIC_NAME_WHITESPACE
+		return tk.KIND_WHITESPACE
 	} else if symbol == "\n" {
-		symbolicName = SYMBOLIC_NAME_NEWLINE
-	} else if symbolicName == "" {
-		symbolicName = SYMBOLIC_NAME_UNKNOWN_SYMBOL
+		return tk.KIND_NEWLINE
+	}
+	if kind, found := tkzr.kinds.symbolLookup[symbol]; found {
+		return kind
 	}
-	return symbolicName
+	return tk.KIND_UNKNOWN
 }
 
 // createTokenType
-// This method takes a token string, and will find out whether it is a keyword,
+// This method takes a token string and the index it begins at, and will find out whether it is a keyword,
 // or symbol, and fully identify it. This will then create a token using the identified string and return it.
-func (tkzr *Tokenizer) createTokenType(tokenString string) *tk.Token {
+func (tkzr *Tokenizer) createTokenType(tokenString string, startIndex int) *tk.Token {
 	possibleKeyword := tkzr.identifyKeyword(tokenString)
-	if possibleKeyword == SYMBOLIC_NAME_NON_KEYWORD {
-		return tkzr.createSymbolToken(tokenString)
+	if possibleKeyword == tk.KIND_IDENTIFIER {
+		return tkzr.createSymbolToken(tokenString, startIndex)
 	}
-	return tkzr.createKeywordToken(tokenString)
+	return tkzr.createKeywordToken(tokenString, startIndex)
 }
 
 // applyFunctionUntilFailureTokenCreation
 // This method takes in a method to be checked every index until it fails. Until it fails,
 // it will be accumulating the characters and create a token which it will return.
-// The symbolic name will be used to set the values for the tokens.
-// The rule nam for the returning token will be set to RULENAME_OTHER.
-func (tkzr *Tokenizer) applyFunctionUntilFailureTokenCreation(BooleanEndFunction func(tkzr *Tokenizer) bool, symbolicName string) *tk.Token {
+// The kind will be used to identify the returned token.
+//
+// If the end function started an interpolated expression (see Interpolate), the token ends right before the expression
+// and the index is left at the start of the expression's delimiter.
+//
+// If the token is a comment which may be nested (see Language.NestedComments), the end function is not checked
+// within the comments nested in it, so the token only ends once all of them have ended.
+//
+// If the run is stopped while the token is being created (see Limits), the token is returned as it is at that point.
+//
+// In recovery mode, a token which is never ended is repaired: a single line string ends with its line,
+// and anything else is replaced by an error token of just its StartInfo, with tokenizing continuing after it.
+func (tkzr *Tokenizer) applyFunctionUntilFailureTokenCreation(BooleanEndFunction func(tkzr *Tokenizer) bool, kind tk.Kind) *tk.Token {
 	lineNumber := tkzr.currentLineNumber
 	tempLineNumber := lineNumber
 	tabLevel := tkzr.currentTabLevel
-	tokenText := ""
-	tkzr.IncrementIndex() // TODO: This should skip the char which initialed this function to be applied
-	for !BooleanEndFunction(tkzr) && tkzr.IndexInBound() {
+	var tokenText strings.Builder
+	singleLine := tkzr.RecoveryMode && kind == tk.KIND_STRING && tkzr.isSingleLineString(tkzr.StartInfo)
+	var nesting *commentNesting
+	if kind == tk.KIND_COMMENT && tkzr.isNestedComment(tkzr.StartInfo, tkzr.EndInfo) {
+		nesting = &commentNesting{skipUntil: tkzr.functionStartIndex + len(tkzr.StartInfo)}
+	}
+	contentEnd := tkzr.NextIndex(tkzr.currentIndex)
+	ended := false
+	truncated := singleLine && tkzr.nextCharIsNewline()
+	if !truncated {
+		tkzr.IncrementIndex() // TODO: This should skip the char which initialed this function to be applied
+	}
+	for !truncated && tkzr.IndexInBound() {
+		if tkzr.interrupted() || tkzr.tokenTooLong(tkzr.functionStartIndex, tkzr.currentIndex) {
+			break
+		}
+		if (nesting == nil || !tkzr.continuesNestedComment(nesting)) && BooleanEndFunction(tkzr) {
+			ended = true
+			break
+		}
 		if tkzr.currentLineNumber != tempLineNumber {
-			tokenText += "\n"
+			tokenText.WriteByte('\n')
 			tempLineNumber = tkzr.currentLineNumber
 		}
-		tokenText += string(tkzr.CurrentChar())
+		tokenText.WriteRune(tkzr.CurrentChar())
+		contentEnd = tkzr.NextIndex(tkzr.currentIndex)
+		// A single line string which is never ended stops before the newline, so the newline is dealt with as usual
+		if singleLine && tkzr.nextCharIsNewline() {
+			truncated = true
+			break
+		}
 		tkzr.IncrementIndex()
 	}
-	tokenText = tkzr.StartInfo + tokenText + tkzr.EndInfo
 
-	if tkzr.IndexInBound() && len(tkzr.EndInfo) > 0 && tkzr.CurrentChar() != rune(tkzr.EndInfo[0]) {
-		tkzr.SkipIncrement()
+	if tkzr.stopError != nil {
+		// Nothing more is added once the run has stopped, so the token is left as it was when it stopped
+		tkzr.pendingInterpolation = nil
+		tkzr.EndInfo = ""
+		stoppedToken := tk.CreateUnidentifiedToken(tkzr.StartInfo+tokenText.String(), lineNumber, tabLevel)
+		tkzr.setTokenKind(&stoppedToken, kind)
+		tkzr.setTokenSpan(&stoppedToken, tkzr.functionStartIndex, contentEnd)
+		return &stoppedToken
+	}
+
+	if !ended && !truncated && tkzr.RecoveryMode && !strings.HasSuffix(tkzr.EndInfo, "\n") {
+		if singleLine { // Ends with the text instead of a newline
+			truncated = true
+		} else {
+			return tkzr.recoverUnterminatedToken(kind, lineNumber, tabLevel)
+		}
+	}
+
+	interpolating := ended && tkzr.pendingInterpolation != nil && kind == tk.KIND_STRING
+	if !interpolating {
+		tkzr.pendingInterpolation = nil
+	} else if tkzr.currentLineNumber != tempLineNumber {
+		// The newline right before the expression still belongs to the string
+		tokenText.WriteByte('\n')
+		contentEnd = tkzr.currentIndex
 	}
 
-	finalToken := tk.CreateUnidentifiedToken(tokenText, lineNumber, tabLevel)
-	finalToken.SetValues(RULENAME_OTHER, symbolicName)
+	endIndex := contentEnd
+	finalText := tkzr.StartInfo + tokenText.String()
+	if !truncated && !interpolating {
+		finalText += tkzr.EndInfo
+		endIndex = tkzr.findEndInfoIndex(contentEnd)
+
+		if tkzr.IndexInBound() && len(tkzr.EndInfo) > 0 && tkzr.CurrentChar() != tkzr.EndInfoFirstChar() {
+			tkzr.SkipIncrement()
+		}
+	}
+
+	finalToken := tk.CreateUnidentifiedToken(finalText, lineNumber, tabLevel)
+	tkzr.setTokenKind(&finalToken, kind)
+	tkzr.setTokenSpan(&finalToken, tkzr.functionStartIndex, endIndex)
+
+	// Tokens ending at the end of a line may also end with the text
+	if !ended && !strings.HasSuffix(tkzr.EndInfo, "\n") {
+		kindName := strings.ToLower(tkzr.kinds.registry.Name(kind))
+		// Only a single line string which is truncated is repaired here, either at the end of its line or of the text
+		repair := fmt.Sprintf("ended the %s at the end of its line", kindName)
+		if !tkzr.IndexInBound() {
+			repair = fmt.Sprintf("ended the %s at the end of the text", kindName)
+		}
+		tkzr.addRepairedDiagnostic(unterminatedDiagnosticCode(kind),
+			fmt.Sprintf("%s started here is never ended (expected %q)", kindName, tkzr.EndInfo),
+			finalToken.Start, finalToken.End, repair)
+	}
+	if truncated {
+		// Nothing more should be skipped, since the EndInfo was never found
+		tkzr.EndInfo = ""
+	}
 
 	return &finalToken
 }
+
+// recoverUnterminatedToken
+// Deals with a string or comment which is never ended while in recovery mode.
+// Rather than the token taking up the rest of the text, its StartInfo becomes an error token
+// and tokenizing continues right after it, as if the token never started.
+func (tkzr *Tokenizer) recoverUnterminatedToken(kind tk.Kind, lineNumber int, tabLevel int) *tk.Token {
+	resumeIndex := tkzr.NextIndex(tkzr.functionStartIndex)
+	if tkzr.StartInfo != "" && tkzr.hasPrefixAt(tkzr.functionStartIndex, tkzr.StartInfo) {
+		resumeIndex = tkzr.functionStartIndex + len(tkzr.StartInfo)
+	}
+
+	errorToken := tk.CreateUnidentifiedToken(tkzr.textSlice(tkzr.functionStartIndex, resumeIndex), lineNumber, tabLevel)
+	tkzr.setTokenKind(&errorToken, tk.KIND_ERROR)
+	tkzr.setTokenSpan(&errorToken, tkzr.functionStartIndex, resumeIndex)
+
+	endOfText := tkzr.PositionOf(tkzr.source.end())
+	tkzr.addRepairedDiagnostic(unterminatedDiagnosticCode(kind),
+		fmt.Sprintf("%s started here is never ended (expected %q)", strings.ToLower(tkzr.kinds.registry.Name(kind)), tkzr.EndInfo),
+		errorToken.Start, endOfText, "marked the start as an error token and continued after it")
+
+	// Goes back to right after the StartInfo, which the main loop will deal with next
+	tkzr.currentIndex = resumeIndex
+	tkzr.currentLineNumber = lineNumber
+	tkzr.currentTabLevel = tabLevel
+	tkzr.EndInfo = ""
+	tkzr.SkipIncrement()
+
+	return &errorToken
+}
+
+// nextCharIsNewline
+// Returns true if the character after the current one is a newline
+func (tkzr *Tokenizer) nextCharIsNewline() bool {
+	nextIndex := tkzr.NextIndex(tkzr.currentIndex)
+	return tkzr.DetermineIfIndexInBound(nextIndex) && tkzr.GetChar(nextIndex) == '\n'
+}
+
+// isSingleLineString
+// Returns true if strings beginning with the StartInfo cannot span multiple lines.
+// Letters are compared case-insensitively, as they are the prefixes of strings (e.g. the "f" of f"...").
+func (tkzr *Tokenizer) isSingleLineString(startInfo string) bool {
+	for _, singleLineStart := range tkzr.SingleLineStrings {
+		if strings.EqualFold(singleLineStart, startInfo) {
+			return true
+		}
+	}
+	return false
+}
+
+// commentNesting
+// Defines how deeply nested the current index is within a comment which may be nested
+//
+// depth: the number of comments which have started within the comment but not yet ended
+//
+// skipUntil: the index the last delimiter found ends at, so its characters are not mistaken for another delimiter (e.g. the "*/" in "/*/")
+type commentNesting struct {
+	depth     int
+	skipUntil int
+}
+
+// isNestedComment
+// Returns true if comments with the StartInfo may be nested. Comments ending with their line never are.
+func (tkzr *Tokenizer) isNestedComment(startInfo string, endInfo string) bool {
+	if startInfo == "" || endInfo == "" || strings.HasSuffix(endInfo, "\n") {
+		return false
+	}
+	for _, nestedStart := range tkzr.NestedComments {
+		if nestedStart == startInfo {
+			return true
+		}
+	}
+	return false
+}
+
+// continuesNestedComment
+// Deals with the delimiters of the comments nested within a comment, which use the same StartInfo and EndInfo as it does.
+// Returns true if the current index is within one of these comments (or their delimiters), in which case the comment cannot end here.
+func (tkzr *Tokenizer) continuesNestedComment(nesting *commentNesting) bool {
+	index := tkzr.currentIndex
+	if index < nesting.skipUntil {
+		return true
+	}
+	if nesting.depth > 0 && tkzr.hasPrefixAt(index, tkzr.EndInfo) {
+		nesting.depth--
+		nesting.skipUntil = index + len(tkzr.EndInfo)
+		return true
+	}
+	if tkzr.hasPrefixAt(index, tkzr.StartInfo) {
+		nesting.depth++
+		nesting.skipUntil = index + len(tkzr.StartInfo)
+		return true
+	}
+	return nesting.depth > 0
+}
+
+// findEndInfoIndex
+// After an end function has succeeded, this finds the index (exclusive) where
+// the EndInfo of the token actually ends in the text. The end function may have
+// stopped on the EndInfo itself (e.g. a closing quote) or already moved past it
+// (e.g. the newline ending a line comment), so both the current index and the
+// index right after the token's contents are checked.
+func (tkzr *Tokenizer) findEndInfoIndex(contentEnd int) int {
+	if !tkzr.IndexInBound() {
+		return tkzr.source.end()
+	}
+	if tkzr.EndInfo == "" {
+		return contentEnd
+	}
+	if length := tkzr.endInfoLengthAt(tkzr.currentIndex); length > 0 {
+		return tkzr.currentIndex + length
+	}
+	if length := tkzr.endInfoLengthAt(contentEnd); length > 0 {
+		return contentEnd + length
+	}
+	return contentEnd
+}
+
+// endInfoLengthAt
+// Returns the length (in bytes) of the EndInfo if the text starting at the provided index begins with it, or 0 if it does not.
+// A newline within the EndInfo matches any line break (see lineBreakLength), so the length may differ from the EndInfo's.
+func (tkzr *Tokenizer) endInfoLengthAt(index int) int {
+	if !strings.Contains(tkzr.EndInfo, "\n") {
+		if tkzr.hasPrefixAt(index, tkzr.EndInfo) {
+			return len(tkzr.EndInfo)
+		}
+		return 0
+	}
+	length := 0
+	for endInfo := tkzr.EndInfo; endInfo != ""; {
+		text := endInfo
+		newline := strings.IndexByte(endInfo, '\n')
+		if newline != -1 {
+			text = endInfo[:newline]
+		}
+		if !tkzr.hasPrefixAt(index+length, text) {
+			return 0
+		}
+		length += len(text)
+		if newline == -1 {
+			break
+		}
+		endInfo = endInfo[newline+1:]
+		// Enough is read for a line break of any length to be held in memory
+		tkzr.DetermineIfIndexInBound(index + length + utf8.UTFMax - 1)
+		lineBreak := lineBreakLength(tkzr.textSlice(index+length, index+length+utf8.UTFMax), tkzr.UnicodeLineBreaks)
+		if lineBreak == 0 {
+			return 0
+		}
+		length += lineBreak
+	}
+	return length
+}
@@ -0,0 +1,185 @@
+package tokenizer
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	tk "tp/src/tokenizer/tokens"
+)
+
+// TokenRule
+// Defines a token which is found by a regular expression rather than by callbacks,
+// for tokens such as annotations, decorators or character literals.
+// Wherever a token could begin, the rules are tried before keywords, numbers and symbols,
+// and the token is made of the text the first matching rule matched.
+//
+// Name: identifies the rule, and must be unique within a language
+//
+// Pattern: the regular expression (RE2 syntax) which matches the token. It is matched against the text
+// starting at the current index, so it is always anchored there. A match cannot span multiple lines
+// and must not be empty
+//
+// Priority: rules with a higher priority are tried first. Rules with the same priority are tried in the order they were added
+//
+// RuleName: the rule name of the tokens the rule creates. RULENAME_OTHER if empty
+//
+// SymbolicName: the symbolic name of the tokens the rule creates. The upper case Name if empty
+//
+// Category: the category of the kind registered for the rule. Rules with the same names and category
+// as a built-in kind (e.g. RULENAME_OTHER, SYMBOLIC_NAME_NUMBER and tk.CATEGORY_LITERAL) create tokens of that kind
+type TokenRule struct {
+	Name         string          `json:"name" yaml:"name"`
+	Pattern      string          `json:"pattern" yaml:"pattern"`
+	Priority     int             `json:"priority,omitempty" yaml:"priority,omitempty"`
+	RuleName     string          `json:"ruleName,omitempty" yaml:"ruleName,omitempty"`
+	SymbolicName string          `json:"symbolicName,omitempty" yaml:"symbolicName,omitempty"`
+	Category     tk.KindCategory `json:"category,omitempty" yaml:"category,omitempty"`
+}
+
+// tokenRuleMatcher
+// Defines a compiled token rule
+//
+// pattern: the rule's pattern, anchored to the start of the text it is matched against
+//
+// prefix: the text every match begins with, so most indices can be ruled out without running the pattern
+type tokenRuleMatcher struct {
+	pattern *regexp.Regexp
+	prefix  string
+}
+
+// compileTokenRule
+// Checks the rule and compiles its pattern
+func compileTokenRule(rule TokenRule) (*tokenRuleMatcher, error) {
+	if rule.Name == "" {
+		return nil, fmt.Errorf("token rule has no name")
+	}
+	if rule.Pattern == "" {
+		return nil, fmt.Errorf("token rule %q has no pattern", rule.Name)
+	}
+	pattern, err := regexp.Compile(`\A(?:` + rule.Pattern + `)`)
+	if err != nil {
+		return nil, fmt.Errorf("token rule %q has an invalid pattern: %w", rule.Name, err)
+	}
+	if pattern.MatchString("") {
+		return nil, fmt.Errorf("token rule %q has a pattern which matches empty text", rule.Name)
+	}
+	prefix, _ := pattern.LiteralPrefix()
+	return &tokenRuleMatcher{pattern: pattern, prefix: prefix}, nil
+}
+
+// ConfigureTokenRule
+// Adds a rule which creates a token wherever its regular expression matches (see TokenRule).
+// This method is not necessary to be run; without any rules, only the callbacks, keywords, numbers and symbols create tokens.
+//
+// rule: the rule to add. An error is returned if the rule's name is already used or its pattern is invalid
+func (lang *Language) ConfigureTokenRule(rule TokenRule) error {
+	matcher, err := compileTokenRule(rule)
+	if err != nil {
+		return err
+	}
+	for _, existingRule := range lang.TokenRules {
+		if existingRule.Name == rule.Name {
+			return fmt.Errorf("token rule %q already exists", rule.Name)
+		}
+	}
+	if rule.RuleName == "" {
+		rule.RuleName = RULENAME_OTHER
+	}
+	if rule.SymbolicName == "" {
+		rule.SymbolicName = strings.ToUpper(rule.Name)
+	}
+
+	// New slices are made, so copies of the language are not affected
+	rules := append(append([]TokenRule(nil), lang.TokenRules...), rule)
+	matchers := append(append([]*tokenRuleMatcher(nil), lang.tokenRuleMatchers...), matcher)
+	order := make([]int, len(rules))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return rules[order[i]].Priority > rules[order[j]].Priority
+	})
+
+	lang.TokenRules = make([]TokenRule, len(rules))
+	lang.tokenRuleMatchers = make([]*tokenRuleMatcher, len(rules))
+	for i, index := range order {
+		lang.TokenRules[i] = rules[index]
+		lang.tokenRuleMatchers[i] = matchers[index]
+	}
+	lang.kinds = buildLanguageKinds(lang.Symbols, lang.Keywords, lang.TokenRules)
+	return nil
+}
+
+// tokenRuleTable
+// Returns the compiled token rules of the language, in the same order as its TokenRules. If the language's TokenRules
+// were changed without calling ConfigureTokenRule, they are compiled again without modifying the language,
+// in which case any rule which is invalid is left as nil and never matches.
+func (lang *Language) tokenRuleTable() []*tokenRuleMatcher {
+	if len(lang.tokenRuleMatchers) == len(lang.TokenRules) {
+		return lang.tokenRuleMatchers
+	}
+	matchers := make([]*tokenRuleMatcher, len(lang.TokenRules))
+	for i, rule := range lang.TokenRules {
+		matchers[i], _ = compileTokenRule(rule)
+	}
+	return matchers
+}
+
+// matchTokenRule
+// Determines whether one of the language's token rules matches at the provided index, trying the rules in order of priority.
+// If one does, the index right after the match (exclusive) and the kind of the rule are returned.
+// If none do, -1 is returned.
+func (tkzr *Tokenizer) matchTokenRule(index int) (int, tk.Kind) {
+	for i, matcher := range tkzr.ruleMatchers {
+		if matcher == nil || (matcher.prefix != "" && !tkzr.hasPrefixAt(index, matcher.prefix)) {
+			continue
+		}
+
+		var match []int
+		if tkzr.source.streaming() {
+			match = matcher.pattern.FindReaderIndex(&lineReader{tkzr: tkzr, index: index})
+		} else {
+			match = matcher.pattern.FindStringIndex(tkzr.source.slice(index, tkzr.ruleLineEndAt(index)))
+		}
+		if match != nil && match[1] > 0 {
+			return index + match[1], tkzr.kinds.rules[i]
+		}
+	}
+	return -1, tk.KIND_UNIDENTIFIED
+}
+
+// ruleLineEndAt
+// Returns the index of the line break which ends the line the index is on (or the end of the text), which token rules are
+// matched up to. The end is kept until the index moves past it, so a long line is only searched for its end once.
+func (tkzr *Tokenizer) ruleLineEndAt(index int) int {
+	if index < tkzr.ruleLineStart || index > tkzr.ruleLineEnd {
+		tkzr.ruleLineStart = index
+		tkzr.ruleLineEnd = index + indexLineBreak(tkzr.source.slice(index, tkzr.source.end()), tkzr.UnicodeLineBreaks)
+	}
+	return tkzr.ruleLineEnd
+}
+
+// lineReader
+// Reads the characters of the text from an index until the end of the line,
+// so token rules can be matched against text which is being read from a reader
+type lineReader struct {
+	tkzr  *Tokenizer
+	index int
+}
+
+// ReadRune
+// Returns the next character of the line, or io.EOF at the end of the line
+func (lr *lineReader) ReadRune() (rune, int, error) {
+	if !lr.tkzr.DetermineIfIndexInBound(lr.index) {
+		return 0, 0, io.EOF
+	}
+	char := lr.tkzr.GetChar(lr.index)
+	if char == '\n' {
+		return 0, 0, io.EOF
+	}
+	width := lr.tkzr.GetCharWidth(lr.index)
+	lr.index += width
+	return char, width, nil
+}
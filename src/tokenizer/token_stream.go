@@ -0,0 +1,248 @@
+package tokenizer
+
+import (
+	"strings"
+	tk "tp/src/tokenizer/tokens"
+)
+
+// TokenEventType
+// Defines the kinds of events produced while tokenizing
+type TokenEventType int
+
+const (
+	EVENT_TOKEN       TokenEventType = iota // A token was found
+	EVENT_SCOPE_OPEN                        // A new scope was opened
+	EVENT_SCOPE_CLOSE                       // The innermost open scope was closed
+)
+
+// TokenEvent
+// Defines a single event produced while tokenizing.
+//
+// Type: what kind of event this is
+//
+// Token: the token which was found. For EVENT_SCOPE_OPEN events, this is the token which opened the scope, and for
+// EVENT_SCOPE_CLOSE events, the token which closed it, or nil if the scope was closed without one (e.g. by a dedent or
+// the end of the text). Either way, the same token is also found by an EVENT_TOKEN event right before or after
+//
+// Position: where a scope opened or closed. This is only set for EVENT_SCOPE_OPEN and EVENT_SCOPE_CLOSE events
+type TokenEvent struct {
+	Type     TokenEventType
+	Token    *tk.Token
+	Position tk.Position
+}
+
+// TokenStream
+// Defines an iterator over the events produced by a tokenizer.
+// Text is only tokenized as the stream is advanced.
+//
+// Usage:
+//
+//	for stream.Next() {
+//		event := stream.Event()
+//		...
+//	}
+//	if stream.Err() != nil {
+//		...
+//	}
+type TokenStream struct {
+	tkzr    *Tokenizer
+	event   TokenEvent
+	decoder *decodingReader // Decodes the text read from a reader, which is nil when the whole text is tokenized at once
+}
+
+// Next
+// Advances the stream to the next event, tokenizing more of the text as needed.
+// Returns false once there are no more events.
+func (ts *TokenStream) Next() bool {
+	tkzr := ts.tkzr
+	for tkzr.nextEvent >= len(tkzr.events) {
+		// All events have been handed out, so they no longer need to be held
+		tkzr.events = tkzr.events[:0]
+		tkzr.nextEvent = 0
+		if !tkzr.step() && len(tkzr.events) == 0 {
+			ts.event = TokenEvent{}
+			return false
+		}
+	}
+
+	ts.event = tkzr.events[tkzr.nextEvent]
+	tkzr.events[tkzr.nextEvent] = TokenEvent{}
+	tkzr.nextEvent++
+	return true
+}
+
+// Event
+// Returns the event the stream is currently at
+func (ts *TokenStream) Event() TokenEvent {
+	return ts.event
+}
+
+// Err
+// Returns the error which stopped the run early (a *LimitError or the error of a cancelled context),
+// or otherwise the error which occurred when reading the text, if any
+func (ts *TokenStream) Err() error {
+	if ts.tkzr.stopError != nil {
+		return ts.tkzr.stopError
+	}
+	if ts.tkzr.source == nil {
+		return nil
+	}
+	return ts.tkzr.source.readError
+}
+
+// Encoding
+// Returns the encoding the text read from the reader was found in (see DecodeText), which is known once
+// the stream has begun reading the text. Until then, the zero value is returned.
+func (ts *TokenStream) Encoding() tk.Encoding {
+	if ts.decoder == nil || !ts.decoder.detected {
+		return tk.Encoding{}
+	}
+	return ts.decoder.encoding
+}
+
+// Diagnostics
+// Returns the diagnostics found so far. Diagnostics about the end of the text
+// (e.g. scopes which are never closed) are only found once Next has returned false.
+func (ts *TokenStream) Diagnostics() []Diagnostic {
+	return ts.tkzr.diagnostics
+}
+
+// BufferedBytes
+// Returns the number of bytes of text currently held in memory by the stream
+func (ts *TokenStream) BufferedBytes() int {
+	if ts.tkzr.source == nil {
+		return 0
+	}
+	return len(ts.tkzr.source.window)
+}
+
+// emitToken
+// Queues a token event
+func (tkzr *Tokenizer) emitToken(token *tk.Token) {
+	if tkzr.indentation != nil {
+		tkzr.trackIndentation(token)
+	}
+	if tkzr.LosslessMode {
+		tkzr.emitLosslessToken(token)
+		return
+	}
+	tkzr.queueToken(token)
+}
+
+// queueToken
+// Adds a token event to the queue. The text of a token is usually a part of the text being tokenized,
+// so when streaming, it is copied to keep tokens from holding on to text the stream has discarded.
+//
+// Once the run has stopped (see Limits), tokens are no longer added.
+func (tkzr *Tokenizer) queueToken(token *tk.Token) {
+	if tkzr.stopError != nil || tkzr.tokenTooLong(token.Start.Offset, token.End.Offset) {
+		return
+	}
+	if maxTokens := tkzr.Limits.MaxTokens; maxTokens > 0 && tkzr.tokenCount >= maxTokens {
+		tkzr.stopAtLimit(LIMIT_TOKENS, maxTokens, token.Start)
+		return
+	}
+	tkzr.tokenCount++
+	if tkzr.source.streaming() {
+		token.Text = copyString(token.Text)
+	}
+	tkzr.queueEvent(TokenEvent{Type: EVENT_TOKEN, Token: token})
+}
+
+// queueEvent
+// Adds an event to the queue, keeping track of how many of the queued scopes are open.
+// Once the run has stopped, events are no longer added.
+func (tkzr *Tokenizer) queueEvent(event TokenEvent) {
+	if tkzr.stopError != nil {
+		return
+	}
+	if event.Type == EVENT_SCOPE_OPEN {
+		tkzr.queuedDepth++
+	} else if event.Type == EVENT_SCOPE_CLOSE {
+		tkzr.queuedDepth--
+	}
+	tkzr.events = append(tkzr.events, event)
+}
+
+// copyString
+// Returns a copy of the string which does not share memory with the original
+func copyString(text string) string {
+	var builder strings.Builder
+	builder.Grow(len(text))
+	builder.WriteString(text)
+	return builder.String()
+}
+
+// emitScopeOpen
+// Queues an event opening a new scope, which begins right after the provided token
+func (tkzr *Tokenizer) emitScopeOpen(opener *tk.Token) {
+	if maxDepth := tkzr.Limits.MaxScopeDepth; maxDepth > 0 && len(tkzr.openScopes) >= maxDepth {
+		tkzr.stopAtLimit(LIMIT_SCOPE_DEPTH, maxDepth, opener.Start)
+	}
+	tkzr.openScopes = append(tkzr.openScopes, opener.Start)
+	tkzr.queueEvent(TokenEvent{Type: EVENT_SCOPE_OPEN, Token: opener, Position: opener.End})
+}
+
+// emitScopeClose
+// Queues an event closing the innermost open scope at the provided position.
+// The closer is the token which closes the scope, which is nil if the scope was closed without one.
+func (tkzr *Tokenizer) emitScopeClose(end tk.Position, closer *tk.Token) {
+	// In lossless mode, the text right before the end of the scope belongs inside of it
+	tkzr.fillGap(end.Offset, end.Line, tkzr.currentTabLevel)
+	tkzr.openScopes = tkzr.openScopes[:len(tkzr.openScopes)-1]
+	tkzr.queueEvent(TokenEvent{Type: EVENT_SCOPE_CLOSE, Token: closer, Position: end})
+}
+
+// buildScopeTree
+// Consumes the entire stream, building the ScopeObj tree described by its events
+func buildScopeTree(stream *TokenStream) tk.ScopeObj {
+	tkzr := stream.tkzr
+
+	finalScope := tk.InitScope()
+	finalScope.SetType(SCOPE_TYPE_FILE)
+	finalScope.SetStart(tkzr.PositionOf(0))
+	currentScope := &finalScope
+	// The scope tokens of the scopes which are open, whose spans end once their scopes are closed
+	scopeTokens := make([]*tk.Token, 0)
+
+	for stream.Next() {
+		event := stream.Event()
+		switch event.Type {
+		case EVENT_TOKEN:
+			currentScope.Push(event.Token)
+		case EVENT_SCOPE_OPEN:
+			newScopeTkn := tk.InitScopeToken()
+			if event.Token != nil {
+				newScopeTkn.LineNumber = event.Token.LineNumber
+				newScopeTkn.TabNumber = event.Token.TabNumber
+			}
+			newScopeTkn.SetSpan(event.Position, event.Position)
+			currentScope.Push(newScopeTkn)
+			depth := currentScope.GetDepth() + 1
+			currentScope = newScopeTkn.GetScopeToken()
+			currentScope.SetStart(event.Position)
+			currentScope.SetDepth(depth)
+			currentScope.SetOpener(event.Token)
+			tkzr.classifyScope(currentScope)
+			scopeTokens = append(scopeTokens, newScopeTkn)
+		case EVENT_SCOPE_CLOSE:
+			currentScope.SetEnd(event.Position)
+			currentScope.SetCloser(event.Token)
+			currentScope.SetImplicitlyClosed(event.Token == nil)
+			if len(scopeTokens) > 0 {
+				scopeTokens[len(scopeTokens)-1].End = event.Position
+				scopeTokens = scopeTokens[:len(scopeTokens)-1]
+			}
+			if parentScope := currentScope.GetScopeParent(); parentScope != nil {
+				currentScope = parentScope
+			}
+		}
+	}
+
+	if tkzr.stopError != nil {
+		finalScope.SetEnd(tkzr.stopPosition)
+	} else {
+		finalScope.SetEnd(tkzr.PositionOf(tkzr.source.end()))
+	}
+	return finalScope
+}
@@ -1,110 +1,313 @@
 package tokenizer
 
 import (
-	"errors"
+	"context"
 	"fmt"
+	"io"
 	tk "tp/src/tokenizer/tokens"
-	"tp/src/util"
+	"unicode/utf8"
 )
 
+// maxStepsWithoutProgress
+// The number of times in a row the tokenizer may deal with the same character before it is skipped
+const maxStepsWithoutProgress = 1024
+
 // Tokenizer
 // Defines Tokenizer object.
+// A Tokenizer holds the state of a single run of tokenizing text with a language. The language itself
+// is never modified while tokenizing, so a single language may be shared by many tokenizers running at
+// the same time. A Tokenizer on the other hand may only be used by one goroutine at a time.
 type Tokenizer struct {
-	LanguageType string
-	Symbols      [][]string
-	Keywords     []string
+	*Language
+
+	// Recovery Info
+	RecoveryMode bool // Whether malformed text is repaired (see Diagnostic.Repair) rather than only reported
+
+	// Lossless Info
+	LosslessMode bool // Whether every byte of the text is kept in exactly one token, so ScopeObj.Source returns the original text once decoded (see TokenizeBytes)
+
+	// Encoding Info
+	FallbackEncoding *SingleByteEncoding // The encoding text is decoded with when it is neither UTF-8 nor UTF-16 (see DecodeText); ISO-8859-1 if nil
+
+	// Limit Info
+	Limits Limits // How much work a single run may do before it is stopped (see Limits)
 
 	// Temp Info
 	tempIgnoreChangesFromIncrement bool
-	Text                           *string
+	Text                           *string // The text being tokenized, which is nil while the text is read from a reader (see TokenizeReader)
+	source                         *textSource
+	symbolMatcher                  *symbolTrie
+	kinds                          *languageKinds
+	ruleMatchers                   []*tokenRuleMatcher
+	ruleLineStart                  int // The index the line end token rules are matched up to was found from
+	ruleLineEnd                    int // The end of the line token rules are matched up to, which is known for indices from ruleLineStart up to it
+	defaultCallbacks               *LexerMode
+	callbacks                      *LexerMode
+	modes                          []*modeFrame
+	pendingInterpolation           *modeFrame
+	indentation                    *indentationTracker
+	spaceSizeString                string
 	currentTabLevel                int
 	currentLineNumber              int
-	potentialKeyword               string
+	potentialKeywordStart          int
+	potentialKeywordEnd            int
+	functionStartIndex             int
+	positionCache                  tk.Position
 	StartInfo                      string
 	EndInfo                        string
 	FunctionSharedInfo             string
+	state                          CallbackState
 	currentIndex                   int
-	currentScope                   *tk.ScopeObj
 	skipIncrement                  bool
+	openScopes                     []tk.Position
+	diagnostics                    []Diagnostic
+	stepsWithoutProgress           int
+	events                         []TokenEvent
+	nextEvent                      int
+	finished                       bool
+	losslessEnd                    tk.Position
+	losslessTabLevel               int
+	ctx                            context.Context
+	stepsUntilContextCheck         int
+	stopError                      error
+	stopPosition                   tk.Position
+	tokenCount                     int
+	queuedDepth                    int
+	delimiterRun                   delimiterRun
+}
 
-	// Scope Info
-	ScopeStartFunction func(tkzr *Tokenizer) bool
-	ScopeEndFunction   func(tkzr *Tokenizer) bool
-	// Note: These scope functions are intended to find MOST scopes... not all scopes
+// Tokenize
+// Takes a string and tokenizes the contents of it into a ScopeObj object.
+// Problems found in the text (or with the language's callbacks) do not stop the text from being
+// tokenized; they are returned as diagnostics instead. In recovery mode, problems in the text are
+// also repaired, so that a half-written file still results in a usable ScopeObj.
+// Returns an error if the tokenizer is not configured correctly or an error results from the final steps.
+// A run which reaches one of the tokenizer's Limits stops early and returns a *LimitError along with what was found before it.
+//
+// The text is decoded like TokenizeBytes does, so text which begins with a byte-order mark or is not UTF-8 (e.g. the contents
+// of a file converted straight into a string) is tokenized like any other. Text which is already UTF-8 is not copied.
+//
+// This builds the ScopeObj by consuming the same stream of events produced by TokenizeReader.
+func (tkzr *Tokenizer) Tokenize(text string) (tk.ScopeObj, []Diagnostic, error) {
+	return tkzr.TokenizeContext(context.Background(), text)
+}
+
+// TokenizeContext
+// Tokenizes the text like Tokenize does, but stops once the context is cancelled. The returned error then wraps
+// the context's error, and the ScopeObj holds the tokens found before it was cancelled.
+// Every scope still open in it is closed where tokenizing stopped, and FinalSteps is not run.
+func (tkzr *Tokenizer) TokenizeContext(ctx context.Context, text string) (tk.ScopeObj, []Diagnostic, error) {
+	text, encoding := decodeString(text, tkzr.FallbackEncoding)
+	return tkzr.tokenizeText(ctx, text, encoding)
+}
+
+// TokenizeBytes
+// Takes the bytes of a file and tokenizes them into a ScopeObj object, like Tokenize does for text.
+// The bytes are first decoded into text (see DecodeText), so files beginning with a byte-order mark
+// or saved in UTF-16 or a single byte encoding (see FallbackEncoding) are tokenized like any other.
+// The encoding which was found is recorded on the ScopeObj (see ScopeObj.GetEncoding).
+//
+// Positions are within the decoded text, which is also what ScopeObj.Source returns in lossless mode,
+// so a byte-order mark is never part of any token.
+func (tkzr *Tokenizer) TokenizeBytes(data []byte) (tk.ScopeObj, []Diagnostic, error) {
+	text, encoding := DecodeText(data, tkzr.FallbackEncoding)
+	return tkzr.tokenizeText(context.Background(), text, encoding)
+}
 
-	// String Info
-	StringStartFunction func(tkzr *Tokenizer) bool
-	StringEndFunction   func(tkzr *Tokenizer) bool
-	IncludeStrings      bool
+// tokenizeText
+// Tokenizes the text, which was decoded from the provided encoding, into a ScopeObj object until the context is cancelled
+func (tkzr *Tokenizer) tokenizeText(ctx context.Context, text string, encoding tk.Encoding) (tk.ScopeObj, []Diagnostic, error) {
+	err := tkzr.IsConfigured()
+	if err != nil {
+		return tk.InitScope(), nil, err
+	}
 
-	// Comment Info
-	CommentStartFunction func(tkzr *Tokenizer) bool
-	CommentEndFunction   func(tkzr *Tokenizer) bool
-	IncludeComments      bool
+	tkzr.initTempVariables(ctx, &text, newStringSource(text))
+	finalScope := buildScopeTree(&TokenStream{tkzr: tkzr})
+	finalScope.SetEncoding(encoding)
+	if tkzr.stopError != nil {
+		return finalScope, tkzr.diagnostics, tkzr.stopError
+	}
 
-	// Keyword Info
-	IsKeywordCharacter func(c rune) bool
+	if tkzr.FinalSteps != nil {
+		err = tkzr.FinalSteps(tkzr, &finalScope)
+		if err != nil {
+			return finalScope, tkzr.diagnostics, err
+		}
+	}
 
-	// Whitespace Info
-	spaceSizeString       string
-	NumOfSpacesEquallyTab int
-	IgnoreWhitespace      bool
-	IgnoreNewLines        bool
+	return finalScope, tkzr.diagnostics, nil
+}
 
-	// Final Steps
-	FinalSteps func(tkzr *Tokenizer, finalScope *tk.ScopeObj) error
+// TokenizeReader
+// Takes a reader and returns a stream which tokenizes the text read from it.
+// The text is read as the stream is iterated, and only the portion of the text which
+// is still needed is kept in memory, allowing very large inputs to be tokenized.
+// Since the whole text is never held at once, the tokenizer's Text is nil while the stream is read,
+// and TextSize returns TEXT_SIZE_UNKNOWN until the end of the text has been read.
+// The bytes read are decoded like TokenizeBytes does, except that the encoding is determined from the start of the text
+// alone (see TokenStream.Encoding).
+// Returns an error if the tokenizer is not configured correctly.
+//
+// Unlike Tokenize, no ScopeObj is built and FinalSteps is not run; the
+// stream's events should be consumed directly. A run which reaches one of the tokenizer's Limits
+// ends the stream early, with the *LimitError returned by the stream's Err.
+func (tkzr *Tokenizer) TokenizeReader(reader io.Reader) (*TokenStream, error) {
+	return tkzr.TokenizeReaderContext(context.Background(), reader)
 }
 
-// Tokenize
-// Takes a string and tokenizes the contents of it into a ScopeObj object.
-// Returns an error if the tokenizer is not configured correctly or an error results from the final steps.
-func (tkzr *Tokenizer) Tokenize(text string) (tk.ScopeObj, error) {
+// TokenizeReaderContext
+// Returns a stream which tokenizes the text read from the reader like TokenizeReader does, but stops once the context
+// is cancelled. The stream's Err then wraps the context's error.
+func (tkzr *Tokenizer) TokenizeReaderContext(ctx context.Context, reader io.Reader) (*TokenStream, error) {
 	err := tkzr.IsConfigured()
 	if err != nil {
-		return tk.InitScope(), err
+		return nil, err
 	}
 
-	tkzr.initTempVariables(&text)
+	decoder := newDecodingReader(reader, tkzr.FallbackEncoding)
+	tkzr.initTempVariables(ctx, nil, newReaderSource(decoder))
+	return &TokenStream{tkzr: tkzr, decoder: decoder}, nil
+}
+
+// step
+// Runs a single iteration of the tokenizer's main loop, dealing with the character at the current index.
+// Once the whole text has been tokenized, this finishes the run and returns false.
+//
+// A callback which panics does not stop the run. The panic is recorded as a diagnostic
+// and tokenizing continues from the next character.
+func (tkzr *Tokenizer) step() (stepped bool) {
+	if tkzr.finished {
+		return false
+	}
+	if tkzr.interrupted() {
+		tkzr.finishStopped()
+		return false
+	}
+	if !tkzr.IndexInBound() {
+		if tkzr.source.truncated {
+			tkzr.finishTruncated()
+		} else {
+			tkzr.finish()
+		}
+		return false
+	}
 
-	finalScope := tk.InitScope()
-	finalScope.SetType("File")
-	tkzr.currentScope = &finalScope
+	startIndex := tkzr.currentIndex
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			tkzr.recoverFromPanic(startIndex, recovered)
+			stepped = true
+		}
+	}()
 
-	for tkzr.IndexInBound() {
-		tkzr.skipIncrement = false
+	tkzr.skipIncrement = false
 
-		if !tkzr.applyFunctions() {
-			// Not a scope identifier, not a comment, not a string
-			char := tkzr.CurrentChar()
-			if tkzr.IsKeywordCharacter(char) {
-				tkzr.potentialKeyword += string(char)
-			} else { // Found a symbol
-				// The previous keyword is over and needs to be added
-				tkzr.addPotentialKeyword()
+	if !tkzr.applyFunctions() {
+		// Not a scope identifier, not a comment, not a string
+		char := tkzr.CurrentChar()
+		ruleEnd, ruleKind := -1, tk.KIND_UNIDENTIFIED
+		if !tkzr.hasPotentialKeyword() || !tkzr.IsKeywordCharacter(char) { // Rules are not matched in the middle of a keyword
+			ruleEnd, ruleKind = tkzr.matchTokenRule(tkzr.currentIndex)
+		}
+		numberEnd := -1
+		if ruleEnd == -1 && !tkzr.hasPotentialKeyword() {
+			numberEnd = tkzr.matchNumber(tkzr.currentIndex)
+		}
+		if ruleEnd != -1 { // Found a token rule
+			tkzr.addPotentialKeyword()
+			tkzr.addMatchedToken(ruleEnd, ruleKind)
+		} else if numberEnd != -1 { // Found a number
+			tkzr.addMatchedToken(numberEnd, tk.KIND_NUMBER)
+		} else if tkzr.IsKeywordCharacter(char) {
+			tkzr.extendPotentialKeyword()
+			tkzr.tokenTooLong(tkzr.potentialKeywordStart, tkzr.potentialKeywordEnd)
+		} else { // Found a symbol
+			// The previous keyword is over and needs to be added
+			tkzr.addPotentialKeyword()
 
-				// Current symbol needs to be added
+			// Current symbol needs to be added, using the longest symbol which can be found here
+			symbol := tkzr.matchSymbol(tkzr.currentIndex)
+			if utf8.RuneCountInString(symbol) > 1 {
+				tkzr.addMultiCharacterSymbol(symbol)
+			} else {
 				tkzr.addSymbol(char)
 			}
 		}
+	}
 
-		if !tkzr.skipIncrement {
-			tkzr.currentIndex++
-		}
+	if !tkzr.skipIncrement {
+		tkzr.currentIndex = tkzr.NextIndex(tkzr.currentIndex)
 	}
+	tkzr.checkProgress(startIndex)
+	return true
+}
 
-	if tkzr.potentialKeyword != "" {
-		tkzr.currentScope.Push(tkzr.createKeywordToken(tkzr.potentialKeyword))
-		tkzr.potentialKeyword = ""
+// checkProgress
+// Makes sure the tokenizer keeps moving forward. Callbacks may skip the increment
+// to deal with the same character more than once (e.g. to close several scopes), but if they
+// keep doing so for too long, the character is skipped so the run always ends.
+func (tkzr *Tokenizer) checkProgress(startIndex int) {
+	if tkzr.currentIndex > startIndex {
+		tkzr.stepsWithoutProgress = 0
+		return
 	}
 
-	if tkzr.FinalSteps != nil {
-		err = tkzr.FinalSteps(tkzr, &finalScope)
-		if err != nil {
-			return finalScope, err
-		}
+	tkzr.stepsWithoutProgress++
+	if tkzr.stepsWithoutProgress >= maxStepsWithoutProgress {
+		nextIndex := tkzr.NextIndex(startIndex)
+		tkzr.addDiagnostic(SEVERITY_ERROR, DIAGNOSTIC_CALLBACK_NO_PROGRESS,
+			fmt.Sprintf("the callbacks did not move forward after %d steps, so this character was skipped", maxStepsWithoutProgress),
+			tkzr.PositionOf(startIndex), tkzr.PositionOf(nextIndex))
+		tkzr.currentIndex = nextIndex
+		tkzr.stepsWithoutProgress = 0
+	}
+}
+
+// recoverFromPanic
+// Records a panic which occurred while dealing with the character at the start index
+// and sets the tokenizer up to continue from the next character.
+func (tkzr *Tokenizer) recoverFromPanic(startIndex int, recovered any) {
+	nextIndex := tkzr.NextIndex(startIndex)
+	tkzr.addDiagnostic(SEVERITY_ERROR, DIAGNOSTIC_CALLBACK_PANIC, fmt.Sprintf("a callback panicked: %v", recovered),
+		tkzr.PositionOf(startIndex), tkzr.PositionOf(nextIndex))
+
+	tkzr.StartInfo = ""
+	tkzr.EndInfo = ""
+	tkzr.tempIgnoreChangesFromIncrement = false
+	tkzr.skipIncrement = false
+	if tkzr.currentIndex < nextIndex {
+		tkzr.currentIndex = nextIndex
 	}
+}
 
-	return finalScope, nil
+// finish
+// Adds any remaining keyword and closes all scopes which are still
+// open, since they end with the text. Unless the language's scopes
+// may end with the text, each of these scopes is reported as a diagnostic.
+// The scopes of interpolated expressions are always reported, as the strings they are in never end.
+// Any indentation blocks still open are ended as well.
+func (tkzr *Tokenizer) finish() {
+	tkzr.addPotentialKeyword()
+
+	endOfText := tkzr.PositionOf(tkzr.source.end())
+	tkzr.fillGap(endOfText.Offset, endOfText.Line, tkzr.currentTabLevel)
+	for len(tkzr.openScopes) > 0 {
+		opener := tkzr.openScopes[len(tkzr.openScopes)-1]
+		if tkzr.isInterpolationScope(len(tkzr.openScopes)) {
+			tkzr.addRepairedDiagnostic(DIAGNOSTIC_UNCLOSED_SCOPE, "interpolated expression started here is never ended", opener, endOfText,
+				"closed the expression at the end of the text")
+		} else if !tkzr.ScopesEndWithText {
+			tkzr.addRepairedDiagnostic(DIAGNOSTIC_UNCLOSED_SCOPE, "scope opened here is never closed", opener, endOfText,
+				"closed the scope at the end of the text")
+		}
+		tkzr.emitScopeClose(endOfText, nil)
+	}
+	tkzr.finishIndentation(endOfText)
+	if tkzr.source.readError != nil {
+		tkzr.addDiagnostic(SEVERITY_ERROR, DIAGNOSTIC_READ_ERROR, fmt.Sprintf("failed to read the text: %v", tkzr.source.readError), endOfText, endOfText)
+	}
+	tkzr.finished = true
 }
 
 // applyBeforeFunction
@@ -115,7 +318,7 @@ func (tkzr *Tokenizer) applyBeforeFunction() {
 
 // applyAfterFunction
 func (tkzr *Tokenizer) applyAfterFunction() {
-	for i := 0; i < len(tkzr.EndInfo)-1; i++ {
+	for i := 0; i < utf8.RuneCountInString(tkzr.EndInfo)-1; i++ {
 		if !tkzr.skipIncrement {
 			tkzr.IncrementIndex() // TODO: Determine whether this is a good long term solution or should be left for anonymous functions to deal with
 		}
@@ -127,55 +330,64 @@ func (tkzr *Tokenizer) applyAfterFunction() {
 
 // applyFunctions
 func (tkzr *Tokenizer) applyFunctions() bool {
+	tkzr.functionStartIndex = tkzr.currentIndex
+	tkzr.pendingInterpolation = nil
+	callbacks := tkzr.callbacks
 
-	if tkzr.StringStartFunction(tkzr) {
+	if tkzr.applyModeEnd() {
+		// FOUND MODE END
+		return true
+	}
+
+	if callbacks.StringStartFunction(tkzr) {
 		tkzr.applyBeforeFunction()
 		// FOUND STRING
-		resultingToken := tkzr.applyFunctionUntilFailureTokenCreation(tkzr.StringEndFunction, SYMBOLIC_NAME_STRING)
-		if tkzr.IncludeStrings {
-			tkzr.currentScope.Push(resultingToken)
-		}
-		tkzr.applyAfterFunction()
+		tkzr.addString(callbacks.StringEndFunction)
 		return true
 	}
 
-	if tkzr.CommentStartFunction(tkzr) {
+	if callbacks.CommentStartFunction(tkzr) {
 		tkzr.applyBeforeFunction()
 		// FOUND COMMENT
-		resultingToken := tkzr.applyFunctionUntilFailureTokenCreation(tkzr.CommentEndFunction, SYMBOLIC_NAME_COMMENT)
-		if tkzr.IncludeComments {
-			tkzr.currentScope.Push(resultingToken)
+		resultingToken := tkzr.applyFunctionUntilFailureTokenCreation(callbacks.CommentEndFunction, tk.KIND_COMMENT)
+		if tkzr.IncludeComments || tkzr.LosslessMode || resultingToken.Kind == tk.KIND_ERROR {
+			tkzr.emitToken(resultingToken)
 		}
 		tkzr.applyAfterFunction()
 		return true
 	}
 
-	if tkzr.ScopeStartFunction(tkzr) {
+	if callbacks.ScopeStartFunction(tkzr) {
 		tkzr.applyBeforeFunction()
 		// FOUND SCOPE START
-		preScopeToken := tkzr.createTokenType(tkzr.StartInfo)
-		tkzr.currentScope.Push(preScopeToken)
-
-		newScopeTkn := tk.InitScopeToken()
-		tkzr.currentScope.Push(newScopeTkn)
-		tkzr.currentScope = newScopeTkn.GetScopeToken()
+		preScopeToken := tkzr.createTokenType(tkzr.StartInfo, tkzr.functionStartIndex)
+		tkzr.emitToken(preScopeToken)
+		tkzr.emitScopeOpen(preScopeToken)
 		tkzr.applyAfterFunction()
 		return true
 	}
 
-	if tkzr.ScopeEndFunction(tkzr) {
+	if callbacks.ScopeEndFunction(tkzr) {
 		tkzr.applyBeforeFunction()
 		// FOUND SCOPE END
-		parentScope := tkzr.currentScope.GetScopeParent()
-		if parentScope == nil {
-			err := errors.New(fmt.Sprintf("Either malformed data attempted to be Tokenized or anonymous functions provided to tokenizers incorrectly defined when scopes being/end"))
-			util.Error(err.Error(), err)
+		// The position is found before the token, since positions are cheapest to find in order
+		start := tkzr.PositionOf(tkzr.functionStartIndex)
+		var postScopeToken *tk.Token
+		if tkzr.EndInfo != "" {
+			postScopeToken = tkzr.createTokenType(tkzr.EndInfo, tkzr.functionStartIndex)
+		}
+		unbalanced := len(tkzr.openScopes) == 0
+		if unbalanced {
+			tkzr.addRepairedDiagnostic(DIAGNOSTIC_UNBALANCED_SCOPE_CLOSE, "scope closed here when no scope is open",
+				start, tkzr.PositionOf(tkzr.functionStartIndex+len(tkzr.EndInfo)), "marked the end of the scope as an error token")
 		} else {
-			tkzr.currentScope = parentScope
+			tkzr.emitScopeClose(start, postScopeToken)
 		}
-		if tkzr.EndInfo != "" {
-			postScopeToken := tkzr.createTokenType(tkzr.EndInfo)
-			tkzr.currentScope.Push(postScopeToken)
+		if postScopeToken != nil {
+			if unbalanced && tkzr.RecoveryMode {
+				tkzr.setTokenKind(postScopeToken, tk.KIND_ERROR)
+			}
+			tkzr.emitToken(postScopeToken)
 		}
 		tkzr.applyAfterFunction()
 		return true
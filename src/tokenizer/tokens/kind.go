@@ -0,0 +1,242 @@
+package tokens
+
+import "fmt"
+
+// Kind
+// Defines what kind of token a token is. A kind is a stable numeric ID, whose upper bits hold
+// the category of the kind, so what category a token belongs to can be checked without any lookups.
+//
+// The built-in kinds (the KIND_ constants) have the same ID in every language. The kinds of a language's
+// keywords and symbols are registered with the language (see KindRegistry), and are numbered in the order
+// the keywords and symbols are listed, so they stay the same for as long as the language's lists do.
+type Kind uint32
+
+// KindCategory
+// Defines the broad group a kind belongs to
+type KindCategory uint32
+
+const (
+	CATEGORY_OTHER      KindCategory = iota // Anything which is not in one of the other categories (e.g. unknown symbols)
+	CATEGORY_IDENTIFIER                     // Names which are not keywords
+	CATEGORY_KEYWORD                        // The keywords of a language
+	CATEGORY_OPERATOR                       // The symbols of a language, including punctuation
+	CATEGORY_LITERAL                        // Strings and numbers
+	CATEGORY_TRIVIA                         // Whitespace, newlines and comments
+)
+
+// kindCategoryNames
+// The names categories are written as (e.g. in a language definition)
+var kindCategoryNames = []string{"other", "identifier", "keyword", "operator", "literal", "trivia"}
+
+// String
+// Returns the name of the category
+func (c KindCategory) String() string {
+	if int(c) < len(kindCategoryNames) {
+		return kindCategoryNames[c]
+	}
+	return fmt.Sprintf("CATEGORY(%d)", uint32(c))
+}
+
+// MarshalText
+// Writes the category as its name
+func (c KindCategory) MarshalText() ([]byte, error) {
+	if int(c) >= len(kindCategoryNames) {
+		return nil, fmt.Errorf("unknown kind category %d", uint32(c))
+	}
+	return []byte(kindCategoryNames[c]), nil
+}
+
+// UnmarshalText
+// Reads a category from its name
+func (c *KindCategory) UnmarshalText(text []byte) error {
+	for i, name := range kindCategoryNames {
+		if name == string(text) {
+			*c = KindCategory(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown kind category %q (expected one of %v)", string(text), kindCategoryNames)
+}
+
+// kindCategoryShift
+// The number of bits of a kind which hold its index within its category
+const kindCategoryShift = 24
+
+// Built-in kinds
+const (
+	KIND_UNIDENTIFIED Kind = Kind(CATEGORY_OTHER)<<kindCategoryShift | iota
+	KIND_SCOPE
+	KIND_UNKNOWN
+	KIND_ERROR
+	KIND_INDENT
+	KIND_DEDENT
+)
+
+const (
+	KIND_IDENTIFIER Kind = Kind(CATEGORY_IDENTIFIER)<<kindCategoryShift | iota
+)
+
+const (
+	KIND_STRING Kind = Kind(CATEGORY_LITERAL)<<kindCategoryShift | iota
+	KIND_NUMBER
+)
+
+const (
+	KIND_WHITESPACE Kind = Kind(CATEGORY_TRIVIA)<<kindCategoryShift | iota
+	KIND_NEWLINE
+	KIND_COMMENT
+)
+
+// NewKind
+// Returns the kind with the provided index within the category
+func NewKind(category KindCategory, index uint32) Kind {
+	return Kind(category)<<kindCategoryShift | Kind(index)
+}
+
+// Category
+// Returns the category the kind belongs to
+func (k Kind) Category() KindCategory {
+	return KindCategory(k >> kindCategoryShift)
+}
+
+// index
+// Returns the index of the kind within its category
+func (k Kind) index() uint32 {
+	return uint32(k) & (1<<kindCategoryShift - 1)
+}
+
+// IsIdentifier
+// Returns true if the kind is a name which is not a keyword
+func (k Kind) IsIdentifier() bool {
+	return k.Category() == CATEGORY_IDENTIFIER
+}
+
+// IsKeyword
+// Returns true if the kind is one of a language's keywords
+func (k Kind) IsKeyword() bool {
+	return k.Category() == CATEGORY_KEYWORD
+}
+
+// IsOperator
+// Returns true if the kind is one of a language's symbols
+func (k Kind) IsOperator() bool {
+	return k.Category() == CATEGORY_OPERATOR
+}
+
+// IsLiteral
+// Returns true if the kind is a string or a number
+func (k Kind) IsLiteral() bool {
+	return k.Category() == CATEGORY_LITERAL
+}
+
+// IsTrivia
+// Returns true if the kind is whitespace, a newline or a comment
+func (k Kind) IsTrivia() bool {
+	return k.Category() == CATEGORY_TRIVIA
+}
+
+// kindNames
+// The rule name and symbolic name a kind is written as
+type kindNames struct {
+	ruleName     string
+	symbolicName string
+}
+
+// KindRegistry
+// Defines the kinds known to a language, along with the rule name and symbolic name each kind is
+// written as (e.g. in JSON). The names are what tokens were identified by before kinds existed,
+// so they are kept on every token for compatibility.
+//
+// Two kinds may have the same names (e.g. a keyword named "identifier" has the same symbolic name as
+// KIND_IDENTIFIER), so tokens should be compared by kind rather than by name.
+type KindRegistry struct {
+	names     map[Kind]kindNames
+	kinds     map[kindNames][]Kind
+	nextIndex map[KindCategory]uint32
+}
+
+// NewKindRegistry
+// Creates a registry which only knows the kinds not tied to any language (KIND_UNIDENTIFIED and KIND_SCOPE)
+func NewKindRegistry() *KindRegistry {
+	registry := &KindRegistry{
+		names:     make(map[Kind]kindNames),
+		kinds:     make(map[kindNames][]Kind),
+		nextIndex: make(map[KindCategory]uint32),
+	}
+	registry.Register(KIND_UNIDENTIFIED, "unidentified", "Unidentified")
+	registry.Register(KIND_SCOPE, SCOPE_TOKEN_STIRNG, "")
+	return registry
+}
+
+// Register
+// Registers a kind with a fixed ID under the provided names.
+// Registering a kind again replaces its names.
+func (kr *KindRegistry) Register(kind Kind, ruleName string, symbolicName string) {
+	if oldNames, found := kr.names[kind]; found {
+		sameNames := kr.kinds[oldNames]
+		for i := range sameNames {
+			if sameNames[i] == kind {
+				kr.kinds[oldNames] = append(sameNames[:i:i], sameNames[i+1:]...)
+				break
+			}
+		}
+	}
+
+	names := kindNames{ruleName: ruleName, symbolicName: symbolicName}
+	kr.names[kind] = names
+	kr.kinds[names] = append(kr.kinds[names], kind)
+
+	if kind.index() >= kr.nextIndex[kind.Category()] {
+		kr.nextIndex[kind.Category()] = kind.index() + 1
+	}
+}
+
+// RegisterNext
+// Registers a new kind within the category under the provided names, using the next free ID of the category.
+// If a kind of the category is already registered under the same names, that kind is returned instead.
+func (kr *KindRegistry) RegisterNext(category KindCategory, ruleName string, symbolicName string) Kind {
+	for _, kind := range kr.kinds[kindNames{ruleName: ruleName, symbolicName: symbolicName}] {
+		if kind.Category() == category {
+			return kind
+		}
+	}
+
+	kind := NewKind(category, kr.nextIndex[category])
+	kr.Register(kind, ruleName, symbolicName)
+	return kind
+}
+
+// Names
+// Returns the rule name and symbolic name of the kind.
+// Returns false if the kind is not registered.
+func (kr *KindRegistry) Names(kind Kind) (string, string, bool) {
+	names, found := kr.names[kind]
+	return names.ruleName, names.symbolicName, found
+}
+
+// Name
+// Returns the symbolic name of the kind, or a description of its ID if it is not registered
+func (kr *KindRegistry) Name(kind Kind) string {
+	if names, found := kr.names[kind]; found {
+		return names.symbolicName
+	}
+	return fmt.Sprintf("KIND(%d)", uint32(kind))
+}
+
+// Lookup
+// Returns the kind written with the provided rule name and symbolic name, e.g. when reading tokens back from JSON.
+// If more than one kind has these names, the one registered first is returned.
+// Returns false if no kind has these names.
+func (kr *KindRegistry) Lookup(ruleName string, symbolicName string) (Kind, bool) {
+	sameNames := kr.kinds[kindNames{ruleName: ruleName, symbolicName: symbolicName}]
+	if len(sameNames) == 0 {
+		return KIND_UNIDENTIFIED, false
+	}
+	return sameNames[0], true
+}
+
+// Size
+// Returns the number of registered kinds
+func (kr *KindRegistry) Size() int {
+	return len(kr.names)
+}
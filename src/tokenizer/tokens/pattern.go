@@ -0,0 +1,425 @@
+package tokens
+
+// Pattern
+// A pattern which matches sequences of tokens, written like a regular expression over tokens (see CompilePattern).
+// A pattern is safe to be used by many goroutines at once.
+type Pattern struct {
+	pattern      string
+	program      []patternInstruction
+	captureNames []string
+}
+
+// PatternMatch
+// A sequence of tokens matched by a pattern
+//
+// Scope: the scope whose tokens were matched, if the pattern was matched against a scope (see Pattern.FindInScope)
+//
+// Start, End: where the match begins and ends (exclusive) within the tokens which were matched against
+//
+// Tokens: the tokens which were matched, including any trivia between them. This is part of the tokens which were
+// matched against, so it is not copied
+//
+// Captures: the tokens captured by each named capture (see CompilePattern). A capture which did not take part in the match is left out
+type PatternMatch struct {
+	Scope    *ScopeObj
+	Start    int
+	End      int
+	Tokens   []*Token
+	Captures map[string]PatternCapture
+}
+
+// PatternCapture
+// The tokens captured by a named capture of a pattern, which are found like the tokens of a PatternMatch
+type PatternCapture struct {
+	Start  int
+	End    int
+	Tokens []*Token
+}
+
+// patternNodeKind
+// Defines what a node of a parsed pattern matches
+type patternNodeKind int
+
+const (
+	patternToken       patternNodeKind = iota // A single token, which passes the node's test
+	patternSequence                           // Each of the node's children, one after another
+	patternAlternation                        // Any one of the node's children, tried in order
+	patternRepeat                             // The node's only child, repeated between min and max times (max < 0 means without limit)
+	patternCapture                            // The node's only child, whose tokens are captured
+)
+
+// patternNode
+// A node of a parsed pattern, which is compiled into the instructions a pattern is matched with (see compilePatternNode)
+type patternNode struct {
+	kind     patternNodeKind
+	test     func(token *Token) bool
+	children []*patternNode
+	min      int
+	max      int
+	lazy     bool
+	capture  int
+}
+
+// patternOp
+// Defines what an instruction of a compiled pattern does
+type patternOp int
+
+const (
+	patternOpToken patternOp = iota // Matches a token which passes the instruction's test, then continues with the next instruction
+	patternOpSplit                  // Continues with the instruction x, and failing that, with the instruction y
+	patternOpJump                   // Continues with the instruction x
+	patternOpSave                   // Records the position in the capture slot, then continues with the next instruction
+	patternOpMatch                  // Ends the match
+)
+
+// patternInstruction
+// An instruction of a compiled pattern
+//
+// slot: the capture slot of a save instruction. The start of capture c is kept in slot 2c, and its end in slot 2c+1
+type patternInstruction struct {
+	op   patternOp
+	test func(token *Token) bool
+	x    int
+	y    int
+	slot int
+}
+
+// patternJob
+// A state of the matcher which is still to be tried: the instruction and position to continue from, or the value of
+// a capture slot to restore once the matcher backtracks past the instruction which set it
+type patternJob struct {
+	pc      int
+	pos     int
+	restore bool
+}
+
+// patternMatcher
+// Matches a pattern against tokens by backtracking, with the trivia of the tokens left out. The states to be tried are kept on
+// a stack rather than by recursion, so any number of tokens can be matched. Every state (an instruction at a position) is tried
+// at most once, since one which did not lead to a match never will: a match is only accepted if it is not empty, which any
+// state after the start of the match satisfies. This keeps matching linear in the number of tokens, and a search for matches
+// at many positions (e.g. FindAll) keeps the states which failed from one position to the next.
+//
+// positions: the indices of the tokens which are not trivia
+//
+// slots: the start and end of each capture, counted in tokens which are not trivia. A start below 0 means the capture is not set
+//
+// visited: the states which have already been tried, with a bit for each instruction at each position. The states tried while
+// matching at a position are forgotten if a match is found there, since they include the states which led to it
+type patternMatcher struct {
+	program   []patternInstruction
+	tokens    []*Token
+	positions []int
+	slots     []int
+	visited   []uint64
+	jobs      []patternJob
+}
+
+// newPattern
+// Creates a pattern from the root of its parsed nodes
+func newPattern(pattern string, root *patternNode, captureNames []string) *Pattern {
+	program := compilePatternNode(root, nil)
+	program = append(program, patternInstruction{op: patternOpMatch})
+	return &Pattern{pattern: pattern, program: program, captureNames: captureNames}
+}
+
+// compilePatternNode
+// Adds the instructions which match the node to the program. Repeats are unrolled into their minimum number of
+// repetitions followed by the optional ones, where a greedy repeat tries to match once more before it tries to stop,
+// and a lazy one tries to stop first.
+func compilePatternNode(node *patternNode, program []patternInstruction) []patternInstruction {
+	switch node.kind {
+	case patternToken:
+		program = append(program, patternInstruction{op: patternOpToken, test: node.test})
+	case patternSequence:
+		for _, child := range node.children {
+			program = compilePatternNode(child, program)
+		}
+	case patternAlternation:
+		jumps := make([]int, 0, len(node.children)-1)
+		for i, child := range node.children {
+			if i == len(node.children)-1 {
+				program = compilePatternNode(child, program)
+				break
+			}
+			split := len(program)
+			program = append(program, patternInstruction{op: patternOpSplit, x: split + 1})
+			program = compilePatternNode(child, program)
+			jumps = append(jumps, len(program))
+			program = append(program, patternInstruction{op: patternOpJump})
+			program[split].y = len(program)
+		}
+		for _, jump := range jumps {
+			program[jump].x = len(program)
+		}
+	case patternRepeat:
+		for i := 0; i < node.min; i++ {
+			program = compilePatternNode(node.children[0], program)
+		}
+		if node.max < 0 {
+			split := len(program)
+			program = append(program, patternInstruction{op: patternOpSplit})
+			program = compilePatternNode(node.children[0], program)
+			program = append(program, patternInstruction{op: patternOpJump, x: split})
+			program[split].x, program[split].y = orderSplit(split+1, len(program), node.lazy)
+		}
+		for i := node.min; i < node.max; i++ {
+			split := len(program)
+			program = append(program, patternInstruction{op: patternOpSplit})
+			program = compilePatternNode(node.children[0], program)
+			program[split].x, program[split].y = orderSplit(split+1, len(program), node.lazy)
+		}
+	case patternCapture:
+		program = append(program, patternInstruction{op: patternOpSave, slot: 2 * node.capture})
+		program = compilePatternNode(node.children[0], program)
+		program = append(program, patternInstruction{op: patternOpSave, slot: 2*node.capture + 1})
+	}
+	return program
+}
+
+// orderSplit
+// Returns the instruction which repeats a node and the one which stops repeating it, in the order they are tried
+func orderSplit(repeat int, stop int, lazy bool) (int, int) {
+	if lazy {
+		return stop, repeat
+	}
+	return repeat, stop
+}
+
+// matchesEmpty
+// Returns true if the node can match without any tokens
+func (node *patternNode) matchesEmpty() bool {
+	switch node.kind {
+	case patternSequence:
+		for _, child := range node.children {
+			if !child.matchesEmpty() {
+				return false
+			}
+		}
+		return true
+	case patternAlternation:
+		for _, child := range node.children {
+			if child.matchesEmpty() {
+				return true
+			}
+		}
+		return false
+	case patternRepeat:
+		return node.min == 0 || node.children[0].matchesEmpty()
+	case patternCapture:
+		return node.children[0].matchesEmpty()
+	}
+	return false
+}
+
+// String
+// Returns the text the pattern was compiled from
+func (p *Pattern) String() string {
+	return p.pattern
+}
+
+// MatchAt
+// Matches the pattern against the tokens starting at the index, or at the first token after it which is not trivia.
+// Returns false if the pattern does not match there. Matches are never empty.
+func (p *Pattern) MatchAt(tokens []*Token, start int) (PatternMatch, bool) {
+	matcher := p.newMatcher(tokens)
+	i := 0
+	for i < len(matcher.positions) && matcher.positions[i] < start {
+		i++
+	}
+	return matcher.matchAt(p, i)
+}
+
+// Find
+// Returns the first match of the pattern within the tokens, or false if there is none
+func (p *Pattern) Find(tokens []*Token) (PatternMatch, bool) {
+	matcher := p.newMatcher(tokens)
+	for i := range matcher.positions {
+		if match, found := matcher.matchAt(p, i); found {
+			return match, true
+		}
+	}
+	return PatternMatch{}, false
+}
+
+// FindAll
+// Returns the matches of the pattern within the tokens, from first to last. The matches do not overlap,
+// as the search for the next match begins where the last one ended.
+func (p *Pattern) FindAll(tokens []*Token) []PatternMatch {
+	matches := make([]PatternMatch, 0)
+	matcher := p.newMatcher(tokens)
+	for i := 0; i < len(matcher.positions); i++ {
+		match, found := matcher.matchAt(p, i)
+		if !found {
+			continue
+		}
+		matches = append(matches, match)
+		for i+1 < len(matcher.positions) && matcher.positions[i+1] < match.End {
+			i++
+		}
+	}
+	return matches
+}
+
+// FindInScope
+// Returns the matches of the pattern within the token list of the scope and of each of its scopes (see FindAll),
+// with the scopes in the order they are opened. A match never reaches across the boundary of a scope, though a pattern
+// can match scope tokens (see CompilePattern). To match across scopes, the pattern may be matched against ConvertToArray instead.
+func (p *Pattern) FindInScope(scope *ScopeObj) []PatternMatch {
+	matches := make([]PatternMatch, 0)
+	p.findInScope(scope, &matches)
+	return matches
+}
+
+// findInScope
+// Adds the matches within the scope and its scopes to the matches
+func (p *Pattern) findInScope(scope *ScopeObj, matches *[]PatternMatch) {
+	for _, match := range p.FindAll(scope.tokenList) {
+		match.Scope = scope
+		*matches = append(*matches, match)
+	}
+	for _, token := range scope.tokenList {
+		if token.ValidScopeToken() {
+			p.findInScope(token.scopeToken, matches)
+		}
+	}
+}
+
+// matchesAll
+// Returns true if the pattern matches all the tokens, leaving out their trivia
+func (p *Pattern) matchesAll(tokens []*Token) bool {
+	matcher := p.newMatcher(tokens)
+	_, found := matcher.run(0, func(end int) bool { return end == len(matcher.positions) })
+	return found
+}
+
+// newMatcher
+// Creates a matcher of the pattern for the tokens
+func (p *Pattern) newMatcher(tokens []*Token) *patternMatcher {
+	positions := make([]int, 0, len(tokens))
+	for i, token := range tokens {
+		if !token.Kind.IsTrivia() {
+			positions = append(positions, i)
+		}
+	}
+	return &patternMatcher{
+		program:   p.program,
+		tokens:    tokens,
+		positions: positions,
+		slots:     make([]int, 2*len(p.captureNames)),
+		visited:   make([]uint64, (len(p.program)*(len(positions)+1)+63)/64),
+	}
+}
+
+// matchAt
+// Matches the pattern starting at the token which is not trivia at the index
+func (m *patternMatcher) matchAt(p *Pattern, start int) (PatternMatch, bool) {
+	for i := range m.slots {
+		m.slots[i] = -1
+	}
+	end, found := m.run(start, func(matchEnd int) bool { return matchEnd > start })
+	if !found {
+		return PatternMatch{}, false
+	}
+
+	match := PatternMatch{Captures: make(map[string]PatternCapture)}
+	match.Start, match.End = m.span(start, end)
+	match.Tokens = m.tokens[match.Start:match.End]
+	for i, name := range p.captureNames {
+		if m.slots[2*i] < 0 {
+			continue
+		}
+		capture := PatternCapture{}
+		capture.Start, capture.End = m.span(m.slots[2*i], m.slots[2*i+1])
+		capture.Tokens = m.tokens[capture.Start:capture.End]
+		match.Captures[name] = capture
+	}
+	return match, true
+}
+
+// span
+// Returns where the tokens between the start and end (which are counted in tokens which are not trivia) are within all the tokens
+func (m *patternMatcher) span(start int, end int) (int, int) {
+	if start == end {
+		if start < len(m.positions) {
+			return m.positions[start], m.positions[start]
+		}
+		return len(m.tokens), len(m.tokens)
+	}
+	return m.positions[start], m.positions[end-1] + 1
+}
+
+// visit
+// Marks the instruction at the position as tried, returning false if it already was
+func (m *patternMatcher) visit(pc int, pos int) bool {
+	state := pc*(len(m.positions)+1) + pos
+	if m.visited[state/64]&(1<<(state%64)) != 0 {
+		return false
+	}
+	m.visited[state/64] |= 1 << (state % 64)
+	return true
+}
+
+// forget
+// Marks every instruction at the positions from the start to the end (inclusive) as not tried
+func (m *patternMatcher) forget(start int, end int) {
+	for pc := range m.program {
+		first, last := pc*(len(m.positions)+1)+start, pc*(len(m.positions)+1)+end
+		for first <= last && first%64 != 0 {
+			m.visited[first/64] &^= 1 << (first % 64)
+			first++
+		}
+		for ; first+63 <= last; first += 64 {
+			m.visited[first/64] = 0
+		}
+		for ; first <= last; first++ {
+			m.visited[first/64] &^= 1 << (first % 64)
+		}
+	}
+}
+
+// run
+// Matches the pattern starting at the token which is not trivia at the index, trying the ways it can match in order of priority
+// until one ends where accept returns true. Returns the index after the match, along with whether one was found.
+func (m *patternMatcher) run(start int, accept func(end int) bool) (int, bool) {
+	furthest := start
+	m.jobs = append(m.jobs[:0], patternJob{pc: 0, pos: start})
+	for len(m.jobs) > 0 {
+		job := m.jobs[len(m.jobs)-1]
+		m.jobs = m.jobs[:len(m.jobs)-1]
+		if job.restore {
+			m.slots[job.pc] = job.pos
+			continue
+		}
+
+		pc, pos := job.pc, job.pos
+	thread:
+		for m.visit(pc, pos) {
+			instruction := &m.program[pc]
+			switch instruction.op {
+			case patternOpToken:
+				if pos >= len(m.positions) || !instruction.test(m.tokens[m.positions[pos]]) {
+					break thread
+				}
+				pc, pos = pc+1, pos+1
+				furthest = max(furthest, pos)
+			case patternOpSplit:
+				m.jobs = append(m.jobs, patternJob{pc: instruction.y, pos: pos})
+				pc = instruction.x
+			case patternOpJump:
+				pc = instruction.x
+			case patternOpSave:
+				m.jobs = append(m.jobs, patternJob{pc: instruction.slot, pos: m.slots[instruction.slot], restore: true})
+				m.slots[instruction.slot] = pos
+				pc++
+			case patternOpMatch:
+				if !accept(pos) {
+					break thread
+				}
+				m.forget(start, furthest)
+				return pos, true
+			}
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,354 @@
+package tokens
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// patternSpecialChars
+// The characters which have a meaning of their own within a pattern, so they have to be quoted to match tokens with these texts
+const patternSpecialChars = "[]|@?*+'\"/"
+
+// patternSingleChars
+// The characters which always match a token of their own, even when they are written right next to other punctuation (e.g. "){")
+const patternSingleChars = "(){},;"
+
+// CompilePattern
+// Compiles a pattern which matches sequences of tokens. A pattern is a sequence of elements separated by whitespace,
+// which match one token after another:
+//
+//	IDENTIFIER   a word matches any token whose kind has the symbolic name, or whose text is the word (e.g. "catch")
+//	(  ->  ==    punctuation matches any token with the text. Brackets, commas and semicolons match a token on their own,
+//	             while any other punctuation which is not separated by whitespace is taken as a single text
+//	'x'  "x"     a quoted text matches any token with the text, in which a backslash escapes the next character
+//	/^get/       a regular expression matches any token whose text it matches. The i flag ignores case (e.g. /^get/i)
+//	_            any single token
+//	...          any number of tokens, as few as possible
+//	@            any scope token
+//	@Method      any scope token whose scope is of the type
+//	@[p]         any scope token whose scope's tokens are all matched by the pattern p (e.g. "@[]" for an empty scope),
+//	             which may follow a type (e.g. "@Method[...]"). The pattern p cannot capture tokens
+//	[p]          a group, which matches the pattern p
+//	p | q        either the pattern p or the pattern q, with p tried first
+//	e?  e*  e+   the element e at most once, any number of times, or at least once, written right after the element. These match
+//	             as many times as possible, unless followed by another ? to match as few times as possible (e.g. "_*?").
+//	             An element which can match no tokens (e.g. "[_*]" or "...") cannot be repeated
+//	name:e       captures the tokens matched by the element e (e.g. "name:IDENTIFIER", "args:[...]" or "args:IDENTIFIER*")
+//	             under the name. A capture which is repeated (e.g. "[name:IDENTIFIER]*") keeps the tokens of the last repetition
+//
+// Trivia (e.g. whitespace and comments) is left out of the tokens a pattern is matched against, so a pattern matches the same
+// tokens in lossless mode. The characters [ ] | @ ? * + ' " / have to be quoted to match tokens with these texts.
+//
+// For example, "IDENTIFIER ( ... ) {" matches the start of a method, and "catch ( IDENTIFIER IDENTIFIER ) { @[] }" matches
+// an empty catch clause.
+func CompilePattern(pattern string) (*Pattern, error) {
+	parser := patternParser{query: pattern, captures: make(map[string]int)}
+	if strings.TrimSpace(pattern) == "" {
+		return nil, parser.errorf("the pattern is empty")
+	}
+	root, err := parser.parseAlternation()
+	if err != nil {
+		return nil, err
+	}
+	if parser.pos < len(pattern) {
+		return nil, parser.errorf("unexpected %q", pattern[parser.pos])
+	}
+	return newPattern(pattern, root, parser.captureNames), nil
+}
+
+// MustCompilePattern
+// Compiles a pattern like CompilePattern, panicking if it is invalid. It is meant for patterns which are known to be valid,
+// e.g. those held by package level variables.
+func MustCompilePattern(pattern string) *Pattern {
+	compiled, err := CompilePattern(pattern)
+	if err != nil {
+		panic(err)
+	}
+	return compiled
+}
+
+// patternParser
+// Parses a pattern into its nodes, one character at a time
+//
+// captures: the index of each capture's name within captureNames
+//
+// scopeDepth: the number of scope patterns (e.g. "@[...]") the parser is within, where captures are not allowed
+type patternParser struct {
+	query        string
+	pos          int
+	captures     map[string]int
+	captureNames []string
+	scopeDepth   int
+}
+
+// errorf
+// Returns an error describing what is wrong with the pattern at the current position
+func (p *patternParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("invalid pattern %q at offset %d: %s", p.query, p.pos, fmt.Sprintf(format, args...))
+}
+
+// peek
+// Returns the current character, or 0 at the end of the pattern
+func (p *patternParser) peek() byte {
+	if p.pos < len(p.query) {
+		return p.query[p.pos]
+	}
+	return 0
+}
+
+// skipSpaces
+// Moves past any whitespace
+func (p *patternParser) skipSpaces() {
+	for p.pos < len(p.query) && isPatternSpace(p.query[p.pos]) {
+		p.pos++
+	}
+}
+
+// parseAlternation
+// Parses sequences separated by "|", up to the end of the pattern or a closing bracket
+func (p *patternParser) parseAlternation() (*patternNode, error) {
+	alternation := &patternNode{kind: patternAlternation}
+	for {
+		sequence, err := p.parseSequence()
+		if err != nil {
+			return nil, err
+		}
+		alternation.children = append(alternation.children, sequence)
+		if p.peek() != '|' {
+			break
+		}
+		p.pos++
+	}
+	if len(alternation.children) == 1 {
+		return alternation.children[0], nil
+	}
+	return alternation, nil
+}
+
+// parseSequence
+// Parses elements (along with their quantifiers) up to the end of the pattern, a "|" or a closing bracket
+func (p *patternParser) parseSequence() (*patternNode, error) {
+	sequence := &patternNode{kind: patternSequence}
+	for {
+		p.skipSpaces()
+		switch p.peek() {
+		case 0, ']', '|':
+			if len(sequence.children) == 1 {
+				return sequence.children[0], nil
+			}
+			return sequence, nil
+		case '?', '*', '+':
+			return nil, p.errorf("%q has nothing to repeat (quote it to match a token with the text)", p.peek())
+		}
+		element, err := p.parseQuantified()
+		if err != nil {
+			return nil, err
+		}
+		sequence.children = append(sequence.children, element)
+	}
+}
+
+// parseQuantified
+// Parses an element followed by an optional quantifier, which is written right after it
+func (p *patternParser) parseQuantified() (*patternNode, error) {
+	element, err := p.parseElement()
+	if err != nil {
+		return nil, err
+	}
+	repeat := &patternNode{kind: patternRepeat, children: []*patternNode{element}}
+	switch p.peek() {
+	case '?':
+		repeat.min, repeat.max = 0, 1
+	case '*':
+		repeat.min, repeat.max = 0, -1
+	case '+':
+		repeat.min, repeat.max = 1, -1
+	default:
+		return element, nil
+	}
+	if element.matchesEmpty() {
+		return nil, p.errorf("%q repeats an element which can match no tokens", p.peek())
+	}
+	p.pos++
+	if p.peek() == '?' {
+		repeat.lazy = true
+		p.pos++
+	}
+	return repeat, nil
+}
+
+// parseElement
+// Parses a single element, without its quantifier
+func (p *patternParser) parseElement() (*patternNode, error) {
+	switch c := p.peek(); {
+	case c == '[':
+		return p.parseGroup()
+	case c == '@':
+		return p.parseScope()
+	case c == '\'' || c == '"':
+		text, err := p.parseDelimited(c, false)
+		if err != nil {
+			return nil, err
+		}
+		return textNode(text), nil
+	case c == '/':
+		return p.parseRegex()
+	case isPatternWordChar(c):
+		return p.parseWordElement()
+	case strings.IndexByte(patternSpecialChars, c) >= 0:
+		return nil, p.errorf("unexpected %q", c)
+	}
+
+	start := p.pos
+	if strings.IndexByte(patternSingleChars, p.peek()) >= 0 {
+		p.pos++
+	} else {
+		for p.pos < len(p.query) {
+			c := p.query[p.pos]
+			if isPatternSpace(c) || isPatternWordChar(c) || strings.IndexByte(patternSpecialChars+patternSingleChars, c) >= 0 {
+				break
+			}
+			p.pos++
+		}
+	}
+	text := p.query[start:p.pos]
+	if text == "..." {
+		anyToken := &patternNode{kind: patternToken, test: func(*Token) bool { return true }}
+		return &patternNode{kind: patternRepeat, children: []*patternNode{anyToken}, min: 0, max: -1, lazy: true}, nil
+	}
+	return textNode(text), nil
+}
+
+// parseWordElement
+// Parses an element which begins with a word: a capture, the wildcard "_", or a token's symbolic name or text
+func (p *patternParser) parseWordElement() (*patternNode, error) {
+	start := p.pos
+	word := p.parseWord()
+	// A capture's name is followed right away by a colon and its element (e.g. "name:IDENTIFIER"), unlike a word
+	// followed by a colon (e.g. "else :" or "else:" at the end of a pattern)
+	if p.peek() == ':' && p.pos+1 < len(p.query) && !isPatternSpace(p.query[p.pos+1]) && strings.IndexByte("]|", p.query[p.pos+1]) < 0 {
+		if p.scopeDepth > 0 {
+			p.pos = start
+			return nil, p.errorf("capture %q is within the pattern of a scope, where tokens cannot be captured", word)
+		}
+		p.pos++
+		element, err := p.parseQuantified()
+		if err != nil {
+			return nil, err
+		}
+		index, found := p.captures[word]
+		if !found {
+			index = len(p.captureNames)
+			p.captures[word] = index
+			p.captureNames = append(p.captureNames, word)
+		}
+		return &patternNode{kind: patternCapture, children: []*patternNode{element}, capture: index}, nil
+	}
+	if word == "_" {
+		return &patternNode{kind: patternToken, test: func(*Token) bool { return true }}, nil
+	}
+	return &patternNode{kind: patternToken, test: func(token *Token) bool {
+		return token.SymbolicName == word || token.Text == word
+	}}, nil
+}
+
+// parseGroup
+// Parses a group, e.g. "[ , IDENTIFIER ]"
+func (p *patternParser) parseGroup() (*patternNode, error) {
+	p.pos++
+	group, err := p.parseAlternation()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek() != ']' {
+		return nil, p.errorf("expected \"]\" to close the group")
+	}
+	p.pos++
+	return group, nil
+}
+
+// parseScope
+// Parses an element which matches scope tokens, e.g. "@Method[ ... ]"
+func (p *patternParser) parseScope() (*patternNode, error) {
+	p.pos++
+	scopeType := ""
+	if isPatternWordChar(p.peek()) {
+		scopeType = p.parseWord()
+	}
+	var contents *Pattern
+	if p.peek() == '[' {
+		p.scopeDepth++
+		group, err := p.parseGroup()
+		p.scopeDepth--
+		if err != nil {
+			return nil, err
+		}
+		contents = newPattern("", group, nil)
+	}
+	return &patternNode{kind: patternToken, test: func(token *Token) bool {
+		if !token.ValidScopeToken() || (scopeType != "" && token.scopeToken.GetType() != scopeType) {
+			return false
+		}
+		return contents == nil || contents.matchesAll(token.scopeToken.tokenList)
+	}}, nil
+}
+
+// parseRegex
+// Parses a regular expression written between slashes, which may be followed by the i flag to ignore case (e.g. /^get/i)
+func (p *patternParser) parseRegex() (*patternNode, error) {
+	start := p.pos
+	expression, err := p.parseDelimited('/', true)
+	if err != nil {
+		return nil, err
+	}
+	if p.peek() == 'i' {
+		p.pos++
+		expression = "(?i)" + expression
+	}
+	compiled, err := regexp.Compile(expression)
+	if err != nil {
+		p.pos = start
+		return nil, p.errorf("invalid regular expression: %s", err)
+	}
+	return &patternNode{kind: patternToken, test: func(token *Token) bool { return compiled.MatchString(token.Text) }}, nil
+}
+
+// parseWord
+// Parses the letters, digits, underscores and dollar signs at the current position
+func (p *patternParser) parseWord() string {
+	start := p.pos
+	for p.pos < len(p.query) && isPatternWordChar(p.query[p.pos]) {
+		p.pos++
+	}
+	return p.query[start:p.pos]
+}
+
+// parseDelimited
+// Parses the text between the delimiter at the current position and the next one which is not escaped (see scanDelimited)
+func (p *patternParser) parseDelimited(delimiter byte, keepEscapes bool) (string, error) {
+	text, end, closed := scanDelimited(p.query, p.pos, keepEscapes)
+	if !closed {
+		return "", p.errorf("%q is never closed", delimiter)
+	}
+	p.pos = end
+	return text, nil
+}
+
+// textNode
+// Returns the node which matches any token with the text
+func textNode(text string) *patternNode {
+	return &patternNode{kind: patternToken, test: func(token *Token) bool { return token.Text == text }}
+}
+
+// isPatternWordChar
+// Returns true if the character can be part of a word
+func isPatternWordChar(c byte) bool {
+	return c == '_' || c == '$' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c >= 0x80
+}
+
+// isPatternSpace
+// Returns true if the character separates the elements of a pattern
+func isPatternSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\n'
+}
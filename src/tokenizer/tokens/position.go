@@ -0,0 +1,29 @@
+package tokens
+
+import "fmt"
+
+// Position
+// Defines a place within the text which was tokenized
+//
+// Line: the line number of the position, starting at 1
+//
+// Column: the column of the position within its line, starting at 1. Columns are counted in characters (runes), not bytes
+//
+// Offset: the byte offset of the position from the start of the text, starting at 0
+type Position struct {
+	Line   int
+	Column int
+	Offset int
+}
+
+// ToString
+// Returns the position formatted as line:column (offset)
+func (p Position) ToString() string {
+	return fmt.Sprintf("%d:%d (%d)", p.Line, p.Column, p.Offset)
+}
+
+// ToJsonString
+// Returns the position as a single line JSON object
+func (p Position) ToJsonString() string {
+	return fmt.Sprintf("{\"Line\": %d, \"Column\": %d, \"Offset\": %d}", p.Line, p.Column, p.Offset)
+}
@@ -19,13 +19,53 @@ import (
 // scopeIndices: This array give quick access to where ScopeObj objects are found within the tokenList by storing the indices of said ScopeObj's
 //
 // size: This is the number of tokens within this scope object. DOES NOT INCLUDE INNER SCOPE SIZES
+//
+// start: The position in the text where the contents of this scope begin
+//
+// end: The position in the text where the contents of this scope end (exclusive)
+//
+// encoding: The encoding the text was decoded from. Only the file-level scope created by a tokenizer has one
+//
+// opener: The token which opened this scope (e.g. "{" or ":"), which is found in the parent scope right before this scope's token
+//
+// closer: The token which closed this scope (e.g. "}"), which is found in the parent scope right after this scope's token.
+// Nil if the scope was closed without one
+//
+// depth: The number of scopes this scope is nested in. The file-level scope has a depth of 0
+//
+// implicitlyClosed: Whether this scope was closed without a closing token, e.g. by a dedent or the end of the text
+//
+// header: The tokens before the opener which belong to the same statement as it (e.g. "if ( a )" before "{"),
+// which are found in the parent scope. Only set if the language finds headers
+//
+// name: The name of what this scope is the body of (e.g. the name of a method), if it has one
 type ScopeObj struct {
-	scopeType    string
-	info         *any
-	tokenList    []*Token
-	scopeIndices []int
-	size         int
-	parentScope  *ScopeObj
+	scopeType        string
+	info             *any
+	tokenList        []*Token
+	scopeIndices     []int
+	size             int
+	parentScope      *ScopeObj
+	start            Position
+	end              Position
+	encoding         Encoding
+	opener           *Token
+	closer           *Token
+	depth            int
+	implicitlyClosed bool
+	header           []*Token
+	name             string
+}
+
+// Encoding
+// Defines the encoding a text was decoded from before it was tokenized
+//
+// Name: the name of the encoding (e.g. "UTF-8" or "UTF-16LE"). Empty if the text was never decoded
+//
+// ByteOrderMark: whether the text began with a byte-order mark, which was removed before it was tokenized
+type Encoding struct {
+	Name          string
+	ByteOrderMark bool
 }
 
 // InitScope
@@ -79,6 +119,7 @@ func InitScopeToken(scopes ...*ScopeObj) *Token {
 	return &Token{
 		LineNumber:   0,
 		TabNumber:    0,
+		Kind:         KIND_SCOPE,
 		SymbolicName: "",
 		RuleName:     SCOPE_TOKEN_STIRNG,
 		Text:         "",
@@ -117,6 +158,115 @@ func (so *ScopeObj) GetNumberOfScopes() int {
 	return len(so.scopeIndices)
 }
 
+// GetStart
+// Returns the position in the text where the contents of this scope begin
+func (so *ScopeObj) GetStart() Position {
+	return so.start
+}
+
+// GetEnd
+// Returns the position in the text where the contents of this scope end.
+// This position is exclusive, i.e. it is right after the scope's last character
+func (so *ScopeObj) GetEnd() Position {
+	return so.end
+}
+
+// SetStart
+// Sets the position in the text where the contents of this scope begin
+func (so *ScopeObj) SetStart(start Position) {
+	so.start = start
+}
+
+// SetEnd
+// Sets the position in the text where the contents of this scope end
+func (so *ScopeObj) SetEnd(end Position) {
+	so.end = end
+}
+
+// GetEncoding
+// Returns the encoding the text was decoded from. Only the file-level scope created by a tokenizer has one
+func (so *ScopeObj) GetEncoding() Encoding {
+	return so.encoding
+}
+
+// SetEncoding
+// Sets the encoding the text was decoded from
+func (so *ScopeObj) SetEncoding(encoding Encoding) {
+	so.encoding = encoding
+}
+
+// GetOpener
+// Returns the token which opened this scope, or nil if it has none
+func (so *ScopeObj) GetOpener() *Token {
+	return so.opener
+}
+
+// SetOpener
+// Sets the token which opened this scope
+func (so *ScopeObj) SetOpener(opener *Token) {
+	so.opener = opener
+}
+
+// GetCloser
+// Returns the token which closed this scope, or nil if it has none (see IsImplicitlyClosed)
+func (so *ScopeObj) GetCloser() *Token {
+	return so.closer
+}
+
+// SetCloser
+// Sets the token which closed this scope
+func (so *ScopeObj) SetCloser(closer *Token) {
+	so.closer = closer
+}
+
+// GetDepth
+// Returns the number of scopes this scope is nested in. The file-level scope has a depth of 0
+func (so *ScopeObj) GetDepth() int {
+	return so.depth
+}
+
+// SetDepth
+// Sets the number of scopes this scope is nested in
+func (so *ScopeObj) SetDepth(depth int) {
+	so.depth = depth
+}
+
+// IsImplicitlyClosed
+// Returns true if this scope was closed without a closing token, e.g. by a dedent or the end of the text
+func (so *ScopeObj) IsImplicitlyClosed() bool {
+	return so.implicitlyClosed
+}
+
+// SetImplicitlyClosed
+// Sets whether this scope was closed without a closing token
+func (so *ScopeObj) SetImplicitlyClosed(implicitlyClosed bool) {
+	so.implicitlyClosed = implicitlyClosed
+}
+
+// GetHeader
+// Returns the tokens before the opener which belong to the same statement as it, without any trivia
+func (so *ScopeObj) GetHeader() []*Token {
+	return so.header
+}
+
+// SetHeader
+// Sets the tokens before the opener which belong to the same statement as it
+func (so *ScopeObj) SetHeader(header []*Token) {
+	so.header = header
+}
+
+// GetName
+// Returns the name of what this scope is the body of (e.g. the name of a method), or an empty string if it has none
+func (so *ScopeObj) GetName() string {
+	return so.name
+}
+
+// SetName
+// Sets the name of what this scope is the body of
+func (so *ScopeObj) SetName(name string) {
+	so.name = name
+}
+
 func (so *ScopeObj) SetScopeParent(newParent *ScopeObj) {
 	so.parentScope = newParent
 }
@@ -342,6 +492,17 @@ func (so *ScopeObj) ConvertToArray() []*Token {
 	return rtnArray
 }
 
+// Source
+// This will join the text of all the tokens in the scope object (and its scopes) in order.
+// For a scope object created by a tokenizer in lossless mode, this is exactly the text which was tokenized.
+func (so *ScopeObj) Source() string {
+	var builder strings.Builder
+	for token := range so.Tokens() {
+		builder.WriteString(token.Text)
+	}
+	return builder.String()
+}
+
 // PrintSymbolicNames
 // This will print out the symbolic names of all the tokens
 // in this scope object on a single line.
@@ -366,6 +527,9 @@ func (so *ScopeObj) PrintTexts() {
 	fmt.Println()
 }
 
+// ToJsonString
+// Returns the scope as a JSON object with a single member named by the tag, which holds the scope's tokens as an array.
+// Every scope within it is an array of its tokens as well. ToDetailedJsonString also describes the boundaries of each scope.
 func (so *ScopeObj) ToJsonString(tag string) string {
 	str := "{\n"
 	str += fmt.Sprintf("\"%s\":\n", tag)
@@ -416,3 +580,75 @@ func (so *ScopeObj) toJsonHelper(tabLevel int) string {
 
 	return outputStr
 }
+
+// ToDetailedJsonString
+// Returns the scope as a JSON object with a single member named by the tag, like ToJsonString does, except that
+// every scope is an object holding its boundaries (see scopeJsonHeader) along with its "Tokens".
+func (so *ScopeObj) ToDetailedJsonString(tag string) string {
+	str := "{\n"
+	str += fmt.Sprintf("\"%s\": ", tag)
+	str += so.toDetailedJsonHelper(0)
+	str += "\n}"
+
+	return strings.ReplaceAll(str, "\t", "    ")
+}
+
+// scopeJsonHeader
+// Returns the members of the scope's JSON object which describe its boundaries, each on a line of its own
+func (so *ScopeObj) scopeJsonHeader() []string {
+	members := []string{
+		fmt.Sprintf("\"Type\": \"%s\"", strings.ReplaceAll(so.scopeType, "\"", "\\\"")),
+		fmt.Sprintf("\"Name\": \"%s\"", strings.ReplaceAll(so.name, "\"", "\\\"")),
+		fmt.Sprintf("\"Depth\": %d", so.depth),
+		fmt.Sprintf("\"Start\": %s", so.start.ToJsonString()),
+		fmt.Sprintf("\"End\": %s", so.end.ToJsonString()),
+		fmt.Sprintf("\"ImplicitlyClosed\": %t", so.implicitlyClosed),
+		fmt.Sprintf("\"Opener\": %s", optionalTokenJson(so.opener)),
+		fmt.Sprintf("\"Closer\": %s", optionalTokenJson(so.closer)),
+	}
+	if so.encoding.Name != "" {
+		members = append(members, fmt.Sprintf("\"Encoding\": {\"Name\": \"%s\", \"ByteOrderMark\": %t}", so.encoding.Name, so.encoding.ByteOrderMark))
+	}
+	return members
+}
+
+// optionalTokenJson
+// Returns the token as a JSON object, or null if there is no token
+func optionalTokenJson(token *Token) string {
+	if token == nil {
+		return "null"
+	}
+	return token.ToJsonString(0)
+}
+
+// toDetailedJsonHelper
+// Returns the scope as a JSON object holding its boundaries and its tokens, indented by the tab level
+func (so *ScopeObj) toDetailedJsonHelper(tabLevel int) string {
+	tabString := strings.Repeat("\t", tabLevel)
+	innerTabString := tabString + "\t"
+	tokenTabString := innerTabString + "\t"
+
+	outputStr := "{\n"
+	for _, member := range so.scopeJsonHeader() {
+		outputStr += innerTabString + strings.ReplaceAll(member, "\n", "\n"+innerTabString) + ",\n"
+	}
+	outputStr += innerTabString + "\"Tokens\": ["
+
+	for i, token := range so.tokenList {
+		outputStr += "\n" + tokenTabString
+		if token.ValidScopeToken() {
+			outputStr += token.scopeToken.toDetailedJsonHelper(tabLevel + 2)
+		} else {
+			outputStr += token.ToJsonString(tabLevel + 2)
+		}
+		if i != len(so.tokenList)-1 {
+			outputStr += ","
+		}
+	}
+	if len(so.tokenList) > 0 {
+		outputStr += "\n" + innerTabString
+	}
+	outputStr += "]\n" + tabString + "}"
+
+	return outputStr
+}
@@ -0,0 +1,265 @@
+package tokens
+
+// Selector
+// A query which finds tokens and scopes within a scope, written like a CSS selector (see ParseSelector).
+// A selector is safe to be used by many goroutines at once.
+type Selector struct {
+	query        string
+	alternatives []complexSelector
+}
+
+// Match
+// A token or scope found by a selector
+//
+// Token: the token which was matched. For a scope, this is its scope token
+//
+// Scope: the scope which was matched, or nil if a token which is not a scope token was matched
+//
+// Depth: the depth of the match (see the depth attribute of ParseSelector)
+//
+// Path: where the token is within the scope the selector was matched against (see WalkLocation)
+type Match struct {
+	Token *Token
+	Scope *ScopeObj
+	Depth int
+	Path  []int
+}
+
+// selectorCombinator
+// Defines how two compound selectors of a complex selector relate to each other
+type selectorCombinator int
+
+const (
+	combinatorDescendant     selectorCombinator = iota // "a b": b is within a, at any depth
+	combinatorChild                                    // "a > b": b is directly within a
+	combinatorAdjacent                                 // "a + b": b comes right after a, within the same scope
+	combinatorGeneralSibling                           // "a ~ b": b comes after a, within the same scope
+)
+
+// complexSelector
+// A list of compound selectors joined by combinators, e.g. "scope.Class > scope.Method IDENTIFIER".
+// combinators[i] joins compounds[i] and compounds[i+1].
+type complexSelector struct {
+	compounds   []compoundSelector
+	combinators []selectorCombinator
+}
+
+// compoundSelector
+// The conditions a single token or scope has to meet, e.g. the type, attributes and pseudo-classes of "scope[depth>3]:last-child"
+type compoundSelector struct {
+	conditions []selectorCondition
+}
+
+// selectorCondition
+// A single condition of a compound selector
+type selectorCondition func(element *selectorElement) bool
+
+// selectorElement
+// A token (or scope token) a selector is matched against, along with where it is within the tree
+//
+// ancestors: the scope tokens the token is within, outermost first, not counting the scope the selector was matched against
+//
+// baseDepth: the depth of the scope the token is within
+type selectorElement struct {
+	token     *Token
+	parent    *ScopeObj
+	index     int
+	ancestors []selectorElement
+	baseDepth int
+}
+
+// String
+// Returns the query the selector was parsed from
+func (s *Selector) String() string {
+	return s.query
+}
+
+// Match
+// Returns the tokens and scopes within the scope (and its scopes) which the selector matches, in the order they are found
+// in the text. The scope itself is never matched.
+func (s *Selector) Match(scope *ScopeObj) []Match {
+	matches := make([]Match, 0)
+	s.match(scope, func(element *selectorElement, location WalkLocation) {
+		match := Match{Token: element.token, Depth: element.depth(), Path: append([]int(nil), location.Path...)}
+		if element.token.ValidScopeToken() {
+			match.Scope = element.token.scopeToken
+		}
+		matches = append(matches, match)
+	})
+	return matches
+}
+
+// MatchTokens
+// Returns the tokens within the scope (and its scopes) which the selector matches, in the order they are found in the text,
+// without the scope tokens of the scopes it matches
+func (s *Selector) MatchTokens(scope *ScopeObj) []*Token {
+	matches := make([]*Token, 0)
+	s.match(scope, func(element *selectorElement, location WalkLocation) {
+		if !element.token.ValidScopeToken() {
+			matches = append(matches, element.token)
+		}
+	})
+	return matches
+}
+
+// MatchScopes
+// Returns the scopes within the scope (and its scopes) which the selector matches, in the order they are opened
+func (s *Selector) MatchScopes(scope *ScopeObj) []*ScopeObj {
+	matches := make([]*ScopeObj, 0)
+	s.match(scope, func(element *selectorElement, location WalkLocation) {
+		if element.token.ValidScopeToken() {
+			matches = append(matches, element.token.scopeToken)
+		}
+	})
+	return matches
+}
+
+// Query
+// Parses the query (see ParseSelector) and returns the tokens and scopes within this scope which it matches (see Selector.Match)
+func (so *ScopeObj) Query(query string) ([]Match, error) {
+	selector, err := ParseSelector(query)
+	if err != nil {
+		return nil, err
+	}
+	return selector.Match(so), nil
+}
+
+// match
+// Walks through the scope, calling found with every token and scope token the selector matches
+func (s *Selector) match(scope *ScopeObj, found func(element *selectorElement, location WalkLocation)) {
+	ancestors := make([]selectorElement, 0, 8)
+	element := func(token *Token, location WalkLocation) selectorElement {
+		return selectorElement{
+			token:     token,
+			parent:    location.Parent,
+			index:     location.Index(),
+			ancestors: ancestors,
+			baseDepth: scope.GetDepth() + location.Depth,
+		}
+	}
+	scope.Walk(Visitor{
+		EnterScope: func(token *Token, _ *ScopeObj, location WalkLocation) WalkAction {
+			scopeElement := element(token, location)
+			if s.matches(&scopeElement) {
+				found(&scopeElement, location)
+			}
+			ancestors = append(ancestors, scopeElement)
+			return WALK_CONTINUE
+		},
+		LeaveScope: func(*Token, *ScopeObj, WalkLocation) WalkAction {
+			ancestors = ancestors[:len(ancestors)-1]
+			return WALK_CONTINUE
+		},
+		Token: func(token *Token, location WalkLocation) WalkAction {
+			tokenElement := element(token, location)
+			if s.matches(&tokenElement) {
+				found(&tokenElement, location)
+			}
+			return WALK_CONTINUE
+		},
+	})
+}
+
+// matches
+// Returns true if any of the selector's alternatives match the element
+func (s *Selector) matches(element *selectorElement) bool {
+	for i := range s.alternatives {
+		if s.alternatives[i].matches(element, len(s.alternatives[i].compounds)-1) {
+			return true
+		}
+	}
+	return false
+}
+
+// matches
+// Returns true if the element matches the compound selector at the index, and the elements it is related to
+// match the compound selectors before it
+func (cs *complexSelector) matches(element *selectorElement, index int) bool {
+	if !cs.compounds[index].matches(element) {
+		return false
+	}
+	if index == 0 {
+		return true
+	}
+
+	switch cs.combinators[index-1] {
+	case combinatorChild:
+		if len(element.ancestors) == 0 {
+			return false
+		}
+		return cs.matches(&element.ancestors[len(element.ancestors)-1], index-1)
+	case combinatorDescendant:
+		for i := len(element.ancestors) - 1; i >= 0; i-- {
+			if cs.matches(&element.ancestors[i], index-1) {
+				return true
+			}
+		}
+	case combinatorAdjacent:
+		if sibling, found := element.previousSibling(); found {
+			return cs.matches(&sibling, index-1)
+		}
+	case combinatorGeneralSibling:
+		for sibling, found := element.previousSibling(); found; sibling, found = sibling.previousSibling() {
+			if cs.matches(&sibling, index-1) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matches
+// Returns true if the element meets all the conditions of the compound selector
+func (cs *compoundSelector) matches(element *selectorElement) bool {
+	for _, condition := range cs.conditions {
+		if !condition(element) {
+			return false
+		}
+	}
+	return true
+}
+
+// depth
+// Returns the depth of the element: the depth of its scope for a scope token, or the depth of the scope it is within otherwise
+func (element *selectorElement) depth() int {
+	if element.token.ValidScopeToken() {
+		return element.baseDepth + 1
+	}
+	return element.baseDepth
+}
+
+// previousSibling
+// Returns the closest token before the element within the same scope which is not trivia
+func (element *selectorElement) previousSibling() (selectorElement, bool) {
+	tokens := element.parent.tokenList
+	for i := element.index - 1; i >= 0; i-- {
+		if !tokens[i].Kind.IsTrivia() {
+			return element.sibling(i), true
+		}
+	}
+	return selectorElement{}, false
+}
+
+// nextSibling
+// Returns the closest token after the element within the same scope which is not trivia
+func (element *selectorElement) nextSibling() (selectorElement, bool) {
+	tokens := element.parent.tokenList
+	for i := element.index + 1; i < len(tokens); i++ {
+		if !tokens[i].Kind.IsTrivia() {
+			return element.sibling(i), true
+		}
+	}
+	return selectorElement{}, false
+}
+
+// sibling
+// Returns the token at the index of the element's scope
+func (element *selectorElement) sibling(index int) selectorElement {
+	return selectorElement{
+		token:     element.parent.tokenList[index],
+		parent:    element.parent,
+		index:     index,
+		ancestors: element.ancestors,
+		baseDepth: element.baseDepth,
+	}
+}
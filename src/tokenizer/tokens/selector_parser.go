@@ -0,0 +1,527 @@
+package tokens
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// selectorAttribute
+// An attribute which selectors can test, e.g. the "depth" of "[depth>3]"
+//
+// value: returns the value of the attribute for a token, or false if the token does not have it (e.g. the type of a token which is not a scope token)
+//
+// numeric: whether the attribute is a number, which can be compared with <, <=, > and >=
+type selectorAttribute struct {
+	value   func(element *selectorElement) (string, bool)
+	numeric bool
+}
+
+// selectorAttributes
+// The attributes selectors can test, by name
+var selectorAttributes = map[string]selectorAttribute{
+	"text":     {value: func(element *selectorElement) (string, bool) { return element.token.Text, true }},
+	"kind":     {value: func(element *selectorElement) (string, bool) { return element.token.SymbolicName, true }},
+	"rule":     {value: func(element *selectorElement) (string, bool) { return element.token.RuleName, true }},
+	"category": {value: func(element *selectorElement) (string, bool) { return element.token.Kind.Category().String(), true }},
+	"line":     {value: func(element *selectorElement) (string, bool) { return strconv.Itoa(element.token.LineNumber), true }, numeric: true},
+	"column":   {value: func(element *selectorElement) (string, bool) { return strconv.Itoa(element.token.Start.Column), true }, numeric: true},
+	"offset":   {value: func(element *selectorElement) (string, bool) { return strconv.Itoa(element.token.Start.Offset), true }, numeric: true},
+	"depth":    {value: func(element *selectorElement) (string, bool) { return strconv.Itoa(element.depth()), true }, numeric: true},
+	"type": {value: func(element *selectorElement) (string, bool) {
+		if !element.token.ValidScopeToken() {
+			return "", false
+		}
+		return element.token.scopeToken.GetType(), true
+	}},
+	"name": {value: func(element *selectorElement) (string, bool) {
+		if !element.token.ValidScopeToken() {
+			return "", false
+		}
+		return element.token.scopeToken.GetName(), true
+	}},
+	"header": {value: func(element *selectorElement) (string, bool) {
+		if !element.token.ValidScopeToken() {
+			return "", false
+		}
+		header := element.token.scopeToken.GetHeader()
+		texts := make([]string, len(header))
+		for i, token := range header {
+			texts[i] = token.Text
+		}
+		return strings.Join(texts, " "), true
+	}},
+}
+
+// selectorOperators
+// The operators attributes can be tested with, longest first so "<=" is found before "<"
+var selectorOperators = []string{"!=", "^=", "$=", "*=", "~=", "=~", "<=", ">=", "=", "<", ">"}
+
+// ParseSelector
+// Parses a query for tokens and scopes, which is written like a CSS selector. A query is a list of selectors separated by commas,
+// which matches everything any of them match. A selector is a list of compound selectors joined by combinators:
+//
+//	a b    b is within the scope of a, at any depth
+//	a > b  b is directly within the scope of a
+//	a + b  b comes right after a, within the same scope
+//	a ~ b  b comes after a, within the same scope
+//
+// The sibling combinators (and the :first-child and :last-child pseudo-classes) skip trivia, so they find the same tokens
+// in lossless mode, though trivia is matched like any other token (e.g. by *). A compound selector is an optional type followed by any number of attribute tests and pseudo-classes:
+//
+//	scope        any scope
+//	token        any token which is not a scope token
+//	*            any token or scope
+//	IDENTIFIER   any token whose kind has the symbolic name (the same as [kind=IDENTIFIER])
+//	"class"      any token with the text (the same as [text="class"])
+//	.Method      any scope of the type (the same as [type=Method])
+//	#main        any scope with the name (the same as [name=main])
+//	[attr]       has the attribute, and its value is not empty
+//	[attr=v]     the value of the attribute is v. The other operators are != (is not), ^= (starts with), $= (ends with),
+//	             *= (contains), ~= (is one of the words of), =~ (matches the regular expression, e.g. /^get/ or /get/i)
+//	             and <, <=, >, >= (compares numbers)
+//	:not(q)      does not match the query q
+//	:first-child is the first token of its scope which is not trivia
+//	:last-child  is the last token of its scope which is not trivia
+//
+// The attributes are text, kind (the symbolic name), rule (the rule name), category (e.g. keyword), line, column, offset
+// and depth, along with type, name and header (the texts of the header, separated by spaces) for scopes only.
+// A token or scope without an attribute never matches a test of it. The depth of a scope is its own depth (see ScopeObj.GetDepth),
+// and the depth of any other token is the depth of the scope it is within.
+// Values are words (letters, digits, "_" and "-") or quoted strings, in which a backslash escapes the next character.
+//
+// For example, `"class" + IDENTIFIER` finds the names of classes, and "scope[depth>3][header~=for]" finds the scopes
+// nested more than 3 deep whose header has a "for"
+func ParseSelector(query string) (*Selector, error) {
+	parser := selectorParser{query: query}
+	alternatives, err := parser.parseList()
+	if err != nil {
+		return nil, err
+	}
+	if parser.pos < len(query) {
+		return nil, parser.errorf("unexpected %q", query[parser.pos])
+	}
+	return &Selector{query: query, alternatives: alternatives}, nil
+}
+
+// MustParseSelector
+// Parses a query like ParseSelector, panicking if it is invalid. It is meant for queries which are known to be valid,
+// e.g. those held by package level variables.
+func MustParseSelector(query string) *Selector {
+	selector, err := ParseSelector(query)
+	if err != nil {
+		panic(err)
+	}
+	return selector
+}
+
+// selectorParser
+// Parses a query into selectors, one character at a time
+type selectorParser struct {
+	query string
+	pos   int
+}
+
+// errorf
+// Returns an error describing what is wrong with the query at the current position
+func (p *selectorParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("invalid selector %q at offset %d: %s", p.query, p.pos, fmt.Sprintf(format, args...))
+}
+
+// peek
+// Returns the current character, or 0 at the end of the query
+func (p *selectorParser) peek() byte {
+	if p.pos < len(p.query) {
+		return p.query[p.pos]
+	}
+	return 0
+}
+
+// skipSpaces
+// Moves past any whitespace, returning true if there was any
+func (p *selectorParser) skipSpaces() bool {
+	start := p.pos
+	for p.pos < len(p.query) && strings.IndexByte(" \t\r\n", p.query[p.pos]) >= 0 {
+		p.pos++
+	}
+	return p.pos > start
+}
+
+// parseList
+// Parses selectors separated by commas, up to the end of the query or a closing parenthesis
+func (p *selectorParser) parseList() ([]complexSelector, error) {
+	alternatives := make([]complexSelector, 0, 1)
+	for {
+		p.skipSpaces()
+		alternative, err := p.parseComplex()
+		if err != nil {
+			return nil, err
+		}
+		alternatives = append(alternatives, alternative)
+		if p.peek() != ',' {
+			return alternatives, nil
+		}
+		p.pos++
+	}
+}
+
+// parseComplex
+// Parses compound selectors joined by combinators
+func (p *selectorParser) parseComplex() (complexSelector, error) {
+	selector := complexSelector{}
+	for {
+		compound, err := p.parseCompound()
+		if err != nil {
+			return selector, err
+		}
+		selector.compounds = append(selector.compounds, compound)
+
+		hadSpaces := p.skipSpaces()
+		combinator := combinatorDescendant
+		switch p.peek() {
+		case 0, ',', ')':
+			return selector, nil
+		case '>':
+			combinator = combinatorChild
+		case '+':
+			combinator = combinatorAdjacent
+		case '~':
+			combinator = combinatorGeneralSibling
+		default:
+			if !hadSpaces {
+				return selector, p.errorf("unexpected %q", p.peek())
+			}
+		}
+		if combinator != combinatorDescendant {
+			p.pos++
+			p.skipSpaces()
+		}
+		selector.combinators = append(selector.combinators, combinator)
+	}
+}
+
+// parseCompound
+// Parses a type followed by attribute tests and pseudo-classes
+func (p *selectorParser) parseCompound() (compoundSelector, error) {
+	start := p.pos
+	compound := compoundSelector{}
+	switch c := p.peek(); {
+	case c == '*':
+		p.pos++
+	case c == '"' || c == '\'':
+		text, err := p.parseString()
+		if err != nil {
+			return compound, err
+		}
+		compound.conditions = append(compound.conditions, attributeCondition(selectorAttributes["text"], "=", text))
+	case isSelectorWordChar(c):
+		switch word := p.parseWord(); word {
+		case "scope":
+			compound.conditions = append(compound.conditions, func(element *selectorElement) bool { return element.token.ValidScopeToken() })
+		case "token":
+			compound.conditions = append(compound.conditions, func(element *selectorElement) bool { return !element.token.ValidScopeToken() })
+		default:
+			compound.conditions = append(compound.conditions, attributeCondition(selectorAttributes["kind"], "=", word))
+		}
+	}
+
+	for {
+		var condition selectorCondition
+		var err error
+		switch p.peek() {
+		case '[':
+			condition, err = p.parseAttribute()
+		case '.', '#':
+			attribute := map[byte]string{'.': "type", '#': "name"}[p.peek()]
+			p.pos++
+			if !isSelectorWordChar(p.peek()) {
+				return compound, p.errorf("expected a %s after %q", attribute, p.query[p.pos-1])
+			}
+			condition = attributeCondition(selectorAttributes[attribute], "=", p.parseWord())
+		case ':':
+			condition, err = p.parsePseudoClass()
+		default:
+			if p.pos == start {
+				if p.peek() == 0 {
+					return compound, p.errorf("expected a selector at the end of the query")
+				}
+				return compound, p.errorf("expected a selector, found %q", p.peek())
+			}
+			return compound, nil
+		}
+		if err != nil {
+			return compound, err
+		}
+		compound.conditions = append(compound.conditions, condition)
+	}
+}
+
+// parseAttribute
+// Parses an attribute test, e.g. "[depth>3]"
+func (p *selectorParser) parseAttribute() (selectorCondition, error) {
+	p.pos++
+	p.skipSpaces()
+	nameStart := p.pos
+	name := p.parseWord()
+	attribute, found := selectorAttributes[name]
+	if !found {
+		p.pos = nameStart
+		names := make([]string, 0, len(selectorAttributes))
+		for attributeName := range selectorAttributes {
+			names = append(names, attributeName)
+		}
+		sort.Strings(names)
+		return nil, p.errorf("unknown attribute %q (expected one of %v)", name, names)
+	}
+	p.skipSpaces()
+	if p.peek() == ']' {
+		p.pos++
+		return func(element *selectorElement) bool {
+			value, found := attribute.value(element)
+			return found && value != ""
+		}, nil
+	}
+
+	operator := ""
+	for _, candidate := range selectorOperators {
+		if strings.HasPrefix(p.query[p.pos:], candidate) {
+			operator = candidate
+			break
+		}
+	}
+	if operator == "" {
+		return nil, p.errorf("expected an operator or \"]\" after attribute %q", name)
+	}
+	p.pos += len(operator)
+	p.skipSpaces()
+
+	var condition selectorCondition
+	switch operator {
+	case "=~":
+		pattern, err := p.parseRegex()
+		if err != nil {
+			return nil, err
+		}
+		condition = func(element *selectorElement) bool {
+			value, found := attribute.value(element)
+			return found && pattern.MatchString(value)
+		}
+	case "<", "<=", ">", ">=":
+		if !attribute.numeric {
+			return nil, p.errorf("attribute %q is not a number, so it cannot be compared with %q", name, operator)
+		}
+		valueStart := p.pos
+		number, err := strconv.Atoi(p.parseWord())
+		if err != nil {
+			p.pos = valueStart
+			return nil, p.errorf("expected a number after %q", operator)
+		}
+		condition = numericCondition(attribute, operator, number)
+	default:
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		condition = attributeCondition(attribute, operator, value)
+	}
+
+	p.skipSpaces()
+	if p.peek() != ']' {
+		return nil, p.errorf("expected \"]\" to close the test of attribute %q", name)
+	}
+	p.pos++
+	return condition, nil
+}
+
+// parsePseudoClass
+// Parses a pseudo-class, e.g. ":not(COMMENT)"
+func (p *selectorParser) parsePseudoClass() (selectorCondition, error) {
+	p.pos++
+	nameStart := p.pos
+	switch name := p.parseWord(); name {
+	case "first-child":
+		return func(element *selectorElement) bool {
+			_, found := element.previousSibling()
+			return !found && !element.token.Kind.IsTrivia()
+		}, nil
+	case "last-child":
+		return func(element *selectorElement) bool {
+			_, found := element.nextSibling()
+			return !found && !element.token.Kind.IsTrivia()
+		}, nil
+	case "not":
+		if p.peek() != '(' {
+			return nil, p.errorf("expected \"(\" after \":not\"")
+		}
+		p.pos++
+		alternatives, err := p.parseList()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ')' {
+			return nil, p.errorf("expected \")\" to close \":not(\"")
+		}
+		p.pos++
+		negated := Selector{alternatives: alternatives}
+		return func(element *selectorElement) bool { return !negated.matches(element) }, nil
+	default:
+		p.pos = nameStart
+		return nil, p.errorf("unknown pseudo-class %q (expected first-child, last-child or not)", name)
+	}
+}
+
+// parseValue
+// Parses the value of an attribute test, which is either a word or a quoted string
+func (p *selectorParser) parseValue() (string, error) {
+	if c := p.peek(); c == '"' || c == '\'' {
+		return p.parseString()
+	}
+	if !isSelectorWordChar(p.peek()) {
+		return "", p.errorf("expected a value")
+	}
+	return p.parseWord(), nil
+}
+
+// parseWord
+// Parses the letters, digits, underscores and dashes at the current position
+func (p *selectorParser) parseWord() string {
+	start := p.pos
+	for p.pos < len(p.query) && isSelectorWordChar(p.query[p.pos]) {
+		p.pos++
+	}
+	return p.query[start:p.pos]
+}
+
+// parseString
+// Parses a string quoted with single or double quotes, in which a backslash escapes the next character
+func (p *selectorParser) parseString() (string, error) {
+	return p.parseDelimited(p.peek(), false)
+}
+
+// parseRegex
+// Parses a regular expression written between slashes, which may be followed by the i flag to ignore case (e.g. /^get/i).
+// A quoted string is taken as a regular expression too.
+func (p *selectorParser) parseRegex() (*regexp.Regexp, error) {
+	start := p.pos
+	var pattern string
+	var err error
+	switch c := p.peek(); c {
+	case '/':
+		pattern, err = p.parseDelimited('/', true)
+		if err == nil && p.peek() == 'i' {
+			p.pos++
+			pattern = "(?i)" + pattern
+		}
+	case '"', '\'':
+		pattern, err = p.parseString()
+	default:
+		return nil, p.errorf("expected a regular expression (e.g. /^get/)")
+	}
+	if err != nil {
+		return nil, err
+	}
+	compiled, err := regexp.Compile(pattern)
+	if err != nil {
+		p.pos = start
+		return nil, p.errorf("invalid regular expression: %s", err)
+	}
+	return compiled, nil
+}
+
+// parseDelimited
+// Parses the text between the delimiter at the current position and the next one which is not escaped (see scanDelimited)
+func (p *selectorParser) parseDelimited(delimiter byte, keepEscapes bool) (string, error) {
+	text, end, closed := scanDelimited(p.query, p.pos, keepEscapes)
+	if !closed {
+		return "", p.errorf("%q is never closed", delimiter)
+	}
+	p.pos = end
+	return text, nil
+}
+
+// scanDelimited
+// Returns the text between the delimiter at the start position of the query and the next one which is not escaped by a backslash,
+// along with the position after the closing delimiter. If keepEscapes is true, the backslashes are kept unless they escape
+// the delimiter (as regular expressions have their own escapes). closed is false if the delimiter is never closed.
+func scanDelimited(query string, start int, keepEscapes bool) (text string, end int, closed bool) {
+	delimiter := query[start]
+	var builder strings.Builder
+	for i := start + 1; i < len(query); i++ {
+		c := query[i]
+		if c == delimiter {
+			return builder.String(), i + 1, true
+		}
+		if c == '\\' && i+1 < len(query) {
+			i++
+			if keepEscapes && query[i] != delimiter {
+				builder.WriteByte('\\')
+			}
+		}
+		builder.WriteByte(query[i])
+	}
+	return "", start, false
+}
+
+// isSelectorWordChar
+// Returns true if the character can be part of a word (a type, attribute name or unquoted value)
+func isSelectorWordChar(c byte) bool {
+	return c == '_' || c == '-' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// attributeCondition
+// Returns the condition which compares the attribute with the value using one of the text operators
+func attributeCondition(attribute selectorAttribute, operator string, expected string) selectorCondition {
+	var compare func(value string) bool
+	switch operator {
+	case "=":
+		compare = func(value string) bool { return value == expected }
+	case "!=":
+		compare = func(value string) bool { return value != expected }
+	case "^=":
+		compare = func(value string) bool { return strings.HasPrefix(value, expected) }
+	case "$=":
+		compare = func(value string) bool { return strings.HasSuffix(value, expected) }
+	case "*=":
+		compare = func(value string) bool { return strings.Contains(value, expected) }
+	case "~=":
+		compare = func(value string) bool {
+			for _, word := range strings.Fields(value) {
+				if word == expected {
+					return true
+				}
+			}
+			return false
+		}
+	}
+	return func(element *selectorElement) bool {
+		value, found := attribute.value(element)
+		return found && compare(value)
+	}
+}
+
+// numericCondition
+// Returns the condition which compares the numeric attribute with the number using one of the numeric operators
+func numericCondition(attribute selectorAttribute, operator string, expected int) selectorCondition {
+	return func(element *selectorElement) bool {
+		text, found := attribute.value(element)
+		if !found {
+			return false
+		}
+		value, err := strconv.Atoi(text)
+		if err != nil {
+			return false
+		}
+		switch operator {
+		case "<":
+			return value < expected
+		case "<=":
+			return value <= expected
+		case ">":
+			return value > expected
+		default:
+			return value >= expected
+		}
+	}
+}
@@ -7,12 +7,19 @@ import (
 	"tp/src/util"
 )
 
+// Token
+// Defines a single token found in the text.
+// The kind of a token is what it should be identified by. The symbolic name and rule name are
+// the names of the kind, which are kept for compatibility (e.g. with JSON written before kinds existed).
 type Token struct {
 	LineNumber   int
 	TabNumber    int
+	Kind         Kind
 	SymbolicName string
 	RuleName     string
 	Text         string
+	Start        Position
+	End          Position
 	scopeToken   *ScopeObj
 }
 
@@ -20,6 +27,7 @@ func CreateUnidentifiedToken(text string, lineNumber int, tabNum int) Token {
 	return Token{
 		LineNumber:   lineNumber,
 		TabNumber:    tabNum,
+		Kind:         KIND_UNIDENTIFIED,
 		SymbolicName: "Unidentified",
 		RuleName:     "unidentified",
 		Text:         text,
@@ -31,15 +39,39 @@ func (t *Token) ToString() string {
 	return fmt.Sprintf("[Tkn: txt:%s\tln:%d\ttb:%d\tsn:%s\trn:%s]", t.Text, t.LineNumber, t.TabNumber, t.SymbolicName, t.RuleName)
 }
 
+// Equal
+// Returns true if both tokens are of the same kind, meaning their kinds are in the same category and have the same names.
+// The kinds themselves are not compared, since each language numbers the kinds of its keywords and symbols in its own order,
+// so tokens from different languages (or from languages built from different lists) can be compared.
 func (t *Token) Equal(t2 Token) bool {
-	return t.SymbolicName == t2.SymbolicName && t.RuleName == t2.RuleName
+	return t.Kind.Category() == t2.Kind.Category() && t.SymbolicName == t2.SymbolicName && t.RuleName == t2.RuleName
 }
 
+// SetValues
+// Sets the names of the token without changing its kind. Use SetKind to identify a token.
 func (t *Token) SetValues(ruleName string, symbolicName string) {
 	t.RuleName = ruleName
 	t.SymbolicName = symbolicName
 }
 
+// SetKind
+// Sets the kind of the token, along with the names the kind is registered under.
+// If the kind is not registered, the names are left as they are.
+func (t *Token) SetKind(kind Kind, registry *KindRegistry) {
+	t.Kind = kind
+	if ruleName, symbolicName, found := registry.Names(kind); found {
+		t.SetValues(ruleName, symbolicName)
+	}
+}
+
+// SetSpan
+// Sets where the token starts and ends within the text.
+// The end position is exclusive, i.e. it is the position right after the token's last character.
+func (t *Token) SetSpan(start Position, end Position) {
+	t.Start = start
+	t.End = end
+}
+
 // ValidScopeToken
 // Returns true if the provided token is a scope token; false otherwise.
 func (t *Token) ValidScopeToken() bool {
@@ -68,7 +100,7 @@ func (t *Token) ToJsonString(tabLevel int) string {
 		tabString += "\t"
 	}
 
-	tempString := fmt.Sprintf("{\n\t\"LineNumber\": %d,\n\t\"TabNumber\": %d,\n\t\"SymbolicName\": \"%s\",\n\t\"RuleName\": \"%s\",\n\t\"Text\": \"%s\"\n}", t.LineNumber, t.TabNumber, symName, rulName, txtName)
+	tempString := fmt.Sprintf("{\n\t\"LineNumber\": %d,\n\t\"TabNumber\": %d,\n\t\"Kind\": %d,\n\t\"SymbolicName\": \"%s\",\n\t\"RuleName\": \"%s\",\n\t\"Text\": \"%s\",\n\t\"Start\": %s,\n\t\"End\": %s\n}", t.LineNumber, t.TabNumber, uint32(t.Kind), symName, rulName, txtName, t.Start.ToJsonString(), t.End.ToJsonString())
 
 	return strings.ReplaceAll(tempString, "\n", "\n"+tabString)
 }
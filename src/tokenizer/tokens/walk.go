@@ -0,0 +1,135 @@
+package tokens
+
+import "iter"
+
+// WalkAction
+// Tells Walk how to carry on after one of a Visitor's callbacks
+type WalkAction int
+
+const (
+	WALK_CONTINUE WalkAction = iota // Carry on walking
+	WALK_SKIP                       // Skip the contents of the scope which is being entered. Otherwise, the same as WALK_CONTINUE
+	WALK_STOP                       // Stop walking, without calling any more callbacks
+)
+
+// WalkLocation
+// Describes where a token is within the scope a walk began at
+//
+// Depth: the number of scopes the token is within, not counting the scope the walk began at.
+// For a walk of the file-level scope, the depth of a scope token is one less than the depth of its scope (see ScopeObj.GetDepth)
+//
+// Path: the index of the token within its scope, preceded by the indices of the scope tokens it is within, outermost first
+// (e.g. [3, 0] for the first token of the scope at index 3). Its length is always Depth + 1.
+// The path is reused as the walk goes on, so it must be copied to be kept after the callback returns
+//
+// Parent: the scope the token is directly within
+type WalkLocation struct {
+	Depth  int
+	Path   []int
+	Parent *ScopeObj
+}
+
+// Index
+// Returns the index of the token within its scope
+func (location WalkLocation) Index() int {
+	return location.Path[len(location.Path)-1]
+}
+
+// Visitor
+// Defines the callbacks Walk makes. A callback which is nil is not called, as if it returned WALK_CONTINUE.
+//
+// EnterScope: called with a scope token and its scope before the tokens within the scope are walked.
+// Returning WALK_SKIP skips them, in which case LeaveScope is not called for the scope either
+//
+// LeaveScope: called with a scope token and its scope after the tokens within the scope were walked
+//
+// Token: called with every token which is not a scope token
+type Visitor struct {
+	EnterScope func(token *Token, scope *ScopeObj, location WalkLocation) WalkAction
+	LeaveScope func(token *Token, scope *ScopeObj, location WalkLocation) WalkAction
+	Token      func(token *Token, location WalkLocation) WalkAction
+}
+
+// Walk
+// Walks through the tokens within this scope (and its scopes) in the order they are found in the text,
+// calling the visitor's callbacks along the way. Returns false if a callback stopped the walk with WALK_STOP.
+func (so *ScopeObj) Walk(visitor Visitor) bool {
+	path := make([]int, 1, 8)
+	return so.walk(visitor, path)
+}
+
+// walk
+// Walks through the tokens within this scope, whose path (without the index of the tokens) is provided
+func (so *ScopeObj) walk(visitor Visitor, path []int) bool {
+	depth := len(path) - 1
+	for i, token := range so.tokenList {
+		path[depth] = i
+		location := WalkLocation{Depth: depth, Path: path, Parent: so}
+		if !token.ValidScopeToken() {
+			if visitor.Token != nil && visitor.Token(token, location) == WALK_STOP {
+				return false
+			}
+			continue
+		}
+
+		scope := token.scopeToken
+		if visitor.EnterScope != nil {
+			switch visitor.EnterScope(token, scope, location) {
+			case WALK_STOP:
+				return false
+			case WALK_SKIP:
+				continue
+			}
+		}
+		if !scope.walk(visitor, append(path, 0)) {
+			return false
+		}
+		if visitor.LeaveScope != nil && visitor.LeaveScope(token, scope, location) == WALK_STOP {
+			return false
+		}
+	}
+	return true
+}
+
+// Inspect
+// Calls the function with every token within this scope (and its scopes), including scope tokens,
+// in the order they are found in the text. If the function returns false for a scope token,
+// the tokens within its scope are skipped.
+func (so *ScopeObj) Inspect(inspect func(token *Token, location WalkLocation) bool) {
+	so.Walk(Visitor{
+		EnterScope: func(token *Token, scope *ScopeObj, location WalkLocation) WalkAction {
+			if !inspect(token, location) {
+				return WALK_SKIP
+			}
+			return WALK_CONTINUE
+		},
+		Token: func(token *Token, location WalkLocation) WalkAction {
+			inspect(token, location)
+			return WALK_CONTINUE
+		},
+	})
+}
+
+// Tokens
+// Returns an iterator over the tokens within this scope (and its scopes) in the order they are found in the text,
+// without the scope tokens. It goes through the same tokens as ConvertToArray, without building an array of them.
+func (so *ScopeObj) Tokens() iter.Seq[*Token] {
+	return func(yield func(*Token) bool) {
+		so.yieldTokens(yield)
+	}
+}
+
+// yieldTokens
+// Yields the tokens within this scope (and its scopes), returning false if the iteration was stopped
+func (so *ScopeObj) yieldTokens(yield func(*Token) bool) bool {
+	for _, token := range so.tokenList {
+		if token.ValidScopeToken() {
+			if !token.scopeToken.yieldTokens(yield) {
+				return false
+			}
+		} else if !yield(token) {
+			return false
+		}
+	}
+	return true
+}
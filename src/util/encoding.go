@@ -0,0 +1,115 @@
+package util
+
+import (
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Encoding names
+const (
+	ENCODING_UTF8    = "UTF-8"
+	ENCODING_UTF16LE = "UTF-16LE"
+	ENCODING_UTF16BE = "UTF-16BE"
+)
+
+// ENCODING_SNIFF_LENGTH
+// The number of bytes looked at to guess the encoding of text without a byte-order mark
+const ENCODING_SNIFF_LENGTH = 1024
+
+// utf16SniffMinimum
+// The fewest bytes text without a byte-order mark must have for it to be taken for UTF-16
+const utf16SniffMinimum = 16
+
+// DecodeText
+// Converts the bytes of a file into UTF-8 text, returning the text along with the name of the encoding it was found in
+// and whether it began with a byte-order mark, which is removed from the text (see DetectEncoding).
+// Text which is neither UTF-8 nor UTF-16 is decoded by the fallback, in which case the name returned is empty.
+// If the fallback is nil, every byte is taken for the character with the same value (ISO-8859-1).
+func DecodeText(data string, fallback func(data string) string) (text string, encoding string, byteOrderMark bool) {
+	encoding, byteOrderMarkLength := DetectEncoding(data)
+	switch encoding {
+	case ENCODING_UTF8:
+		return data[byteOrderMarkLength:], encoding, true
+	case ENCODING_UTF16LE, ENCODING_UTF16BE:
+		return DecodeUTF16(data[byteOrderMarkLength:], encoding == ENCODING_UTF16BE), encoding, byteOrderMarkLength > 0
+	}
+	if utf8.ValidString(data) {
+		return data, ENCODING_UTF8, false
+	}
+	if fallback == nil {
+		fallback = decodeLatin1
+	}
+	return fallback(data), "", false
+}
+
+// DetectEncoding
+// Determines the encoding of text from its start. A byte-order mark decides the encoding (UTF-8, UTF-16LE or UTF-16BE),
+// and its length is returned along with it. Without one, text is only taken for UTF-16 if it is mostly ASCII characters
+// encoded in UTF-16: at least half of the pairs of bytes it starts with have a NUL byte in the same position, and none
+// have one in the other position. An empty name is returned for any other text.
+func DetectEncoding(start string) (encoding string, byteOrderMarkLength int) {
+	switch {
+	case strings.HasPrefix(start, "\xef\xbb\xbf"):
+		return ENCODING_UTF8, 3
+	case strings.HasPrefix(start, "\xff\xfe"):
+		return ENCODING_UTF16LE, 2
+	case strings.HasPrefix(start, "\xfe\xff"):
+		return ENCODING_UTF16BE, 2
+	}
+
+	sample := start
+	if len(sample) > ENCODING_SNIFF_LENGTH {
+		sample = sample[:ENCODING_SNIFF_LENGTH]
+	}
+	if len(sample) < utf16SniffMinimum {
+		return "", 0
+	}
+	evenNuls, oddNuls := 0, 0
+	for i := 0; i+1 < len(sample); i += 2 {
+		if sample[i] == 0 {
+			evenNuls++
+		}
+		if sample[i+1] == 0 {
+			oddNuls++
+		}
+	}
+	units := len(sample) / 2
+	if oddNuls == 0 && evenNuls*2 >= units {
+		return ENCODING_UTF16BE, 0
+	}
+	if evenNuls == 0 && oddNuls*2 >= units {
+		return ENCODING_UTF16LE, 0
+	}
+	return "", 0
+}
+
+// DecodeUTF16
+// Returns the text the UTF-16 bytes stand for. Bytes which cannot be decoded (e.g. half of a surrogate pair or a trailing odd byte)
+// become the replacement character (U+FFFD).
+func DecodeUTF16(data string, bigEndian bool) string {
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		if bigEndian {
+			units[i] = uint16(data[2*i])<<8 | uint16(data[2*i+1])
+		} else {
+			units[i] = uint16(data[2*i+1])<<8 | uint16(data[2*i])
+		}
+	}
+	text := string(utf16.Decode(units))
+	if len(data)%2 != 0 {
+		text += string(utf8.RuneError)
+	}
+	return text
+}
+
+// decodeLatin1
+// Returns the text in which every byte is the character with the same value
+func decodeLatin1(data string) string {
+	var builder strings.Builder
+	builder.Grow(len(data))
+	for i := 0; i < len(data); i++ {
+		builder.WriteRune(rune(data[i]))
+	}
+	return builder.String()
+}
@@ -297,7 +297,7 @@ func FindValueBetweenStringsFromFilesInDirectory(dirPath string, fileRegex strin
 }
 
 // GetTextOfFile
-// Reads and returns the text found in a file
+// Reads and returns the text found in a file, decoded into UTF-8 without its byte-order mark (see DecodeText)
 func GetTextOfFile(filePath string) (string, error) {
 	ret := ""
 	var err error = nil
@@ -305,7 +305,7 @@ func GetTextOfFile(filePath string) (string, error) {
 	if thisError != nil {
 		err = thisError
 	} else {
-		ret = string(content)
+		ret, _, _ = DecodeText(string(content), nil)
 	}
 	return ret, err
 }